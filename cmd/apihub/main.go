@@ -4,17 +4,30 @@ import (
 	"apihub/configs"
 	"apihub/internal/core"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
+	"apihub/internal/accesslog"
 	"apihub/internal/auth"
+	"apihub/internal/capture"
+	"apihub/internal/logger"
+	"apihub/internal/metrics"
+	"apihub/internal/middleware"
 	"apihub/internal/provider"
 	"apihub/internal/provider/registry"
+	"apihub/internal/retention"
 	"apihub/internal/router"
 	"apihub/internal/store/sqlite"
+	"apihub/internal/tracing"
 
 	// 导入 Swagger 文档
 	_ "apihub/docs"
@@ -46,6 +59,32 @@ func main() {
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
+	// 查找配置文件
+	configFilePath := findConfigFile(*configPath)
+
+	// 加载配置，数据库中的密钥在初始化完成后再补充
+	config, err := configs.LoadConfig(configFilePath, nil)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化日志，之后的日志均按配置输出
+	logCloser, err := logger.Setup(logger.Config{
+		Level:          config.Log.Level,
+		Format:         config.Log.Format,
+		Path:           config.Log.Path,
+		Console:        config.Log.Console,
+		MaxSize:        int64(config.Log.MaxSize) * 1024 * 1024,
+		RotateInterval: config.Log.RotateInterval,
+		MaxAge:         time.Duration(config.Log.MaxAge) * 24 * time.Hour,
+		MaxBackups:     config.Log.MaxBackups,
+	})
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	defer logCloser.Close()
+	slog.Info("使用配置文件", "path", configFilePath)
+
 	// 创建数据库连接
 	store := sqlite.NewSQLiteStore("apihub.db")
 
@@ -55,35 +94,55 @@ func main() {
 	// 执行系统初始化
 	ctx := context.Background()
 	if err := initService.InitializeSystem(ctx); err != nil {
-		log.Fatalf("系统初始化失败: %v", err)
+		fatal("系统初始化失败", err)
 	}
 
 	// 确保数据库保持连接
 	if err := store.Connect(); err != nil {
-		log.Fatalf("数据库连接失败: %v", err)
+		fatal("数据库连接失败", err)
 	}
+	metrics.RegisterDBStats(store.DB(), "apihub")
 
-	// 查找配置文件
-	configFilePath := findConfigFile(*configPath)
-	log.Printf("使用配置文件: %s", configFilePath)
+	// 从数据库补充密钥
+	if err := configs.LoadSecrets(config, store); err != nil {
+		fatal("加载配置失败", err)
+	}
 
-	// 加载配置
-	config, err := configs.LoadConfig(configFilePath, store)
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Enabled:     config.Tracing.Enabled,
+		ServiceName: config.Tracing.ServiceName,
+		Exporter:    config.Tracing.Exporter,
+		Endpoint:    config.Tracing.Endpoint,
+		Insecure:    config.Tracing.Insecure,
+		FilePath:    config.Tracing.FilePath,
+		SampleRatio: config.Tracing.SampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		fatal("初始化链路追踪失败", err)
 	}
 
 	// 创建认证服务配置
 	authConfig := auth.AuthConfig{
 		JWT: auth.JWTConfig{
-			AccessExpiry:  config.Auth.JWT.AccessExpiry,
-			Issuer:        config.Auth.JWT.Issuer,
-			PrivateKeyPEM: "", // 留空将自动生成密钥对
-			PublicKeyPEM:  "",
+			Secret:              config.Auth.JWT.Secret, // 用于加密保存在数据库中的签名私钥
+			AccessExpiry:        config.Auth.JWT.AccessExpiry,
+			RefreshExpiry:       config.Auth.JWT.RefreshExpiry,
+			Issuer:              config.Auth.JWT.Issuer,
+			KeyRotationInterval: config.Auth.JWT.KeyRotationInterval,
+			KeyOverlap:          config.Auth.JWT.KeyOverlap,
+			ClientTokenExpiry:   config.Auth.JWT.ClientTokenExpiry,
 		},
 		Crypto: auth.CryptoConfig{
 			SecretKey: config.Auth.APIKey.Secret, // 使用配置中的APIKey密钥
 		},
+		APIKey: auth.APIKeyConfig{
+			RotationGrace:        config.Auth.APIKey.RotationGrace,
+			MaxRotationGrace:     config.Auth.APIKey.MaxRotationGrace,
+			RotationNotifyBefore: config.Auth.APIKey.RotationNotifyBefore,
+			DisableUnusedDays:    config.Auth.APIKey.DisableUnusedDays,
+			SignatureMaxSkew:     config.Auth.APIKey.SignatureMaxSkew,
+		},
 		Cache: auth.CacheConfig{
 			DefaultExpiration: config.Auth.Cache.DefaultExpiration,
 			CleanupInterval:   config.Auth.Cache.CleanupInterval,
@@ -93,7 +152,7 @@ func main() {
 	// 创建认证服务
 	authServices, err := auth.NewAuthServices(authConfig, store)
 	if err != nil {
-		log.Fatalf("创建认证服务失败: %v", err)
+		fatal("创建认证服务失败", err)
 	}
 
 	// 创建服务注册中心
@@ -101,11 +160,56 @@ func main() {
 
 	// 注册功能API服务
 	if err := provider.RegisterServices(serviceRegistry); err != nil {
-		log.Fatalf("注册功能API服务失败: %v", err)
+		fatal("注册功能API服务失败", err)
+	}
+
+	// 创建路由配置
+	routerConfig := router.RouterConfig{
+		Admission: middleware.AdmissionConfig{
+			Enabled:             config.Admission.Enabled,
+			MaxInFlight:         config.Admission.MaxInFlight,
+			LatencyP95Threshold: config.Admission.LatencyP95Threshold,
+			MaxLogQueueDepth:    config.Admission.MaxLogQueueDepth,
+			CriticalFactor:      config.Admission.CriticalFactor,
+			RetryAfter:          config.Admission.RetryAfter,
+			LatencyWindow:       config.Admission.LatencyWindow,
+			LatencyMaxAge:       config.Admission.LatencyMaxAge,
+		},
+		AccessLog: accesslog.WriterConfig{
+			QueueSize:     config.AccessLog.QueueSize,
+			BatchSize:     config.AccessLog.BatchSize,
+			FlushInterval: config.AccessLog.FlushInterval,
+		},
+		TrustedProxies:  config.Server.TrustedProxies,
+		RemoteIPHeaders: config.Server.RemoteIPHeaders,
+		Metrics: metrics.Config{
+			Enabled: config.Metrics.Enabled,
+			Listen:  config.Metrics.Listen,
+			Token:   config.Metrics.Token,
+		},
+		Retention: retention.Config{
+			Enabled:           config.Retention.Enabled,
+			Interval:          config.Retention.Interval,
+			BatchSize:         config.Retention.BatchSize,
+			BatchPause:        config.Retention.BatchPause,
+			AccessLogDays:     config.Retention.AccessLogDays,
+			ArchiveAccessLogs: config.Retention.ArchiveAccessLogs,
+			ArchiveDir:        config.Retention.ArchiveDir,
+			UsageHourlyDays:   config.Retention.UsageHourlyDays,
+			UsageDailyDays:    config.Retention.UsageDailyDays,
+		},
+		Capture: capture.Config{
+			Enabled:             config.Capture.Enabled,
+			QueueSize:           config.Capture.QueueSize,
+			DefaultMaxBodyBytes: config.Capture.DefaultMaxBodyBytes,
+			MaxBodyBytes:        config.Capture.MaxBodyBytes,
+			DefaultRetention:    time.Duration(config.Capture.DefaultRetentionHours) * time.Hour,
+			MaxRetention:        time.Duration(config.Capture.MaxRetentionHours) * time.Hour,
+		},
 	}
 
 	// 创建路由器
-	mainRouter := router.NewRouter(store, authServices, serviceRegistry)
+	mainRouter := router.NewRouter(store, authServices, serviceRegistry, routerConfig)
 
 	// 设置路由
 	engine := mainRouter.SetupRoutes()
@@ -114,24 +218,83 @@ func main() {
 	address := config.Server.Host + ":" + fmt.Sprintf("%d", config.Server.Port)
 
 	// 启动服务器
-	log.Printf("启动APIHub服务器，监听地址 %s", address)
-	log.Println("API文档: http://" + address + "/swagger/index.html")
-	log.Println("认证端点:")
-	log.Println("  POST /api/v1/auth/login")
-	log.Println("  POST /api/v1/auth/logout")
-	log.Println("  GET  /api/v1/auth/profile")
-	log.Println("API密钥端点:")
-	log.Println("  GET  /api/v1/dashboard/apikeys/list")
-	log.Println("  POST /api/v1/dashboard/apikeys/generate")
-	log.Println("  POST /api/v1/dashboard/apikeys/delete")
-	log.Println("功能API端点:")
-	log.Println("  GET  /api/v1/provider/services")
-	log.Println("  POST /api/v1/provider/:service/execute")
-	log.Println("  POST /api/v1/provider/:service/public")
-
-	if err := engine.Run(address); err != nil {
-		log.Fatalf("启动服务器失败: %v", err)
+	slog.Info("启动APIHub服务器",
+		"address", address,
+		"docs", "http://"+address+"/swagger/index.html",
+	)
+
+	server := &http.Server{
+		Addr:         address,
+		Handler:      engine,
+		ReadTimeout:  time.Duration(config.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.Server.WriteTimeout) * time.Second,
+	}
+	// 实时访问流为长连接，关闭时主动结束，否则Shutdown会等待到超时
+	server.RegisterOnShutdown(mainRouter.CloseStreams)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("启动服务器失败", err)
+		}
+	}()
+
+	// 配置了独立监听地址时单独提供指标接口，避免暴露在公网端口上
+	var metricsServer *http.Server
+	if config.Metrics.Enabled && config.Metrics.Listen != "" {
+		metricsServer = &http.Server{
+			Addr:        config.Metrics.Listen,
+			Handler:     metrics.Handler(config.Metrics.Token),
+			ReadTimeout: 10 * time.Second,
+		}
+		slog.Info("启动指标服务", "address", config.Metrics.Listen)
+
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("启动指标服务失败", "error", err)
+			}
+		}()
 	}
+
+	// 等待退出信号后优雅关闭
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	slog.Info("正在关闭服务器")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 先停止接收新请求并等待处理中的请求完成
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("关闭HTTP服务器失败", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("关闭指标服务失败", "error", err)
+		}
+	}
+
+	// 再写入剩余的访问日志
+	if err := mainRouter.Shutdown(shutdownCtx); err != nil {
+		slog.Error("写入剩余访问日志失败", "error", err)
+	}
+
+	// 导出剩余的span
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("关闭链路追踪失败", "error", err)
+	}
+
+	if err := store.Close(); err != nil {
+		slog.Error("关闭数据库连接失败", "error", err)
+	}
+
+	slog.Info("服务器已关闭")
+}
+
+// fatal 记录错误日志后退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 // findConfigFile 查找配置文件
@@ -16,10 +16,16 @@ import (
 
 // Config 系统配置
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Auth     AuthConfig     `json:"auth"`
-	Log      LogConfig      `json:"log"`
+	Server    ServerConfig    `json:"server"`
+	Database  DatabaseConfig  `json:"database"`
+	Auth      AuthConfig      `json:"auth"`
+	Log       LogConfig       `json:"log"`
+	Admission AdmissionConfig `json:"admission"`
+	AccessLog AccessLogConfig `json:"access_log"`
+	Metrics   MetricsConfig   `json:"metrics"`
+	Tracing   TracingConfig   `json:"tracing"`
+	Retention RetentionConfig `json:"retention"`
+	Capture   CaptureConfig   `json:"capture"`
 }
 
 // ServerConfig 服务器配置
@@ -28,6 +34,12 @@ type ServerConfig struct {
 	Host         string `json:"host"`
 	ReadTimeout  int    `json:"read_timeout"`
 	WriteTimeout int    `json:"write_timeout"`
+
+	// TrustedProxies 受信任的反向代理地址或CIDR，只有来自这些地址的请求才会解析转发头获取客户端IP
+	// 为空时不信任任何代理，直接使用连接的对端地址
+	TrustedProxies []string `json:"trusted_proxies"`
+	// RemoteIPHeaders 用于获取客户端真实IP的请求头，按顺序检查
+	RemoteIPHeaders []string `json:"remote_ip_headers"`
 }
 
 // DatabaseConfig 数据库配置
@@ -41,12 +53,21 @@ type DatabaseConfig struct {
 // AuthConfig 认证配置
 type AuthConfig struct {
 	JWT struct {
-		Secret       string        `json:"secret"`
-		AccessExpiry time.Duration `json:"access_expiry"`
-		Issuer       string        `json:"issuer"`
+		Secret              string        `json:"secret"`
+		AccessExpiry        time.Duration `json:"access_expiry"`
+		RefreshExpiry       time.Duration `json:"refresh_expiry"` // 刷新令牌有效期，每次刷新后重新计算
+		Issuer              string        `json:"issuer"`
+		KeyRotationInterval time.Duration `json:"key_rotation_interval"` // 签名密钥轮换周期
+		KeyOverlap          time.Duration `json:"key_overlap"`           // 新密钥提前发布及旧密钥继续用于验证的时长，不小于访问令牌有效期
+		ClientTokenExpiry   time.Duration `json:"client_token_expiry"`   // OAuth2客户端凭据模式签发的访问令牌有效期
 	} `json:"jwt"`
 	APIKey struct {
-		Secret string `json:"secret"`
+		Secret               string        `json:"secret"`
+		RotationGrace        time.Duration `json:"rotation_grace"`         // 轮换时未指定宽限期时旧密钥继续有效的时长
+		MaxRotationGrace     time.Duration `json:"max_rotation_grace"`     // 轮换时允许指定的最长宽限期
+		RotationNotifyBefore time.Duration `json:"rotation_notify_before"` // 宽限期结束前多久通知密钥所有者和管理员
+		DisableUnusedDays    int           `json:"disable_unused_days"`    // 超过多少天未使用的密钥自动禁用，0表示不自动禁用
+		SignatureMaxSkew     time.Duration `json:"signature_max_skew"`     // 签名请求的时间戳与服务器时间允许的最大偏差
 	} `json:"apikey"`
 	Cache struct {
 		DefaultExpiration time.Duration `json:"default_expiration"`
@@ -56,9 +77,76 @@ type AuthConfig struct {
 
 // LogConfig 日志配置
 type LogConfig struct {
-	Level  string `json:"level"`
-	Format string `json:"format"`
-	Path   string `json:"path"`
+	Level  string `json:"level"`  // debug, info, warn, error
+	Format string `json:"format"` // json 或 text
+	Path   string `json:"path"`   // 日志目录，为空时只输出到标准输出
+
+	Console        bool          `json:"console"`         // 写入文件的同时是否输出到标准输出
+	MaxSize        int           `json:"max_size"`        // 单个日志文件最大大小(MB)，0表示不按大小轮转
+	RotateInterval time.Duration `json:"rotate_interval"` // 按时间轮转的间隔，0表示不按时间轮转
+	MaxAge         int           `json:"max_age"`         // 轮转文件保留天数，0表示不按时间清理
+	MaxBackups     int           `json:"max_backups"`     // 轮转文件最多保留个数，0表示不按个数清理
+}
+
+// AdmissionConfig 全局准入控制（过载保护）配置
+type AdmissionConfig struct {
+	Enabled             bool          `json:"enabled"`
+	MaxInFlight         int           `json:"max_in_flight"`         // 并发请求数阈值，0表示不检查
+	LatencyP95Threshold time.Duration `json:"latency_p95_threshold"` // 处理延迟P95阈值，0表示不检查
+	MaxLogQueueDepth    int           `json:"max_log_queue_depth"`   // 待写入访问日志数量阈值，0表示不检查
+	CriticalFactor      float64       `json:"critical_factor"`       // 超过 阈值*系数 时拒绝所有请求
+	RetryAfter          time.Duration `json:"retry_after"`           // 拒绝时返回的Retry-After
+	LatencyWindow       int           `json:"latency_window"`        // 计算延迟分位数的样本数
+	LatencyMaxAge       time.Duration `json:"latency_max_age"`       // 延迟样本有效期，过期样本不参与分位数计算
+}
+
+// AccessLogConfig 访问日志写入配置
+type AccessLogConfig struct {
+	QueueSize     int           `json:"queue_size"`     // 写入队列容量，队列满时丢弃新日志
+	BatchSize     int           `json:"batch_size"`     // 单批次最大写入条数
+	FlushInterval time.Duration `json:"flush_interval"` // 未满一批时的最长等待时间
+}
+
+// MetricsConfig Prometheus指标接口配置
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"` // 是否启用/metrics接口
+	Listen  string `json:"listen"`  // 独立监听地址（如127.0.0.1:9090），为空时挂载在主服务上
+	Token   string `json:"token"`   // 访问令牌，非空时要求请求携带 Authorization: Bearer <token>
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Enabled     bool    `json:"enabled"`
+	ServiceName string  `json:"service_name"` // 上报的服务名称
+	Exporter    string  `json:"exporter"`     // 导出方式: otlp, stdout, file
+	Endpoint    string  `json:"endpoint"`     // OTLP/HTTP地址（如localhost:4318），为空时使用OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    `json:"insecure"`     // OTLP是否使用HTTP而非HTTPS
+	FilePath    string  `json:"file_path"`    // file导出方式的输出文件路径
+	SampleRatio float64 `json:"sample_ratio"` // 采样比例，取值0~1
+}
+
+// RetentionConfig 数据保留清理配置
+type RetentionConfig struct {
+	Enabled           bool          `json:"enabled"`             // 是否定期执行清理
+	Interval          time.Duration `json:"interval"`            // 定期执行间隔
+	BatchSize         int           `json:"batch_size"`          // 单批次最多删除的行数
+	BatchPause        time.Duration `json:"batch_pause"`         // 批次之间的暂停时间
+	AccessLogDays     int           `json:"access_log_days"`     // 访问日志保留天数，0表示不清理
+	ArchiveAccessLogs bool          `json:"archive_access_logs"` // 删除前是否归档访问日志
+	ArchiveDir        string        `json:"archive_dir"`         // 归档目录
+	UsageHourlyDays   int           `json:"usage_hourly_days"`   // 小时粒度使用量汇总保留天数，0表示不清理
+	UsageDailyDays    int           `json:"usage_daily_days"`    // 天粒度使用量汇总保留天数，0表示不清理
+}
+
+// CaptureConfig 请求/响应抓取配置
+// 只有命中Dashboard中配置的抓取规则的请求才会被抓取
+type CaptureConfig struct {
+	Enabled               bool `json:"enabled"`                 // 是否允许抓取，关闭时忽略所有抓取规则
+	QueueSize             int  `json:"queue_size"`              // 待写入队列容量，队列满时丢弃新的抓取记录
+	DefaultMaxBodyBytes   int  `json:"default_max_body_bytes"`  // 规则未设置时请求体和响应体各自最多保存的字节数
+	MaxBodyBytes          int  `json:"max_body_bytes"`          // 规则可设置的最大保存字节数
+	DefaultRetentionHours int  `json:"default_retention_hours"` // 规则未设置时抓取记录的保留小时数
+	MaxRetentionHours     int  `json:"max_retention_hours"`     // 规则可设置的最长保留小时数
 }
 
 // LoadConfig 加载配置
@@ -82,14 +170,23 @@ func LoadConfig(configPath string, store store.Store) (*Config, error) {
 
 	// 5. 如果提供了存储实例，尝试从数据库加载密钥
 	if store != nil {
-		if err := loadSecretsFromDB(store, config); err != nil {
-			return nil, fmt.Errorf("从数据库加载密钥失败: %w", err)
+		if err := LoadSecrets(config, store); err != nil {
+			return nil, err
 		}
 	}
 
 	return config, nil
 }
 
+// LoadSecrets 从数据库加载配置中未设置的密钥
+// 用于先加载配置（如初始化日志）、待数据库初始化完成后再补充密钥的场景
+func LoadSecrets(config *Config, store store.Store) error {
+	if err := loadSecretsFromDB(store, config); err != nil {
+		return fmt.Errorf("从数据库加载密钥失败: %w", err)
+	}
+	return nil
+}
+
 // defaultConfig 返回默认配置
 func defaultConfig() *Config {
 	config := &Config{
@@ -98,6 +195,8 @@ func defaultConfig() *Config {
 			Host:         "0.0.0.0",
 			ReadTimeout:  60,
 			WriteTimeout: 60,
+
+			RemoteIPHeaders: []string{"X-Forwarded-For", "X-Real-IP"},
 		},
 		Database: DatabaseConfig{
 			Type:     "sqlite",
@@ -109,16 +208,76 @@ func defaultConfig() *Config {
 			Level:  "info",
 			Format: "json",
 			Path:   "logs",
+
+			Console:        true,
+			MaxSize:        100,
+			RotateInterval: 24 * time.Hour,
+			MaxAge:         30,
+			MaxBackups:     30,
+		},
+		Admission: AdmissionConfig{
+			Enabled:             true,
+			MaxInFlight:         500,
+			LatencyP95Threshold: 2 * time.Second,
+			MaxLogQueueDepth:    5000,
+			CriticalFactor:      1.5,
+			RetryAfter:          5 * time.Second,
+			LatencyWindow:       1000,
+			LatencyMaxAge:       30 * time.Second,
+		},
+		AccessLog: AccessLogConfig{
+			QueueSize:     10000,
+			BatchSize:     200,
+			FlushInterval: time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: false,
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			ServiceName: "apihub",
+			Exporter:    "otlp",
+			Insecure:    true,
+			FilePath:    "logs/traces.jsonl",
+			SampleRatio: 1,
+		},
+		Retention: RetentionConfig{
+			Enabled:           true,
+			Interval:          24 * time.Hour,
+			BatchSize:         1000,
+			BatchPause:        50 * time.Millisecond,
+			AccessLogDays:     90,
+			ArchiveAccessLogs: false,
+			ArchiveDir:        "archive",
+			UsageHourlyDays:   30,
+			UsageDailyDays:    365,
+		},
+		Capture: CaptureConfig{
+			Enabled:               true,
+			QueueSize:             1000,
+			DefaultMaxBodyBytes:   16 * 1024,
+			MaxBodyBytes:          256 * 1024,
+			DefaultRetentionHours: 24,
+			MaxRetentionHours:     7 * 24,
 		},
 	}
 
 	// 设置JWT配置
 	config.Auth.JWT.Secret = ""
-	config.Auth.JWT.AccessExpiry = 30 * time.Minute
+	config.Auth.JWT.AccessExpiry = 15 * time.Minute
+	config.Auth.JWT.RefreshExpiry = 7 * 24 * time.Hour
 	config.Auth.JWT.Issuer = "apihub"
+	config.Auth.JWT.KeyRotationInterval = 30 * 24 * time.Hour
+	config.Auth.JWT.KeyOverlap = 48 * time.Hour
+	config.Auth.JWT.ClientTokenExpiry = 15 * time.Minute
 
 	// 设置APIKey配置
 	config.Auth.APIKey.Secret = ""
+	config.Auth.APIKey.RotationGrace = 7 * 24 * time.Hour
+	config.Auth.APIKey.MaxRotationGrace = 30 * 24 * time.Hour
+	config.Auth.APIKey.RotationNotifyBefore = 24 * time.Hour
+	config.Auth.APIKey.DisableUnusedDays = 0
+	config.Auth.APIKey.SignatureMaxSkew = 5 * time.Minute
 
 	// 设置缓存配置
 	config.Auth.Cache.DefaultExpiration = 30 * time.Minute
@@ -177,10 +336,29 @@ func overrideFromEnv(config *Config) {
 		config.Auth.APIKey.Secret = apiKeySecret
 	}
 
+	// 指标配置
+	if metricsToken := os.Getenv("APIHUB_METRICS_TOKEN"); metricsToken != "" {
+		config.Metrics.Token = metricsToken
+	}
+
+	// 链路追踪配置
+	if tracingEnabled := os.Getenv("APIHUB_TRACING_ENABLED"); tracingEnabled != "" {
+		config.Tracing.Enabled = tracingEnabled == "true" || tracingEnabled == "1"
+	}
+	if tracingExporter := os.Getenv("APIHUB_TRACING_EXPORTER"); tracingExporter != "" {
+		config.Tracing.Exporter = tracingExporter
+	}
+
 	// 日志配置
 	if logLevel := os.Getenv("APIHUB_LOG_LEVEL"); logLevel != "" {
 		config.Log.Level = logLevel
 	}
+	if logFormat := os.Getenv("APIHUB_LOG_FORMAT"); logFormat != "" {
+		config.Log.Format = logFormat
+	}
+	if logPath, ok := os.LookupEnv("APIHUB_LOG_PATH"); ok {
+		config.Log.Path = logPath
+	}
 }
 
 // loadSecretsFromDB 从数据库加载密钥
@@ -0,0 +1,375 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// WriterConfig 访问日志写入器配置
+type WriterConfig struct {
+	QueueSize     int           // 队列容量，队列满时丢弃新日志
+	BatchSize     int           // 单批次最大写入条数
+	FlushInterval time.Duration // 未满一批时的最长等待时间
+}
+
+// Entry 待写入的访问日志条目
+type Entry struct {
+	Log *model.AccessLog
+
+	// DefaultLimit 用户首次调用该服务时创建的默认每日配额
+	DefaultLimit int
+}
+
+// WriterStats 访问日志写入器统计信息
+type WriterStats struct {
+	QueueDepth    int       `json:"queue_depth"`    // 队列中及正在写入的日志数
+	QueueCapacity int       `json:"queue_capacity"` // 队列容量
+	Enqueued      int64     `json:"enqueued"`       // 已入队日志数
+	Written       int64     `json:"written"`        // 已写入日志数
+	Dropped       int64     `json:"dropped"`        // 因队列满或已关闭被丢弃的日志数
+	Failed        int64     `json:"failed"`         // 写入失败的日志数
+	Batches       int64     `json:"batches"`        // 已提交的批次数
+	LastFlushAt   time.Time `json:"last_flush_at"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// quotaKey 配额聚合键
+type quotaKey struct {
+	userID      int
+	serviceName string
+}
+
+// quotaDelta 单批次内的配额增量
+type quotaDelta struct {
+	cost         int
+	defaultLimit int
+}
+
+// Writer 访问日志批量写入器
+// 请求处理完成后将日志放入有界队列，由后台协程按批次在单个事务中写入日志并累加配额
+type Writer struct {
+	store  store.Store
+	config WriterConfig
+
+	queue   chan Entry
+	mu      sync.RWMutex // 保护closed与关闭队列之间的竞争
+	closed  bool
+	done    chan struct{}
+	writing atomic.Int64 // 已出队但尚未写入完成的日志数
+
+	enqueued atomic.Int64
+	written  atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	batches  atomic.Int64
+
+	statsMu     sync.Mutex
+	lastFlushAt time.Time
+	lastError   string
+
+	listeners []func(*model.AccessLog)
+}
+
+// NewWriter 创建访问日志写入器
+func NewWriter(store store.Store, config WriterConfig) *Writer {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 200
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	return &Writer{
+		store:  store,
+		config: config,
+		queue:  make(chan Entry, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台写入协程
+func (w *Writer) Start() {
+	go w.run()
+}
+
+// AddListener 添加访问日志监听函数，每条日志入队时同步调用
+// 监听函数在请求处理路径上执行，不能阻塞。应在Start之前调用
+func (w *Writer) AddListener(fn func(*model.AccessLog)) {
+	w.listeners = append(w.listeners, fn)
+}
+
+// Enqueue 将访问日志放入写入队列
+// 不会阻塞请求处理，队列已满或写入器已关闭时丢弃并返回false
+func (w *Writer) Enqueue(entry Entry) bool {
+	for _, listener := range w.listeners {
+		listener(entry.Log)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case w.queue <- entry:
+		w.enqueued.Add(1)
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// QueueDepth 获取尚未写入完成的日志数量
+func (w *Writer) QueueDepth() int {
+	return len(w.queue) + int(w.writing.Load())
+}
+
+// Stats 获取写入器统计信息
+func (w *Writer) Stats() WriterStats {
+	w.statsMu.Lock()
+	lastFlushAt, lastError := w.lastFlushAt, w.lastError
+	w.statsMu.Unlock()
+
+	return WriterStats{
+		QueueDepth:    w.QueueDepth(),
+		QueueCapacity: w.config.QueueSize,
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Failed:        w.failed.Load(),
+		Batches:       w.batches.Load(),
+		LastFlushAt:   lastFlushAt,
+		LastError:     lastError,
+	}
+}
+
+// Close 停止接收新日志并将队列中剩余日志全部写入
+// ctx超时时返回错误，未写入的日志将丢失
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待访问日志写入完成超时，剩余%d条: %w", w.QueueDepth(), ctx.Err())
+	}
+}
+
+// run 后台写入循环
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, w.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.flush(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			w.writing.Add(1)
+			batch = append(batch, entry)
+			if len(batch) >= w.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush 在单个事务中写入一批访问日志，并累加使用量汇总和配额使用量
+func (w *Writer) flush(batch []Entry) {
+	defer w.writing.Add(-int64(len(batch)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := w.writeBatch(ctx, batch)
+
+	w.statsMu.Lock()
+	w.lastFlushAt = time.Now()
+	if err != nil {
+		w.lastError = err.Error()
+	}
+	w.statsMu.Unlock()
+
+	if err != nil {
+		w.failed.Add(int64(len(batch)))
+		slog.Error("批量写入访问日志失败", "count", len(batch), "error", err)
+		return
+	}
+
+	w.written.Add(int64(len(batch)))
+	w.batches.Add(1)
+}
+
+// writeBatch 写入一批访问日志
+func (w *Writer) writeBatch(ctx context.Context, batch []Entry) error {
+	logs := make([]*model.AccessLog, 0, len(batch))
+	deltas := make(map[quotaKey]*quotaDelta)
+	rollups := make(map[model.UsageRollup]*model.UsageRollup)
+	lastUsed := make(map[int]*model.AccessLog)
+
+	for _, entry := range batch {
+		if entry.Log.CreatedAt.IsZero() {
+			entry.Log.CreatedAt = time.Now()
+		}
+		logs = append(logs, entry.Log)
+		addRollups(rollups, entry.Log)
+
+		// 每个API密钥只保留本批次中最后一次调用，被拒绝的请求不算作使用
+		if entry.Log.APIKeyID > 0 && !model.IsRejectedBeforeCall(entry.Log.ErrorCode) {
+			if last, exists := lastUsed[entry.Log.APIKeyID]; !exists || !entry.Log.CreatedAt.Before(last.CreatedAt) {
+				lastUsed[entry.Log.APIKeyID] = entry.Log
+			}
+		}
+
+		// 同一用户同一服务的配额增量合并为一次更新
+		if entry.Log.UserID > 0 && entry.Log.Cost > 0 {
+			key := quotaKey{userID: entry.Log.UserID, serviceName: entry.Log.ServiceName}
+			delta, exists := deltas[key]
+			if !exists {
+				delta = &quotaDelta{defaultLimit: entry.DefaultLimit}
+				deltas[key] = delta
+			}
+			delta.cost += entry.Log.Cost
+		}
+	}
+
+	tx, err := w.store.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				slog.Error("回滚访问日志事务失败", "error", rbErr)
+			}
+		}
+	}()
+
+	if err := tx.AccessLogs().CreateBatch(ctx, logs); err != nil {
+		return err
+	}
+
+	// 使用量汇总与日志在同一事务中写入，保证统计与明细一致
+	rollupList := make([]*model.UsageRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		rollupList = append(rollupList, rollup)
+	}
+	if err := tx.UsageRollups().Increment(ctx, rollupList); err != nil {
+		return err
+	}
+
+	// 配额更新失败（如用户已被删除）不影响日志写入
+	for key, delta := range deltas {
+		if err := incrementQuota(ctx, tx, key, delta); err != nil {
+			slog.Warn("更新配额失败", "user_id", key.userID, "service", key.serviceName, "error", err)
+		}
+	}
+
+	// 最后使用信息随日志批量更新，避免在认证路径上写库；更新失败不影响日志写入
+	for apiKeyID, accessLog := range lastUsed {
+		if err := tx.APIKeys().TouchLastUsed(ctx, apiKeyID, accessLog.CreatedAt, accessLog.ClientIP); err != nil {
+			slog.Warn("更新API密钥最后使用信息失败", "api_key_id", apiKeyID, "error", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	committed = true
+
+	return nil
+}
+
+// addRollups 将一条访问日志累加到各统计粒度的汇总记录中
+// 汇总记录以只包含维度字段的UsageRollup作为键
+func addRollups(rollups map[model.UsageRollup]*model.UsageRollup, accessLog *model.AccessLog) {
+	for _, granularity := range model.UsageGranularities {
+		key := model.UsageRollup{
+			Granularity: granularity,
+			Bucket:      model.UsageBucket(accessLog.CreatedAt, granularity),
+			UserID:      max(accessLog.UserID, 0),
+			APIKeyID:    max(accessLog.APIKeyID, 0),
+			ServiceName: accessLog.ServiceName,
+			StatusClass: model.StatusClass(accessLog.Status),
+		}
+
+		rollup, exists := rollups[key]
+		if !exists {
+			rollup = &model.UsageRollup{}
+			*rollup = key
+			rollups[key] = rollup
+		}
+
+		rollup.TotalCalls++
+		rollup.TotalCost += int64(accessLog.Cost)
+		rollup.TotalDurationMs += accessLog.DurationMs
+		rollup.MaxDurationMs = max(rollup.MaxDurationMs, accessLog.DurationMs)
+	}
+}
+
+// incrementQuota 累加每日配额使用量，配额不存在时先创建默认配额
+func incrementQuota(ctx context.Context, tx store.Transaction, key quotaKey, delta *quotaDelta) error {
+	_, err := tx.Quotas().GetByUserAndService(ctx, key.userID, key.serviceName, "daily")
+	if err != nil {
+		var dbErr *store.DBError
+		if !errors.As(err, &dbErr) || dbErr.Code != store.ErrNotFound {
+			return fmt.Errorf("查询配额失败: %w", err)
+		}
+
+		quota := &model.ServiceQuota{
+			UserID:      key.userID,
+			ServiceName: key.serviceName,
+			TimeWindow:  "daily",
+			Usage:       0,
+			LimitValue:  delta.defaultLimit,
+			ResetTime:   time.Now().Add(24 * time.Hour),
+		}
+		if err := tx.Quotas().Create(ctx, quota); err != nil {
+			return fmt.Errorf("创建配额失败: %w", err)
+		}
+	}
+
+	if err := tx.Quotas().IncrementUsage(ctx, key.userID, key.serviceName, "daily", delta.cost); err != nil {
+		return fmt.Errorf("增加使用量失败: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"apihub/internal/logger"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// Redacted 敏感字段在审计日志中的替代值
+const Redacted = "[REDACTED]"
+
+// sensitiveFields 不写入审计日志明文的字段，变化时只记录为Redacted
+var sensitiveFields = map[string]bool{
+	"password": true,
+	"api_key":  true,
+	"secret":   true,
+	"token":    true,
+}
+
+// Actor 操作人信息
+type Actor struct {
+	UserID   int
+	Username string
+	ClientIP string
+}
+
+// actorKey 操作人在context中的键
+type actorKey struct{}
+
+// WithActor 将操作人存入context，之后在该context中记录的审计日志会带上操作人信息
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 从context获取操作人
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// Record 记录一条审计日志
+// before和after为变更前后的对象，可为nil；两者都不为nil时只记录发生变化的字段。
+// repo应取自执行变更的事务，保证审计日志与变更同时提交或回滚
+func Record(ctx context.Context, repo store.AuditLogRepository, action, targetType, targetID string, before, after any) error {
+	entry, err := NewEntry(ctx, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	if err := repo.Append(ctx, entry); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// NewEntry 根据context中的操作人和请求ID构造审计日志
+func NewEntry(ctx context.Context, action, targetType, targetID string, before, after any) (*model.AuditLog, error) {
+	beforeFields, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	if beforeFields != nil && afterFields != nil {
+		beforeFields, afterFields = diff(beforeFields, afterFields)
+	}
+
+	entry := &model.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  logger.RequestIDFromContext(ctx),
+	}
+	if actor, ok := ActorFromContext(ctx); ok {
+		entry.ActorID = actor.UserID
+		entry.ActorName = actor.Username
+		entry.ClientIP = actor.ClientIP
+	}
+
+	if entry.Before, err = encode(beforeFields); err != nil {
+		return nil, err
+	}
+	if entry.After, err = encode(afterFields); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// snapshot 将对象转换为字段表，敏感字段替换为Redacted
+func snapshot(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("序列化审计对象失败: %w", err)
+	}
+	fields := make(map[string]any)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("序列化审计对象失败: %w", err)
+	}
+
+	for name, value := range fields {
+		if sensitiveFields[name] && value != nil && value != "" {
+			fields[name] = sensitiveValue{raw: value}
+		}
+	}
+	return fields, nil
+}
+
+// sensitiveValue 敏感字段的原值，只用于比较是否变化，序列化时输出Redacted
+type sensitiveValue struct {
+	raw any
+}
+
+// MarshalJSON 输出Redacted
+func (sensitiveValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// diff 只保留前后不同的字段
+func diff(before, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := make(map[string]any)
+	changedAfter := make(map[string]any)
+
+	for name, value := range before {
+		afterValue, ok := after[name]
+		if ok && reflect.DeepEqual(value, afterValue) {
+			continue
+		}
+		changedBefore[name] = value
+		if ok {
+			changedAfter[name] = afterValue
+		}
+	}
+	for name, value := range after {
+		if _, ok := before[name]; !ok {
+			changedAfter[name] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+// encode 序列化字段表，空表返回nil
+func encode(fields map[string]any) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("序列化审计对象失败: %w", err)
+	}
+	return data, nil
+}
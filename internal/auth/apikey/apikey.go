@@ -2,73 +2,183 @@ package apikey
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
+	"apihub/internal/audit"
 	"apihub/internal/auth/crypto"
+	"apihub/internal/auth/permission"
 	"apihub/internal/model"
 	"apihub/internal/store"
 )
 
+// KeyPrefix 新格式APIKey的固定前缀，完整格式为 ahk_<密钥ID>_<随机部分>
+const KeyPrefix = "ahk"
+
+// hashKeyPurpose 从主密钥派生APIKey哈希密钥时使用的用途标识
+// 主密钥同时用作AES加密密钥，哈希使用派生的子密钥，不直接使用主密钥
+const hashKeyPurpose = "apihub/apikey-hash"
+
+const (
+	keyIDBytes         = 6  // 密钥ID随机字节数，十六进制编码后12个字符
+	keySecretBytes     = 24 // 随机部分字节数，十六进制编码后48个字符
+	legacyPrefixLength = 8  // 旧版本密钥取前8个字符作为查找前缀
+)
+
+// APIKey权限范围相关错误
+var (
+	ErrAPIKeyNotFound    = errors.New("API密钥不存在")
+	ErrInvalidScope      = errors.New("无效的权限范围")
+	ErrScopeNotPermitted = errors.New("权限范围超出用户自身权限")
+)
+
+// Config APIKey服务配置
+type Config struct {
+	Rotation      RotationConfig
+	DisableUnused time.Duration // 超过该时长未使用的密钥自动禁用，0表示不自动禁用
+	MaxClockSkew  time.Duration // 签名请求的时间戳与服务器时间允许的最大偏差
+}
+
 // APIKeyService APIKey服务
+// APIKey只保存前缀和HMAC-SHA256哈希，明文只在创建和轮换时返回一次
 type APIKeyService struct {
-	store         store.Store
-	cryptoService crypto.CryptoService
+	store             store.Store
+	hashSecret        []byte
+	cryptoService     crypto.CryptoService // 加密保存签名密钥，以及解密迁移旧版本AES加密保存的密钥
+	permissionService *permission.PermissionService
+	rotation          RotationConfig
+	disableUnused     time.Duration
+	maxClockSkew      time.Duration
 }
 
 // NewAPIKeyService 创建APIKey服务实例
-func NewAPIKeyService(store store.Store, cryptoService crypto.CryptoService) *APIKeyService {
+// hashSecret为主密钥，APIKey的HMAC哈希使用由其派生的子密钥计算，修改后已有的APIKey全部失效
+func NewAPIKeyService(store store.Store, hashSecret string, cryptoService crypto.CryptoService, permissionService *permission.PermissionService, config Config) *APIKeyService {
+	rotation := config.Rotation
+	if rotation.DefaultGrace <= 0 {
+		rotation.DefaultGrace = 7 * 24 * time.Hour
+	}
+	if rotation.MaxGrace < rotation.DefaultGrace {
+		rotation.MaxGrace = max(rotation.DefaultGrace, 30*24*time.Hour)
+	}
+	if rotation.NotifyBefore <= 0 {
+		rotation.NotifyBefore = 24 * time.Hour
+	}
+	if config.MaxClockSkew <= 0 {
+		config.MaxClockSkew = 5 * time.Minute
+	}
+
 	return &APIKeyService{
-		store:         store,
-		cryptoService: cryptoService,
+		store:             store,
+		hashSecret:        crypto.DeriveKey(hashSecret, hashKeyPurpose),
+		cryptoService:     cryptoService,
+		permissionService: permissionService,
+		rotation:          rotation,
+		disableUnused:     max(config.DisableUnused, 0),
+		maxClockSkew:      config.MaxClockSkew,
 	}
 }
 
-// GenerateAPIKey 生成新的APIKey
-func (s *APIKeyService) GenerateAPIKey(length int) (string, error) {
-	if length <= 0 {
-		length = 32 // 默认32字符
+// GenerateAPIKey 生成新的APIKey，返回完整密钥及其查找前缀
+func (s *APIKeyService) GenerateAPIKey() (string, string, error) {
+	keyID, err := randomHex(keyIDBytes)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomHex(keySecretBytes)
+	if err != nil {
+		return "", "", err
 	}
 
-	// 生成随机字节
-	bytes := make([]byte, length/2)
+	prefix := KeyPrefix + "_" + keyID
+	return prefix + "_" + secret, prefix, nil
+}
+
+// randomHex 生成n个随机字节并编码为十六进制
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", fmt.Errorf("failed to generate random bytes: %w", err)
 	}
-
-	// 转换为十六进制字符串
 	return hex.EncodeToString(bytes), nil
 }
 
+// hashKey 计算完整密钥的HMAC-SHA256
+func (s *APIKeyService) hashKey(key string) string {
+	mac := hmac.New(sha256.New, s.hashSecret)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// keyPrefixOf 获取密钥的查找前缀，格式不正确时返回空字符串
+func keyPrefixOf(key string) string {
+	if strings.HasPrefix(key, KeyPrefix+"_") {
+		parts := strings.SplitN(key, "_", 3)
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return ""
+		}
+		return parts[0] + "_" + parts[1]
+	}
+
+	// 旧版本密钥为不带前缀的十六进制字符串
+	if len(key) < legacyPrefixLength {
+		return ""
+	}
+	return key[:legacyPrefixLength]
+}
+
 // CreateAPIKey 创建APIKey记录
-func (s *APIKeyService) CreateAPIKey(userID int, name, description string, expiresAt *time.Time, scopes []string) (*model.APIKey, error) {
-	// 生成APIKey
-	keyString, err := s.GenerateAPIKey(32)
+// scopes为空时使用默认权限范围，不能超出用户自身权限；restrictions为空时不限制使用
+// ctx中的操作人信息用于记录审计日志
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID int, name, description string, expiresAt *time.Time, scopes []string, restrictions model.APIKeyRestrictions) (*model.APIKey, error) {
+	owner, err := s.store.Users().GetByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("生成API密钥失败: %w", err)
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	scopes, err = s.normalizeScopes(ctx, owner, scopes)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err = normalizeRestrictions(restrictions)
+	if err != nil {
+		return nil, err
 	}
 
-	// 加密APIKey
-	encryptedKey, err := s.cryptoService.Encrypt(keyString)
+	// 生成APIKey
+	keyString, prefix, err := s.GenerateAPIKey()
 	if err != nil {
-		return nil, fmt.Errorf("加密API密钥失败: %w", err)
+		return nil, fmt.Errorf("生成API密钥失败: %w", err)
 	}
 
-	// 创建APIKey模型
+	// 创建APIKey模型，只保存前缀和哈希
 	apiKey := &model.APIKey{
-		UserID:    userID,
-		KeyName:   name,
-		APIKey:    encryptedKey, // 存储加密后的APIKey
-		Status:    model.APIKeyStatusActive,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
+		UserID:       userID,
+		KeyName:      name,
+		KeyPrefix:    prefix,
+		KeyHash:      s.hashKey(keyString),
+		Scopes:       scopes,
+		Restrictions: restrictions,
+		Status:       model.APIKeyStatusActive,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    time.Now(),
 	}
 
-	// 保存到数据库
-	err = s.store.APIKeys().Create(context.Background(), apiKey)
+	// 保存到数据库，同时记录审计日志
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Create(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyCreate,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKey.ID), nil, apiKey)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("创建API密钥失败: %w", err)
 	}
@@ -79,68 +189,111 @@ func (s *APIKeyService) CreateAPIKey(userID int, name, description string, expir
 }
 
 // ValidateAPIKey 验证APIKey
-func (s *APIKeyService) ValidateAPIKey(keyString string) (*model.APIKey, error) {
+// ctx用于关联调用方的链路追踪和取消
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, keyString string) (*model.APIKey, error) {
 	if keyString == "" {
 		return nil, errors.New("API密钥不能为空")
 	}
 
-	fmt.Printf("验证API密钥: %s...\n", keyString[:4])
-
-	// 加密输入的API密钥
-	encryptedKey, err := s.cryptoService.Encrypt(keyString)
-	if err != nil {
-		return nil, fmt.Errorf("加密API密钥失败: %w", err)
+	prefix := keyPrefixOf(keyString)
+	if prefix == "" {
+		return nil, errors.New("API密钥格式不正确")
 	}
 
-	// 直接使用加密后的密钥查询数据库
-	apiKey, err := s.store.APIKeys().GetByKey(context.Background(), encryptedKey)
+	// 按前缀查找候选记录，再以常量时间比对哈希
+	candidates, err := s.store.APIKeys().GetByPrefix(ctx, prefix)
 	if err != nil {
 		return nil, fmt.Errorf("API密钥验证失败: %w", err)
 	}
 
-	// 检查APIKey状态
-	if apiKey.Status != model.APIKeyStatusActive {
-		return nil, errors.New("API密钥未激活")
+	keyHash := s.hashKey(keyString)
+	var apiKey *model.APIKey
+	for _, candidate := range candidates {
+		if candidate.KeyHash != "" && hmac.Equal([]byte(candidate.KeyHash), []byte(keyHash)) {
+			apiKey = candidate
+			break
+		}
+	}
+	if apiKey == nil {
+		return nil, errors.New("API密钥不存在")
 	}
 
-	// 检查过期时间
-	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
-		return nil, errors.New("API密钥已过期")
+	if err := checkAPIKeyStatus(apiKey, time.Now()); err != nil {
+		return nil, err
 	}
 
-	// 返回APIKey（包含明文密钥）
-	apiKey.APIKey = keyString
 	return apiKey, nil
 }
 
-// GetAPIKeysByUserID 获取用户的所有APIKey
-func (s *APIKeyService) GetAPIKeysByUserID(userID int) ([]*model.APIKey, error) {
-	apiKeys, err := s.store.APIKeys().GetByUserID(context.Background(), userID)
+// checkAPIKeyStatus 检查APIKey是否已启用且未过期
+func checkAPIKeyStatus(apiKey *model.APIKey, now time.Time) error {
+	if apiKey.Status != model.APIKeyStatusActive {
+		return errors.New("API密钥未激活")
+	}
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return errors.New("API密钥已过期")
+	}
+	return nil
+}
+
+// MigrateLegacyKeys 将旧版本AES加密保存的APIKey解密后重新计算哈希，并清除密文
+// 无法解密的密钥保持原样并记录警告，这些密钥无法通过验证，需要用户重新生成
+func (s *APIKeyService) MigrateLegacyKeys(ctx context.Context) (int, error) {
+	legacyKeys, err := s.store.APIKeys().ListLegacy(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+		return 0, fmt.Errorf("获取待迁移API密钥失败: %w", err)
+	}
+	if len(legacyKeys) == 0 {
+		return 0, nil
 	}
 
-	// 解密所有APIKey
-	for _, apiKey := range apiKeys {
-		decryptedKey, err := s.cryptoService.Decrypt(apiKey.APIKey)
-		if err != nil {
-			// 如果解密失败，设置为空字符串而不是返回错误
-			apiKey.APIKey = ""
+	migrated := make([]*model.APIKey, 0, len(legacyKeys))
+	for _, apiKey := range legacyKeys {
+		keyString, err := s.cryptoService.Decrypt(apiKey.LegacyKey)
+		if err != nil || keyPrefixOf(keyString) == "" {
+			slog.WarnContext(ctx, "旧版本API密钥解密失败，跳过迁移", "api_key_id", apiKey.ID, "error", err)
 			continue
 		}
-		apiKey.APIKey = decryptedKey
+
+		apiKey.KeyPrefix = keyPrefixOf(keyString)
+		apiKey.KeyHash = s.hashKey(keyString)
+		apiKey.LegacyKey = ""
+		migrated = append(migrated, apiKey)
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		for _, apiKey := range migrated {
+			if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("迁移旧版本API密钥失败: %w", err)
+	}
+
+	return len(migrated), nil
+}
+
+// GetAPIKeysByUserID 获取用户的所有APIKey，不包含明文密钥
+func (s *APIKeyService) GetAPIKeysByUserID(userID int) ([]*model.APIKey, error) {
+	apiKeys, err := s.store.APIKeys().GetByUserID(context.Background(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
 	}
 
 	return apiKeys, nil
 }
 
 // UpdateAPIKey 更新APIKey
-func (s *APIKeyService) UpdateAPIKey(apiKeyID int, name string, status int, expiresAt *time.Time) error {
+func (s *APIKeyService) UpdateAPIKey(ctx context.Context, apiKeyID int, name string, status int, expiresAt *time.Time) error {
 	// 获取现有APIKey
-	apiKey, err := s.store.APIKeys().GetByID(context.Background(), apiKeyID)
+	apiKey, err := s.store.APIKeys().GetByID(ctx, apiKeyID)
 	if err != nil {
 		return fmt.Errorf("failed to get API key: %w", err)
 	}
+	before := *apiKey
 
 	// 更新字段
 	if name != "" {
@@ -153,8 +306,14 @@ func (s *APIKeyService) UpdateAPIKey(apiKeyID int, name string, status int, expi
 		apiKey.ExpiresAt = expiresAt
 	}
 
-	// 保存更新
-	err = s.store.APIKeys().Update(context.Background(), apiKey)
+	// 保存更新，同时记录审计日志
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyUpdate,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKeyID), &before, apiKey)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update API key: %w", err)
 	}
@@ -162,61 +321,183 @@ func (s *APIKeyService) UpdateAPIKey(apiKeyID int, name string, status int, expi
 	return nil
 }
 
-// RevokeAPIKey 撤销APIKey
-func (s *APIKeyService) RevokeAPIKey(apiKeyID int) error {
-	return s.UpdateAPIKey(apiKeyID, "", model.APIKeyStatusDisabled, nil)
+// UpdateAPIKeyScopes 更新用户自己的APIKey的权限范围，不能超出用户自身权限
+func (s *APIKeyService) UpdateAPIKeyScopes(ctx context.Context, userID, apiKeyID int, scopes []string) (*model.APIKey, error) {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.store.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	scopes, err = s.normalizeScopes(ctx, owner, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *apiKey
+	apiKey.Scopes = scopes
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyUpdate,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKeyID), &before, apiKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新API密钥权限范围失败: %w", err)
+	}
+
+	return apiKey, nil
 }
 
-// DeleteAPIKey 删除APIKey
-func (s *APIKeyService) DeleteAPIKey(apiKeyID int) error {
-	err := s.store.APIKeys().Delete(context.Background(), apiKeyID)
+// UpdateAPIKeyRestrictions 更新用户自己的APIKey的使用限制，立即生效
+// 已累计的使用次数保留，提高最大使用次数后可以继续使用
+func (s *APIKeyService) UpdateAPIKeyRestrictions(ctx context.Context, userID, apiKeyID int, restrictions model.APIKeyRestrictions) (*model.APIKey, error) {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
 	if err != nil {
-		return fmt.Errorf("failed to delete API key: %w", err)
+		return nil, err
 	}
-	return nil
+
+	restrictions, err = normalizeRestrictions(restrictions)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *apiKey
+	apiKey.Restrictions = restrictions
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyUpdate,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKeyID), &before, apiKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新API密钥使用限制失败: %w", err)
+	}
+
+	return apiKey, nil
 }
 
-// RegenerateAPIKey 重新生成APIKey
-func (s *APIKeyService) RegenerateAPIKey(apiKeyID int) (*model.APIKey, error) {
-	// 获取现有APIKey
-	apiKey, err := s.store.APIKeys().GetByID(context.Background(), apiKeyID)
+// getOwnedAPIKey 获取属于指定用户的APIKey，不存在或不属于该用户时返回ErrAPIKeyNotFound
+func (s *APIKeyService) getOwnedAPIKey(ctx context.Context, userID, apiKeyID int) (*model.APIKey, error) {
+	apiKey, err := s.store.APIKeys().GetByID(ctx, apiKeyID)
 	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, ErrAPIKeyNotFound
+		}
 		return nil, fmt.Errorf("获取API密钥失败: %w", err)
 	}
+	if apiKey.UserID != userID {
+		return nil, ErrAPIKeyNotFound
+	}
+	return apiKey, nil
+}
 
-	// 生成新的APIKey
-	newKeyString, err := s.GenerateAPIKey(32)
+// GetOwner 获取APIKey所属的用户，用户不存在或已禁用时返回错误
+func (s *APIKeyService) GetOwner(ctx context.Context, apiKey *model.APIKey) (*model.User, error) {
+	owner, err := s.store.Users().GetByID(ctx, apiKey.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("生成新API密钥失败: %w", err)
+		return nil, fmt.Errorf("获取API密钥所属用户失败: %w", err)
+	}
+	if !owner.IsActive() {
+		return nil, errors.New("API密钥所属用户已禁用")
+	}
+	return owner, nil
+}
+
+// TouchLastUsed 记录APIKey的最后使用时间和IP
+// 调用服务的请求由访问日志写入器批量记录，这里用于不写访问日志的请求，如使用APIKey访问Dashboard只读接口
+func (s *APIKeyService) TouchLastUsed(ctx context.Context, apiKey *model.APIKey, clientIP string, usedAt time.Time) error {
+	if err := s.store.APIKeys().TouchLastUsed(ctx, apiKey.ID, usedAt, clientIP); err != nil {
+		return fmt.Errorf("更新API密钥最后使用时间失败: %w", err)
 	}
+	return nil
+}
 
-	// 加密新的APIKey
-	encryptedKey, err := s.cryptoService.Encrypt(newKeyString)
-	if err != nil {
-		return nil, fmt.Errorf("加密新API密钥失败: %w", err)
+// normalizeScopes 校验并去重权限范围，为空时返回默认权限范围
+// 服务权限范围要求用户可以使用服务且服务存在，dashboard:read要求用户可以查看访问日志
+func (s *APIKeyService) normalizeScopes(ctx context.Context, owner *model.User, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		scopes = model.DefaultAPIKeyScopes
 	}
 
-	// 更新APIKey
-	apiKey.APIKey = encryptedKey
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		switch {
+		case scope == model.APIKeyScopeDashboardRead:
+			if !s.permissionService.HasAllPermissions(owner.Role, []string{permission.PermReadAccessLog, permission.PermListAccessLogs}) {
+				return nil, fmt.Errorf("%w: %s", ErrScopeNotPermitted, scope)
+			}
+
+		case strings.HasPrefix(scope, model.APIKeyScopeServicePrefix):
+			serviceName := strings.TrimPrefix(scope, model.APIKeyScopeServicePrefix)
+			if serviceName == "" {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+			}
+			if !s.permissionService.HasPermission(owner.Role, permission.PermUseService) {
+				return nil, fmt.Errorf("%w: %s", ErrScopeNotPermitted, scope)
+			}
+			if scope != model.APIKeyScopeServiceAll {
+				if _, err := s.store.Services().GetByName(ctx, serviceName); err != nil {
+					return nil, fmt.Errorf("%w: 服务不存在: %s", ErrInvalidScope, serviceName)
+				}
+			}
+
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
 
-	err = s.store.APIKeys().Update(context.Background(), apiKey)
+		normalized = append(normalized, scope)
+	}
+
+	return normalized, nil
+}
+
+// RevokeAPIKey 撤销APIKey
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, apiKeyID int) error {
+	return s.UpdateAPIKey(ctx, apiKeyID, "", model.APIKeyStatusDisabled, nil)
+}
+
+// DeleteAPIKey 删除APIKey
+func (s *APIKeyService) DeleteAPIKey(ctx context.Context, apiKeyID int) error {
+	apiKey, err := s.store.APIKeys().GetByID(ctx, apiKeyID)
 	if err != nil {
-		return nil, fmt.Errorf("更新API密钥失败: %w", err)
+		return fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	// 返回时包含明文APIKey
-	apiKey.APIKey = newKeyString
-	return apiKey, nil
+	return store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Delete(ctx, apiKeyID); err != nil {
+			return fmt.Errorf("failed to delete API key: %w", err)
+		}
+
+		// 同时清理该密钥上的IP访问规则
+		if err := tx.IPRules().DeleteByTarget(ctx, model.IPRuleScopeAPIKey, strconv.Itoa(apiKeyID)); err != nil {
+			return fmt.Errorf("failed to delete API key IP rules: %w", err)
+		}
+
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyDelete,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKeyID), apiKey, nil)
+	})
 }
 
-// CheckAPIKeyScope 检查APIKey是否具有指定的权限范围
-// 注意：当前APIKey模型不包含Scopes字段，默认允许所有操作
+// CheckAPIKeyScope 检查APIKey是否有效且具有指定的权限范围
 func (s *APIKeyService) CheckAPIKeyScope(apiKey *model.APIKey, requiredScope string) bool {
 	if apiKey == nil {
 		return false
 	}
-
-	// 当前实现：如果APIKey有效，则允许所有操作
-	// 未来可以扩展APIKey模型添加权限范围字段
-	return apiKey.IsActive()
+	return apiKey.IsActive() && apiKey.Allows(requiredScope)
 }
@@ -1,9 +1,16 @@
 package apikey
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"apihub/internal/metrics"
 	"apihub/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +24,10 @@ const (
 	APIKeyKey ContextKey = "api_key"
 	// APIKeyUserIDKey APIKey用户ID在上下文中的键
 	APIKeyUserIDKey ContextKey = "api_key_user_id"
+	// SignedRequestKey 请求通过签名认证时在上下文中的键
+	SignedRequestKey ContextKey = "api_key_signed"
+	// RestrictionErrorKey 请求被API密钥使用限制拒绝时，拒绝原因在上下文中的键
+	RestrictionErrorKey ContextKey = "api_key_restriction_error"
 )
 
 // APIKeyAuthMiddleware APIKey认证中间件
@@ -25,19 +36,26 @@ func APIKeyAuthMiddleware(apiKeyService *APIKeyService) gin.HandlerFunc {
 		// 从请求头获取APIKey
 		apiKey := getAPIKeyFromRequest(c)
 		if apiKey == "" {
+			metrics.AuthFailed(metrics.AuthMethodNone)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "缺少API密钥"))
 			c.Abort()
 			return
 		}
 
 		// 验证APIKey
-		apiKeyModel, err := apiKeyService.ValidateAPIKey(apiKey)
+		apiKeyModel, err := apiKeyService.ValidateAPIKey(c.Request.Context(), apiKey)
 		if err != nil {
+			metrics.AuthFailed(metrics.AuthMethodAPIKey)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "API密钥无效: "+err.Error()))
 			c.Abort()
 			return
 		}
 
+		// 检查使用限制，之后没有其他检查，直接消耗使用次数
+		if !CheckRequestRestrictions(c, apiKeyService, apiKeyModel) || !ConsumeRequestUse(c, apiKeyService, apiKeyModel) {
+			return
+		}
+
 		// 将APIKey信息存入上下文
 		c.Set(string(APIKeyKey), apiKeyModel)
 		c.Set(string(APIKeyUserIDKey), apiKeyModel.UserID)
@@ -46,6 +64,102 @@ func APIKeyAuthMiddleware(apiKeyService *APIKeyService) gin.HandlerFunc {
 	}
 }
 
+// CheckRequestRestrictions 检查当前请求是否满足API密钥的使用限制，不消耗使用次数
+// 不满足时返回对应限制的错误码并中止请求，返回false
+func CheckRequestRestrictions(c *gin.Context, apiKeyService *APIKeyService, apiKeyModel *model.APIKey) bool {
+	err := apiKeyService.CheckRestrictions(apiKeyModel, c.GetHeader("Origin"), c.GetHeader("Referer"), time.Now())
+	if err == nil {
+		return true
+	}
+
+	abortRestricted(c, apiKeyModel, err)
+	return false
+}
+
+// ConsumeRequestUse 消耗一次API密钥的使用次数，次数已用完时返回1018并中止请求，返回false
+func ConsumeRequestUse(c *gin.Context, apiKeyService *APIKeyService, apiKeyModel *model.APIKey) bool {
+	if err := apiKeyService.ConsumeUse(c.Request.Context(), apiKeyModel); err != nil {
+		abortRestricted(c, apiKeyModel, err)
+		return false
+	}
+	return true
+}
+
+// ConsumeUseMiddleware 消耗API密钥使用次数的中间件
+// 认证中间件只检查剩余次数，本中间件放在权限范围、IP规则和限流检查之后，被这些检查拒绝的请求不计入最大使用次数
+func ConsumeUseMiddleware(apiKeyService *APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKeyModel, ok := GetAPIKey(c); ok && !ConsumeRequestUse(c, apiKeyService, apiKeyModel) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortRestricted 以使用限制对应的错误码中止请求
+// 拒绝原因和API密钥同时存入上下文，认证阶段的拒绝也能按该密钥记录访问日志
+func abortRestricted(c *gin.Context, apiKeyModel *model.APIKey, err error) {
+	var restrictionErr *RestrictionError
+	if errors.As(err, &restrictionErr) {
+		slog.InfoContext(c.Request.Context(), "API密钥使用限制拒绝请求",
+			"api_key_id", apiKeyModel.ID, "client_ip", c.ClientIP(), "reason", restrictionErr.Message)
+		c.Set(string(RestrictionErrorKey), restrictionErr)
+		c.Set(string(APIKeyKey), apiKeyModel)
+		c.Set(string(APIKeyUserIDKey), apiKeyModel.UserID)
+		if IsSignedRequest(c) {
+			c.Set(string(SignedRequestKey), true)
+		}
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(restrictionErr.Code, restrictionErr.Message))
+	} else {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(model.CodeInternalError, "检查API密钥使用限制失败: "+err.Error()))
+	}
+	c.Abort()
+}
+
+// GetRestrictionError 获取请求被API密钥使用限制拒绝的原因
+func GetRestrictionError(c *gin.Context) (*RestrictionError, bool) {
+	value, exists := c.Get(string(RestrictionErrorKey))
+	if !exists {
+		return nil, false
+	}
+
+	restrictionErr, ok := value.(*RestrictionError)
+	return restrictionErr, ok
+}
+
+// IsSignedRequest 检查请求是否携带签名认证信息
+func IsSignedRequest(c *gin.Context) bool {
+	return c.GetHeader(HeaderAccessKey) != ""
+}
+
+// AuthenticateSignedRequest 验证请求签名，成功时返回访问密钥对应的API密钥
+// 读取请求体计算哈希后重新设置请求体，后续处理器可以照常读取
+func AuthenticateSignedRequest(c *gin.Context, apiKeyService *APIKeyService) (*model.APIKey, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxSignedBodyBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %w", err)
+		}
+		if len(data) > MaxSignedBodyBytes {
+			return nil, ErrSignedBodyTooLarge
+		}
+		body = data
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return apiKeyService.VerifySignature(c.Request.Context(), &SignedRequest{
+		AccessKey: c.GetHeader(HeaderAccessKey),
+		Timestamp: c.GetHeader(HeaderTimestamp),
+		Nonce:     c.GetHeader(HeaderNonce),
+		Signature: c.GetHeader(HeaderSignature),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.EscapedPath(),
+		RawQuery:  c.Request.URL.RawQuery,
+		BodyHash:  HashBody(body),
+	}, time.Now())
+}
+
 // OptionalAPIKeyAuthMiddleware 可选APIKey认证中间件
 func OptionalAPIKeyAuthMiddleware(apiKeyService *APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -58,13 +172,19 @@ func OptionalAPIKeyAuthMiddleware(apiKeyService *APIKeyService) gin.HandlerFunc
 		}
 
 		// 验证APIKey
-		apiKeyModel, err := apiKeyService.ValidateAPIKey(apiKey)
+		apiKeyModel, err := apiKeyService.ValidateAPIKey(c.Request.Context(), apiKey)
 		if err != nil {
 			// APIKey无效，继续执行
+			metrics.AuthFailed(metrics.AuthMethodAPIKey)
 			c.Next()
 			return
 		}
 
+		// 有效的APIKey不满足使用限制时拒绝请求，不降级为匿名访问
+		if !CheckRequestRestrictions(c, apiKeyService, apiKeyModel) || !ConsumeRequestUse(c, apiKeyService, apiKeyModel) {
+			return
+		}
+
 		// 将APIKey信息存入上下文
 		c.Set(string(APIKeyKey), apiKeyModel)
 		c.Set(string(APIKeyUserIDKey), apiKeyModel.UserID)
@@ -84,13 +204,19 @@ func RequireScopeMiddleware(requiredScope string) gin.HandlerFunc {
 			return
 		}
 
-		// 检查权限范围 - 当前APIKey模型不包含Scopes字段，默认允许所有操作
 		if !apiKeyModel.IsActive() {
 			c.JSON(http.StatusForbidden, model.NewErrorResponse(model.CodeForbidden, "API密钥未激活"))
 			c.Abort()
 			return
 		}
 
+		// 检查权限范围
+		if !apiKeyModel.Allows(requiredScope) {
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(model.CodeForbidden, "API密钥缺少权限范围: "+requiredScope))
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -132,6 +258,12 @@ func GetAPIKey(c *gin.Context) (*model.APIKey, bool) {
 	return apiKeyModel, ok
 }
 
+// IsSignedAuth 检查当前请求是否通过签名认证
+func IsSignedAuth(c *gin.Context) bool {
+	_, exists := c.Get(string(SignedRequestKey))
+	return exists
+}
+
 // GetAPIKeyUserID 从上下文获取APIKey用户ID
 func GetAPIKeyUserID(c *gin.Context) (int, bool) {
 	userID, exists := c.Get(string(APIKeyUserIDKey))
@@ -142,7 +274,3 @@ func GetAPIKeyUserID(c *gin.Context) (int, bool) {
 	id, ok := userID.(int)
 	return id, ok
 }
-
-// 注意：GetAPIKeyScopes和hasScope函数已移除
-// 因为当前APIKey模型不包含Scopes字段
-// 如果需要权限控制，可以在未来扩展APIKey模型时重新添加
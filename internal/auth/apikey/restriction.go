@@ -0,0 +1,268 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apihub/internal/model"
+)
+
+// 使用限制的条目数上限
+const maxRestrictionEntries = 100
+
+// ErrInvalidRestrictions 使用限制格式错误
+var ErrInvalidRestrictions = errors.New("无效的使用限制")
+
+// RestrictionError API密钥使用限制检查未通过，Code对应model.CodeAPIKey*响应码
+type RestrictionError struct {
+	Code    int
+	Message string
+}
+
+func (e *RestrictionError) Error() string {
+	return e.Message
+}
+
+// locations 已加载的时区缓存
+var locations sync.Map
+
+// CheckRestrictions 依次检查API密钥的请求来源、使用时间段限制和剩余使用次数
+// 只检查不消耗使用次数，请求通过后续全部检查后再调用ConsumeUse；限制未通过时返回*RestrictionError
+// 允许的网段由IP访问规则在IP过滤中间件中检查，不属于使用限制
+func (s *APIKeyService) CheckRestrictions(apiKey *model.APIKey, origin, referer string, now time.Time) error {
+	restrictions := &apiKey.Restrictions
+
+	if len(restrictions.AllowedReferrers) > 0 && !matchReferrers(restrictions.AllowedReferrers, origin, referer) {
+		return &RestrictionError{Code: model.CodeAPIKeyReferrerNotAllowed, Message: model.MsgAPIKeyReferrerNotAllowed}
+	}
+
+	if len(restrictions.TimeWindows) > 0 && !matchTimeWindows(restrictions.TimeWindows, now.In(loadLocation(restrictions.Timezone))) {
+		return &RestrictionError{Code: model.CodeAPIKeyOutsideTimeWindow, Message: model.MsgAPIKeyOutsideTimeWindow}
+	}
+
+	if restrictions.MaxUses > 0 && apiKey.UseCount >= restrictions.MaxUses {
+		return &RestrictionError{Code: model.CodeAPIKeyUsageExhausted, Message: model.MsgAPIKeyUsageExhausted}
+	}
+
+	return nil
+}
+
+// ConsumeUse 消耗一次API密钥的使用次数，未设置最大使用次数时不做任何操作
+// 在数据库中原子地检查并累加，并发请求用完次数时返回*RestrictionError
+func (s *APIKeyService) ConsumeUse(ctx context.Context, apiKey *model.APIKey) error {
+	maxUses := apiKey.Restrictions.MaxUses
+	if maxUses <= 0 {
+		return nil
+	}
+
+	ok, err := s.store.APIKeys().ConsumeUse(ctx, apiKey.ID, maxUses)
+	if err != nil {
+		return fmt.Errorf("更新API密钥使用次数失败: %w", err)
+	}
+	if !ok {
+		return &RestrictionError{Code: model.CodeAPIKeyUsageExhausted, Message: model.MsgAPIKeyUsageExhausted}
+	}
+	apiKey.UseCount++
+	return nil
+}
+
+// matchReferrers 检查请求来源是否匹配任意一个模式，优先使用Origin头，其次使用Referer头
+// 不含://的模式只匹配主机名，否则匹配完整URL；Origin按 scheme://host/ 匹配
+func matchReferrers(patterns []string, origin, referer string) bool {
+	candidates := make([]string, 0, 2)
+	if origin != "" && origin != "null" {
+		candidates = append(candidates, strings.TrimSuffix(origin, "/")+"/")
+	}
+	if referer != "" {
+		candidates = append(candidates, referer)
+	}
+
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(candidate)
+		u, err := url.Parse(candidate)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		for _, pattern := range patterns {
+			target := candidate
+			if !strings.Contains(pattern, "://") {
+				target = u.Hostname()
+			}
+			if matchWildcard(pattern, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchWildcard 匹配只包含*通配符的模式，*匹配任意长度的字符
+func matchWildcard(pattern, s string) bool {
+	p, i := 0, 0
+	star, match := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, match = p, i
+			p++
+		case p < len(pattern) && pattern[p] == s[i]:
+			p++
+			i++
+		case star >= 0:
+			p = star + 1
+			match++
+			i = match
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchTimeWindows 检查时间是否在任意一个时间段内，时间段已校验过格式
+func matchTimeWindows(windows []model.APIKeyTimeWindow, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	weekday := int(t.Weekday())
+	yesterday := (weekday + 6) % 7
+
+	for _, window := range windows {
+		start, _ := parseClock(window.Start)
+		end, _ := parseClock(window.End)
+
+		if start < end {
+			if minute >= start && minute < end && dayAllowed(window.Days, weekday) {
+				return true
+			}
+			continue
+		}
+
+		// 跨越午夜的时间段，午夜之后的部分属于前一天的时间段
+		if minute >= start && dayAllowed(window.Days, weekday) {
+			return true
+		}
+		if minute < end && dayAllowed(window.Days, yesterday) {
+			return true
+		}
+	}
+	return false
+}
+
+// dayAllowed 检查星期几是否在允许的列表中，列表为空表示每天
+func dayAllowed(days []int, weekday int) bool {
+	return len(days) == 0 || slices.Contains(days, weekday)
+}
+
+// parseClock 解析HH:MM格式的时间，返回从零点开始的分钟数，允许24:00
+func parseClock(value string) (int, error) {
+	hourStr, minuteStr, ok := strings.Cut(value, ":")
+	if !ok || len(hourStr) != 2 || len(minuteStr) != 2 {
+		return 0, fmt.Errorf("时间格式应为HH:MM: %s", value)
+	}
+	hour, err1 := strconv.Atoi(hourStr)
+	minute, err2 := strconv.Atoi(minuteStr)
+	if err1 != nil || err2 != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// loadLocation 加载时区，为空或无法加载时使用服务器本地时区
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// normalizeRestrictions 校验使用限制并统一格式，来源模式转为小写
+func normalizeRestrictions(restrictions model.APIKeyRestrictions) (model.APIKeyRestrictions, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRestrictions, fmt.Sprintf(format, args...))
+	}
+
+	if len(restrictions.AllowedCIDRs) > 0 {
+		return restrictions, invalid("允许的网段请通过API密钥的IP访问规则设置")
+	}
+
+	if len(restrictions.AllowedReferrers) > maxRestrictionEntries ||
+		len(restrictions.TimeWindows) > maxRestrictionEntries {
+		return restrictions, invalid("每项限制最多%d条", maxRestrictionEntries)
+	}
+
+	referrers := make([]string, 0, len(restrictions.AllowedReferrers))
+	for _, pattern := range restrictions.AllowedReferrers {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" || pattern == "*" {
+			return restrictions, invalid("来源模式不能为空或只包含*")
+		}
+		if !slices.Contains(referrers, pattern) {
+			referrers = append(referrers, pattern)
+		}
+	}
+
+	windows := make([]model.APIKeyTimeWindow, 0, len(restrictions.TimeWindows))
+	for _, window := range restrictions.TimeWindows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return restrictions, invalid("%s", err.Error())
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return restrictions, invalid("%s", err.Error())
+		}
+		if start == end || start == 24*60 {
+			return restrictions, invalid("无效的时间段: %s-%s", window.Start, window.End)
+		}
+
+		days := make([]int, 0, len(window.Days))
+		for _, day := range window.Days {
+			if day < 0 || day > 6 {
+				return restrictions, invalid("星期应为0-6: %d", day)
+			}
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+		slices.Sort(days)
+
+		windows = append(windows, model.APIKeyTimeWindow{Days: days, Start: window.Start, End: window.End})
+	}
+
+	timezone := strings.TrimSpace(restrictions.Timezone)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return restrictions, invalid("无效的时区: %s", timezone)
+		}
+	}
+
+	if restrictions.MaxUses < 0 {
+		return restrictions, invalid("最大使用次数不能为负数")
+	}
+
+	return model.APIKeyRestrictions{
+		AllowedReferrers: referrers,
+		TimeWindows:      windows,
+		Timezone:         timezone,
+		MaxUses:          restrictions.MaxUses,
+	}, nil
+}
@@ -0,0 +1,266 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 轮换状态中每个密钥最多返回的客户端IP数
+const maxRotationClientIPs = 20
+
+// APIKey轮换相关错误
+var (
+	ErrAPIKeyInactive     = errors.New("API密钥已禁用或已过期")
+	ErrAPIKeyRotated      = errors.New("API密钥已轮换，请轮换其继任密钥")
+	ErrAPIKeyNotRotated   = errors.New("API密钥未参与轮换")
+	ErrGracePeriodTooLong = errors.New("宽限期超过允许的最大值")
+)
+
+// RotationConfig APIKey轮换配置
+type RotationConfig struct {
+	DefaultGrace time.Duration // 未指定宽限期时旧密钥继续有效的时长
+	MaxGrace     time.Duration // 允许指定的最长宽限期
+	NotifyBefore time.Duration // 宽限期结束前多久通知密钥所有者和管理员
+}
+
+// RotateAPIKey 轮换用户自己的APIKey
+// 创建继承名称、权限范围、使用限制、过期时间和IP访问规则的继任密钥，旧密钥在宽限期内继续有效，之后自动过期；
+// grace为空时使用默认宽限期，为0时旧密钥立即失效。返回包含明文的继任密钥和更新后的旧密钥
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, userID, apiKeyID int, grace *time.Duration) (*model.APIKey, *model.APIKey, error) {
+	oldKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !oldKey.IsActive() {
+		return nil, nil, ErrAPIKeyInactive
+	}
+	if oldKey.SuccessorID != 0 {
+		return nil, nil, ErrAPIKeyRotated
+	}
+
+	gracePeriod := s.rotation.DefaultGrace
+	if grace != nil {
+		gracePeriod = *grace
+	}
+	if gracePeriod > s.rotation.MaxGrace {
+		return nil, nil, fmt.Errorf("%w: %s", ErrGracePeriodTooLong, s.rotation.MaxGrace)
+	}
+
+	keyString, prefix, err := s.GenerateAPIKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成API密钥失败: %w", err)
+	}
+
+	newKey := &model.APIKey{
+		UserID:        oldKey.UserID,
+		KeyName:       oldKey.KeyName,
+		KeyPrefix:     prefix,
+		KeyHash:       s.hashKey(keyString),
+		Scopes:        oldKey.Scopes,
+		Restrictions:  oldKey.Restrictions,
+		Status:        model.APIKeyStatusActive,
+		ExpiresAt:     oldKey.ExpiresAt,
+		PredecessorID: oldKey.ID,
+	}
+
+	// 宽限期不会延长旧密钥原有的过期时间；以本地时区保存，与宽限期检查时的比较保持一致
+	now := time.Now()
+	graceEndsAt := now.Add(gracePeriod)
+	if oldKey.ExpiresAt != nil && oldKey.ExpiresAt.Before(graceEndsAt) {
+		graceEndsAt = *oldKey.ExpiresAt
+	}
+	graceEndsAt = graceEndsAt.In(time.Local)
+
+	before := *oldKey
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Create(ctx, newKey); err != nil {
+			return err
+		}
+
+		// 复制旧密钥上的IP访问规则，IP过滤器定期重新加载规则后对继任密钥生效
+		rules, err := tx.IPRules().List(ctx, model.IPRuleScopeAPIKey, strconv.Itoa(oldKey.ID))
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			copied := *rule
+			copied.Target = strconv.Itoa(newKey.ID)
+			if err := tx.IPRules().Create(ctx, &copied); err != nil {
+				return err
+			}
+		}
+
+		// 以条件更新标记旧密钥，并发轮换时后提交的请求在此失败并回滚已创建的继任密钥
+		marked, err := tx.APIKeys().MarkRotated(ctx, oldKey.ID, newKey.ID, now, graceEndsAt)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return ErrAPIKeyRotated
+		}
+		oldKey.SuccessorID = newKey.ID
+		oldKey.RotatedAt = &now
+		oldKey.ExpiresAt = &graceEndsAt
+
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyRotate,
+			model.AuditTargetAPIKey, strconv.Itoa(oldKey.ID), &before, map[string]any{
+				"old_key": oldKey,
+				"new_key": newKey,
+			})
+	})
+	if errors.Is(err, ErrAPIKeyRotated) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("轮换API密钥失败: %w", err)
+	}
+
+	// 返回时包含明文APIKey（仅此一次）
+	newKey.APIKey = keyString
+	return newKey, oldKey, nil
+}
+
+// GetRotationStatus 获取用户自己的APIKey的轮换状态，apiKeyID可以是旧密钥或继任密钥
+// 密钥同时是前一次轮换的继任密钥和后一次轮换的旧密钥时，返回后一次轮换的状态
+func (s *APIKeyService) GetRotationStatus(ctx context.Context, userID, apiKeyID int) (*model.APIKeyRotationStatus, error) {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldKey, newKey *model.APIKey
+	switch {
+	case apiKey.SuccessorID != 0:
+		oldKey = apiKey
+		newKey, err = s.getOwnedAPIKey(ctx, userID, apiKey.SuccessorID)
+	case apiKey.PredecessorID != 0:
+		newKey = apiKey
+		oldKey, err = s.getOwnedAPIKey(ctx, userID, apiKey.PredecessorID)
+	default:
+		return nil, ErrAPIKeyNotRotated
+	}
+	if err != nil {
+		// 另一个密钥已被删除时不再有轮换状态
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrAPIKeyNotRotated
+		}
+		return nil, err
+	}
+	if oldKey.RotatedAt == nil {
+		return nil, ErrAPIKeyNotRotated
+	}
+
+	status := &model.APIKeyRotationStatus{
+		OldKey:        oldKey.ToResponse(),
+		NewKey:        newKey.ToResponse(),
+		RotatedAt:     *oldKey.RotatedAt,
+		GraceEndsAt:   oldKey.ExpiresAt,
+		InGracePeriod: oldKey.IsActive(),
+		Usage:         make([]*model.APIKeyCallStats, 0, 2),
+	}
+
+	for _, key := range []*model.APIKey{oldKey, newKey} {
+		stats, err := s.store.AccessLogs().CallStatsByAPIKey(ctx, key.ID, *oldKey.RotatedAt, maxRotationClientIPs)
+		if err != nil {
+			return nil, fmt.Errorf("统计API密钥调用情况失败: %w", err)
+		}
+		status.Usage = append(status.Usage, stats)
+	}
+
+	return status, nil
+}
+
+// NotifyRotationsEnding 通知宽限期即将结束的旧密钥的所有者和所有管理员，每个密钥只通知一次
+// 返回发送通知的密钥数
+func (s *APIKeyService) NotifyRotationsEnding(ctx context.Context, now time.Time) (int, error) {
+	apiKeys, err := s.store.APIKeys().ListRotationsEnding(ctx, now, now.Add(s.rotation.NotifyBefore))
+	if err != nil {
+		return 0, fmt.Errorf("获取即将结束宽限期的API密钥失败: %w", err)
+	}
+	if len(apiKeys) == 0 {
+		return 0, nil
+	}
+
+	admins, err := s.store.Users().ListByRole(ctx, model.RoleAdmin)
+	if err != nil {
+		return 0, fmt.Errorf("获取管理员失败: %w", err)
+	}
+
+	notified := 0
+	for _, apiKey := range apiKeys {
+		recipients := make([]int, 0, len(admins)+1)
+		if owner, err := s.store.Users().GetByID(ctx, apiKey.UserID); err == nil && owner.IsActive() {
+			recipients = append(recipients, owner.ID)
+		}
+		for _, admin := range admins {
+			if admin.IsActive() && admin.ID != apiKey.UserID {
+				recipients = append(recipients, admin.ID)
+			}
+		}
+
+		message := fmt.Sprintf("API密钥「%s」（%s）已轮换，旧密钥将于 %s 失效，请确认所有调用方已切换到新密钥",
+			apiKey.KeyName, apiKey.KeyPrefix, apiKey.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+		if successor, err := s.store.APIKeys().GetByID(ctx, apiKey.SuccessorID); err == nil {
+			message += "（" + successor.KeyPrefix + "）"
+		}
+
+		sent := false
+		err := store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+			// 先标记再发送，多个实例同时检查时只有标记成功的实例发送通知
+			ok, err := tx.APIKeys().MarkRotationNotified(ctx, apiKey.ID, now)
+			if err != nil || !ok {
+				return err
+			}
+			for _, userID := range recipients {
+				err := tx.Notifications().Create(ctx, &model.Notification{
+					UserID:     userID,
+					Type:       model.NotificationAPIKeyRotationEnding,
+					Title:      "API密钥轮换宽限期即将结束",
+					Message:    message,
+					TargetType: model.AuditTargetAPIKey,
+					TargetID:   strconv.Itoa(apiKey.ID),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			sent = true
+			return nil
+		})
+		if err != nil {
+			return notified, fmt.Errorf("发送API密钥轮换通知失败: %w", err)
+		}
+		if sent {
+			notified++
+		}
+	}
+
+	return notified, nil
+}
+
+// StartRotationNotifyTask 启动定期检查即将结束的轮换宽限期并发送通知的任务
+func (s *APIKeyService) StartRotationNotifyTask(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			notified, err := s.NotifyRotationsEnding(ctx, time.Now())
+			if err != nil {
+				slog.Error("发送API密钥轮换通知失败", "error", err)
+			} else if notified > 0 {
+				slog.Info("已发送API密钥轮换宽限期即将结束的通知", "count", notified)
+			}
+			cancel()
+		}
+	}()
+}
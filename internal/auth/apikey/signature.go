@@ -0,0 +1,263 @@
+package apikey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 请求签名认证
+// 访问密钥（Access Key）即API密钥的前缀 ahk_<密钥ID>，签名密钥（Secret Key）单独生成并加密保存；
+// 客户端用签名密钥对请求方法、路径、查询参数、请求体哈希、时间戳和随机数计算HMAC-SHA256，请求中不出现任何密钥原文
+const (
+	SignatureAlgorithm = "AHK-HMAC-SHA256"
+
+	HeaderAccessKey = "X-Apihub-Access-Key" // 访问密钥
+	HeaderTimestamp = "X-Apihub-Timestamp"  // Unix时间戳（秒）
+	HeaderNonce     = "X-Apihub-Nonce"      // 随机数，同一访问密钥在时间戳有效期内不能重复
+	HeaderSignature = "X-Apihub-Signature"  // 十六进制编码的签名
+
+	// SigningSecretPrefix 签名密钥的固定前缀，完整格式为 ahs_<随机部分>
+	SigningSecretPrefix = "ahs"
+	// MaxSignedBodyBytes 签名请求允许的最大请求体字节数，计算请求体哈希时需要完整读取请求体
+	MaxSignedBodyBytes = 10 << 20
+)
+
+const (
+	signingSecretBytes = 32
+	minNonceLength     = 16
+	maxNonceLength     = 64
+)
+
+// 请求签名相关错误
+var (
+	ErrInvalidSignature   = errors.New("签名无效")
+	ErrSignatureExpired   = errors.New("请求时间戳超出允许范围")
+	ErrInvalidNonce       = errors.New("随机数格式不正确，需为16到64位字母、数字、下划线或连字符")
+	ErrNonceReused        = errors.New("随机数已使用，请求可能被重放")
+	ErrSignedBodyTooLarge = errors.New("签名请求的请求体过大")
+	ErrLegacyKeyNoSigning = errors.New("旧格式的API密钥不支持签名认证，请先轮换密钥")
+)
+
+// SignedRequest 待验证的签名请求
+type SignedRequest struct {
+	AccessKey string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string // 转义后的请求路径，即URL.EscapedPath()
+	RawQuery  string
+	BodyHash  string // 请求体SHA-256的十六进制编码，见HashBody
+}
+
+// StringToSign 构造待签名字符串
+// 依次为算法、时间戳、随机数、大写的请求方法、转义后的路径、规范化的查询参数和请求体哈希，以换行符连接
+func StringToSign(method, path, rawQuery, bodyHash, timestamp, nonce string) string {
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		SignatureAlgorithm,
+		timestamp,
+		nonce,
+		strings.ToUpper(method),
+		path,
+		CanonicalQuery(rawQuery),
+		bodyHash,
+	}, "\n")
+}
+
+// CanonicalQuery 规范化查询参数
+// 参数按名称排序，同名参数按值排序，名称和值按RFC 3986编码（空格编码为%20）后以&连接
+func CanonicalQuery(rawQuery string) string {
+	// 解析出错的参数会被跳过，客户端和服务端使用相同的规则，不影响签名比对
+	values, _ := url.ParseQuery(rawQuery)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			pairs = append(pairs, escapeRFC3986(key)+"="+escapeRFC3986(val))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func escapeRFC3986(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// HashBody 计算请求体的SHA-256，返回十六进制编码，空请求体同样需要计算
+func HashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeSignature 使用签名密钥计算待签名字符串的HMAC-SHA256，返回十六进制编码
+func ComputeSignature(secretKey, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetSigningSecret 为用户自己的API密钥生成签名密钥，已有的签名密钥立即失效
+// 签名密钥只在返回值中出现一次
+func (s *APIKeyService) SetSigningSecret(ctx context.Context, userID, apiKeyID int) (*model.APIKeySigningSecretResponse, error) {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if !apiKey.IsActive() {
+		return nil, ErrAPIKeyInactive
+	}
+	if !strings.HasPrefix(apiKey.KeyPrefix, KeyPrefix+"_") {
+		return nil, ErrLegacyKeyNoSigning
+	}
+
+	random, err := randomHex(signingSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	secretKey := SigningSecretPrefix + "_" + random
+
+	encrypted, err := s.cryptoService.Encrypt(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("加密签名密钥失败: %w", err)
+	}
+	apiKey.SigningSecret = encrypted
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeySigningSecretSet,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKey.ID), nil, map[string]any{"access_key": apiKey.KeyPrefix})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+
+	return &model.APIKeySigningSecretResponse{
+		APIKeyID:  apiKey.ID,
+		AccessKey: apiKey.KeyPrefix,
+		SecretKey: secretKey,
+	}, nil
+}
+
+// DeleteSigningSecret 删除用户自己的API密钥的签名密钥，之后该密钥不能再用于签名认证
+func (s *APIKeyService) DeleteSigningSecret(ctx context.Context, userID, apiKeyID int) error {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return err
+	}
+	if apiKey.SigningSecret == "" {
+		return nil
+	}
+	apiKey.SigningSecret = ""
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.APIKeys().Update(ctx, apiKey); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeySigningSecretDelete,
+			model.AuditTargetAPIKey, strconv.Itoa(apiKey.ID), map[string]any{"access_key": apiKey.KeyPrefix}, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("删除签名密钥失败: %w", err)
+	}
+
+	return nil
+}
+
+// VerifySignature 验证签名请求，成功时返回访问密钥对应的API密钥
+// 先检查时间戳和签名，签名正确后才记录随机数，避免伪造的请求占用随机数
+func (s *APIKeyService) VerifySignature(ctx context.Context, req *SignedRequest, now time.Time) (*model.APIKey, error) {
+	if req.AccessKey == "" || req.Timestamp == "" || req.Nonce == "" || req.Signature == "" {
+		return nil, errors.New("缺少签名认证请求头")
+	}
+
+	timestamp, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("请求时间戳格式不正确")
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > s.maxClockSkew || skew < -s.maxClockSkew {
+		return nil, ErrSignatureExpired
+	}
+	if !validNonce(req.Nonce) {
+		return nil, ErrInvalidNonce
+	}
+
+	candidates, err := s.store.APIKeys().GetByPrefix(ctx, req.AccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("签名验证失败: %w", err)
+	}
+	var apiKey *model.APIKey
+	for _, candidate := range candidates {
+		if candidate.SigningSecret != "" {
+			apiKey = candidate
+			break
+		}
+	}
+	if apiKey == nil {
+		return nil, errors.New("访问密钥不存在或未启用签名认证")
+	}
+
+	secretKey, err := s.cryptoService.Decrypt(apiKey.SigningSecret)
+	if err != nil {
+		return nil, fmt.Errorf("解密签名密钥失败: %w", err)
+	}
+	expected := ComputeSignature(secretKey, StringToSign(req.Method, req.Path, req.RawQuery, req.BodyHash, req.Timestamp, req.Nonce))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(req.Signature))) {
+		return nil, ErrInvalidSignature
+	}
+
+	if err := checkAPIKeyStatus(apiKey, now); err != nil {
+		return nil, err
+	}
+
+	// 请求在时间戳加maxClockSkew之前都可能被重放，随机数保留到该时间；记录在数据库中，多个实例共享
+	used, err := s.store.SignatureNonces().Use(ctx, apiKey.KeyPrefix, req.Nonce, now, time.Unix(timestamp, 0).Add(s.maxClockSkew))
+	if err != nil {
+		return nil, fmt.Errorf("签名验证失败: %w", err)
+	}
+	if !used {
+		return nil, ErrNonceReused
+	}
+
+	return apiKey, nil
+}
+
+// validNonce 检查随机数长度和字符集
+func validNonce(nonce string) bool {
+	if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return false
+	}
+	for _, ch := range nonce {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9', ch == '-', ch == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package apikey
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signer 请求签名的参考实现，Go客户端可以直接使用，其他语言的客户端可以对照它检查待签名字符串的构造
+type Signer struct {
+	AccessKey string           // 访问密钥，即API密钥的前缀 ahk_<密钥ID>
+	SecretKey string           // 签名密钥 ahs_<随机部分>
+	Now       func() time.Time // 为空时使用time.Now
+}
+
+// NewSigner 创建请求签名器
+func NewSigner(accessKey, secretKey string) *Signer {
+	return &Signer{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}
+}
+
+// Sign 对请求签名并设置签名认证请求头，每次调用都生成新的随机数
+// 会完整读取请求体计算哈希，之后重新设置请求体，请求可以照常发送
+func (s *Signer) Sign(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("读取请求体失败: %w", err)
+		}
+		if err := req.Body.Close(); err != nil {
+			return fmt.Errorf("关闭请求体失败: %w", err)
+		}
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	nonce, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+
+	stringToSign := StringToSign(req.Method, req.URL.EscapedPath(), req.URL.RawQuery, HashBody(body), timestamp, nonce)
+
+	req.Header.Set(HeaderAccessKey, s.AccessKey)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, ComputeSignature(s.SecretKey, stringToSign))
+	return nil
+}
@@ -0,0 +1,189 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"apihub/internal/auth/crypto"
+	"apihub/internal/auth/permission"
+	"apihub/internal/model"
+	"apihub/internal/store"
+	"apihub/internal/store/sqlite"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testMaxClockSkew = 5 * time.Minute
+
+// newSigningTestStore 创建已执行迁移的临时SQLite存储
+func newSigningTestStore(t *testing.T) store.Store {
+	t.Helper()
+
+	s := sqlite.NewSQLiteStore(filepath.Join(t.TempDir(), "apihub.db"))
+	if err := s.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+// newSigningTestService 在存储上创建APIKey服务
+func newSigningTestService(s store.Store) *APIKeyService {
+	const secret = "signer-test-secret-key-32-chars!"
+	return NewAPIKeyService(s, secret, crypto.NewAESCryptoService(secret), permission.NewPermissionService(),
+		Config{MaxClockSkew: testMaxClockSkew})
+}
+
+// newTestSigner 创建用户和API密钥并为其生成签名密钥，返回对应的签名器
+func newTestSigner(t *testing.T, svc *APIKeyService) (*Signer, *model.APIKey) {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &model.User{Username: "signer", Password: "x", Email: "signer@example.com", Role: model.RoleUser, Status: model.UserStatusActive}
+	if err := svc.store.Users().Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	keyString, prefix, err := svc.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("generate api key: %v", err)
+	}
+	apiKey := &model.APIKey{
+		UserID:    user.ID,
+		KeyName:   "signer",
+		KeyPrefix: prefix,
+		KeyHash:   svc.hashKey(keyString),
+		Scopes:    model.DefaultAPIKeyScopes,
+		Status:    model.APIKeyStatusActive,
+	}
+	if err := svc.store.APIKeys().Create(ctx, apiKey); err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+
+	secret, err := svc.SetSigningSecret(ctx, user.ID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("set signing secret: %v", err)
+	}
+	return NewSigner(secret.AccessKey, secret.SecretKey), apiKey
+}
+
+// newSignedRequest 创建并签名请求
+func newSignedRequest(t *testing.T, signer *Signer, target, body string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return req
+}
+
+// verify 按服务端认证中间件的方式验证请求
+func verify(svc *APIKeyService, req *http.Request) (*model.APIKey, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return AuthenticateSignedRequest(c, svc)
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newSigningTestService(newSigningTestStore(t))
+	signer, apiKey := newTestSigner(t, svc)
+
+	req := newSignedRequest(t, signer, "/api/v1/provider/echo/execute?b=2&a=1", `{"message":"hi"}`)
+	got, err := verify(svc, req)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got.ID != apiKey.ID {
+		t.Errorf("api key id = %d, want %d", got.ID, apiKey.ID)
+	}
+
+	// 验证后请求体仍可被处理函数读取
+	body, err := io.ReadAll(req.Body)
+	if err != nil || string(body) != `{"message":"hi"}` {
+		t.Errorf("body after verify = %q, %v", body, err)
+	}
+}
+
+func TestVerifyRejectsTimestampOutsideSkew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newSigningTestService(newSigningTestStore(t))
+	signer, _ := newTestSigner(t, svc)
+
+	for _, offset := range []time.Duration{-testMaxClockSkew - time.Minute, testMaxClockSkew + time.Minute} {
+		signer.Now = func() time.Time { return time.Now().Add(offset) }
+		req := newSignedRequest(t, signer, "/api/v1/provider/echo/execute", `{}`)
+		if _, err := verify(svc, req); !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("offset %v: err = %v, want %v", offset, err, ErrSignatureExpired)
+		}
+	}
+}
+
+func TestVerifyRejectsReusedNonce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newSigningTestStore(t)
+	svc := newSigningTestService(s)
+	signer, _ := newTestSigner(t, svc)
+
+	req := newSignedRequest(t, signer, "/api/v1/provider/echo/execute", `{"message":"hi"}`)
+	if _, err := verify(svc, req); err != nil {
+		t.Fatalf("first verify: %v", err)
+	}
+
+	replay := req.Clone(context.Background())
+	replay.Body = io.NopCloser(strings.NewReader(`{"message":"hi"}`))
+	if _, err := verify(svc, replay); !errors.Is(err, ErrNonceReused) {
+		t.Fatalf("replay: err = %v, want %v", err, ErrNonceReused)
+	}
+
+	// 随机数保存在数据库中，共享同一数据库的其他实例同样拒绝重放
+	other := newSigningTestService(s)
+	replay = req.Clone(context.Background())
+	replay.Body = io.NopCloser(strings.NewReader(`{"message":"hi"}`))
+	if _, err := verify(other, replay); !errors.Is(err, ErrNonceReused) {
+		t.Fatalf("replay on other instance: err = %v, want %v", err, ErrNonceReused)
+	}
+}
+
+func TestVerifyRejectsTamperedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newSigningTestService(newSigningTestStore(t))
+	signer, _ := newTestSigner(t, svc)
+
+	req := newSignedRequest(t, signer, "/api/v1/provider/echo/execute", `{"message":"hi"}`)
+	req.Body = io.NopCloser(strings.NewReader(`{"message":"bye"}`))
+	if _, err := verify(svc, req); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestVerifyAcceptsReorderedQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newSigningTestService(newSigningTestStore(t))
+	signer, _ := newTestSigner(t, svc)
+
+	// 代理等中间环节可能调整查询参数顺序，规范化后签名不受影响
+	req := newSignedRequest(t, signer, "/api/v1/provider/echo/execute?b=2&a=1&a=0&q=hello%20world", `{}`)
+	req.URL.RawQuery = "q=hello+world&a=0&a=1&b=2"
+	if _, err := verify(svc, req); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// 参数值被修改时签名无效
+	req = newSignedRequest(t, signer, "/api/v1/provider/echo/execute?b=2&a=1", `{}`)
+	req.URL.RawQuery = "a=1&b=3"
+	if _, err := verify(svc, req); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("modified query: err = %v, want %v", err, ErrInvalidSignature)
+	}
+}
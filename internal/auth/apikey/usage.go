@@ -0,0 +1,143 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// GetAPIKeyUsage 获取用户自己的API密钥的使用情况
+// 最近24小时和7天的调用次数按小时汇总滚动计算（包含当前小时），最近30天按UTC自然日汇总（包含当天）
+func (s *APIKeyService) GetAPIKeyUsage(ctx context.Context, userID, apiKeyID int, now time.Time) (*model.APIKeyUsage, error) {
+	apiKey, err := s.getOwnedAPIKey(ctx, userID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &model.APIKeyUsage{
+		APIKeyID:   apiKey.ID,
+		LastUsedAt: apiKey.LastUsedAt,
+		LastUsedIP: apiKey.LastUsedIP,
+		Daily:      []model.UsageStatsPoint{},
+	}
+
+	hourly, err := s.store.UsageRollups().Query(ctx, model.UsageStatsFilter{
+		Granularity: model.GranularityHour,
+		APIKeyID:    apiKey.ID,
+		StartBucket: model.UsageBucket(now.Add(-7*24*time.Hour+time.Hour), model.GranularityHour),
+		EndBucket:   model.UsageBucket(now, model.GranularityHour),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥使用量失败: %w", err)
+	}
+	since24h := model.UsageBucket(now.Add(-23*time.Hour), model.GranularityHour)
+	for _, point := range hourly {
+		usage.Calls7d += point.TotalCalls
+		if point.Bucket >= since24h {
+			usage.Calls24h += point.TotalCalls
+		}
+	}
+
+	daily, err := s.store.UsageRollups().Query(ctx, model.UsageStatsFilter{
+		Granularity: model.GranularityDay,
+		APIKeyID:    apiKey.ID,
+		StartBucket: model.UsageBucket(now.AddDate(0, 0, -29), model.GranularityDay),
+		EndBucket:   model.UsageBucket(now, model.GranularityDay),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥使用量失败: %w", err)
+	}
+	for _, point := range daily {
+		usage.Calls30d += point.TotalCalls
+		usage.ErrorCalls30d += point.ErrorCalls
+	}
+	usage.Daily = daily
+
+	if s.disableUnused > 0 && apiKey.IsActive() {
+		disableAt := apiKey.LastActiveAt().Add(s.disableUnused)
+		usage.AutoDisableAt = &disableAt
+	}
+
+	return usage, nil
+}
+
+// DisableUnusedKeys 禁用超过配置时长未使用的有效密钥，并通知密钥所有者
+// 从未使用过的密钥按创建时间计算，返回本次禁用的密钥数
+func (s *APIKeyService) DisableUnusedKeys(ctx context.Context, now time.Time) (int, error) {
+	if s.disableUnused <= 0 {
+		return 0, nil
+	}
+
+	before := now.Add(-s.disableUnused)
+	apiKeys, err := s.store.APIKeys().ListUnused(ctx, before, now)
+	if err != nil {
+		return 0, fmt.Errorf("获取长期未使用的API密钥失败: %w", err)
+	}
+
+	days := int(s.disableUnused / (24 * time.Hour))
+	disabled := 0
+	for _, apiKey := range apiKeys {
+		done := false
+		err := store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+			// 条件更新，检查之后密钥被使用或已被禁用时跳过
+			ok, err := tx.APIKeys().DisableUnused(ctx, apiKey.ID, before)
+			if err != nil || !ok {
+				return err
+			}
+
+			after := *apiKey
+			after.Status = model.APIKeyStatusDisabled
+			if err := audit.Record(ctx, tx.AuditLogs(), model.AuditActionAPIKeyAutoDisable, model.AuditTargetAPIKey,
+				strconv.Itoa(apiKey.ID), apiKey, &after); err != nil {
+				return err
+			}
+
+			done = true
+			return tx.Notifications().Create(ctx, &model.Notification{
+				UserID:     apiKey.UserID,
+				Type:       model.NotificationAPIKeyDisabledUnused,
+				Title:      "API密钥因长期未使用已被禁用",
+				Message:    fmt.Sprintf("API密钥「%s」（%s）已超过 %d 天未使用，已被自动禁用，如仍需调用请创建新的密钥", apiKey.KeyName, apiKey.KeyPrefix, days),
+				TargetType: model.AuditTargetAPIKey,
+				TargetID:   strconv.Itoa(apiKey.ID),
+			})
+		})
+		if err != nil {
+			return disabled, fmt.Errorf("禁用长期未使用的API密钥失败: %w", err)
+		}
+		if done {
+			disabled++
+		}
+	}
+
+	return disabled, nil
+}
+
+// StartUnusedKeyDisableTask 启动定期禁用长期未使用密钥的任务，未配置自动禁用时不启动
+func (s *APIKeyService) StartUnusedKeyDisableTask(interval time.Duration) {
+	if s.disableUnused <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			disabled, err := s.DisableUnusedKeys(ctx, time.Now())
+			if err != nil {
+				slog.Error("禁用长期未使用的API密钥失败", "error", err)
+			} else if disabled > 0 {
+				slog.Info("已禁用长期未使用的API密钥", "count", disabled)
+			}
+			cancel()
+		}
+	}()
+}
@@ -1,12 +1,17 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"apihub/internal/auth/apikey"
 	"apihub/internal/auth/cache"
 	"apihub/internal/auth/crypto"
 	"apihub/internal/auth/jwt"
+	"apihub/internal/auth/oauth"
 	"apihub/internal/auth/permission"
 	"apihub/internal/store"
 )
@@ -19,16 +24,24 @@ type AuthConfig struct {
 	// 加密配置
 	Crypto CryptoConfig `json:"crypto"`
 
+	// APIKey配置
+	APIKey APIKeyConfig `json:"apikey"`
+
 	// 缓存配置
 	Cache CacheConfig `json:"cache"`
 }
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	PrivateKeyPEM string        `json:"private_key_pem"` // RSA私钥PEM格式
-	PublicKeyPEM  string        `json:"public_key_pem"`  // RSA公钥PEM格式
-	AccessExpiry  time.Duration `json:"access_expiry"`   // 访问令牌过期时间
-	Issuer        string        `json:"issuer"`          // 签发者
+	PrivateKeyPEM       string        `json:"private_key_pem"`       // RSA私钥PEM格式，设置时使用固定密钥，不轮换
+	PublicKeyPEM        string        `json:"public_key_pem"`        // RSA公钥PEM格式
+	Secret              string        `json:"secret"`                // 加密保存在数据库中的签名私钥所用的密钥
+	AccessExpiry        time.Duration `json:"access_expiry"`         // 访问令牌过期时间
+	RefreshExpiry       time.Duration `json:"refresh_expiry"`        // 刷新令牌过期时间
+	Issuer              string        `json:"issuer"`                // 签发者
+	KeyRotationInterval time.Duration `json:"key_rotation_interval"` // 签名密钥轮换周期
+	KeyOverlap          time.Duration `json:"key_overlap"`           // 新密钥提前发布及旧密钥继续用于验证的时长
+	ClientTokenExpiry   time.Duration `json:"client_token_expiry"`   // OAuth2客户端访问令牌过期时间
 }
 
 // CryptoConfig 加密配置
@@ -36,6 +49,15 @@ type CryptoConfig struct {
 	SecretKey string `json:"secret_key"` // 加密密钥
 }
 
+// APIKeyConfig APIKey配置
+type APIKeyConfig struct {
+	RotationGrace        time.Duration `json:"rotation_grace"`         // 轮换时未指定宽限期时旧密钥继续有效的时长
+	MaxRotationGrace     time.Duration `json:"max_rotation_grace"`     // 轮换时允许指定的最长宽限期
+	RotationNotifyBefore time.Duration `json:"rotation_notify_before"` // 宽限期结束前多久通知密钥所有者和管理员
+	DisableUnusedDays    int           `json:"disable_unused_days"`    // 超过多少天未使用的密钥自动禁用，0表示不自动禁用
+	SignatureMaxSkew     time.Duration `json:"signature_max_skew"`     // 签名请求的时间戳与服务器时间允许的最大偏差
+}
+
 // CacheConfig 缓存配置
 type CacheConfig struct {
 	DefaultExpiration time.Duration `json:"default_expiration"` // 默认过期时间
@@ -46,6 +68,7 @@ type CacheConfig struct {
 type AuthServices struct {
 	JWTService        *jwt.JWTService
 	APIKeyService     *apikey.APIKeyService
+	OAuthService      *oauth.OAuthService
 	CryptoService     crypto.CryptoService
 	CacheService      cache.CacheService
 	PermissionService *permission.PermissionService
@@ -59,46 +82,125 @@ func NewAuthServices(config AuthConfig, store store.Store) (*AuthServices, error
 		config.Cache.CleanupInterval,
 	)
 
-	// 创建JWT服务
-	jwtConfig := jwt.JWTConfig{
-		PrivateKeyPEM: config.JWT.PrivateKeyPEM,
-		PublicKeyPEM:  config.JWT.PublicKeyPEM,
-		AccessExpiry:  config.JWT.AccessExpiry,
-		Issuer:        config.JWT.Issuer,
-	}
-	jwtService, err := jwt.NewJWTService(jwtConfig, cacheService)
+	// 创建签名密钥管理器
+	keyManager, err := newKeyManager(config.JWT, store)
 	if err != nil {
 		return nil, err
 	}
 
+	// 创建JWT服务
+	jwtConfig := jwt.JWTConfig{
+		AccessExpiry:      config.JWT.AccessExpiry,
+		RefreshExpiry:     config.JWT.RefreshExpiry,
+		ClientTokenExpiry: config.JWT.ClientTokenExpiry,
+		Issuer:            config.JWT.Issuer,
+	}
+	jwtService := jwt.NewJWTService(jwtConfig, keyManager, store, cacheService)
+
 	// 创建加密服务
 	cryptoService := crypto.NewAESCryptoService(config.Crypto.SecretKey)
 
-	// 创建APIKey服务
-	apiKeyService := apikey.NewAPIKeyService(store, cryptoService)
-
 	// 创建权限服务
 	permissionService := permission.NewPermissionService()
 
+	// 创建APIKey服务，并将旧版本AES加密保存的密钥迁移为哈希
+	// 加密密钥只直接用于AES，APIKey和OAuth2客户端密钥的哈希各自使用由其派生的子密钥
+	apiKeyService := apikey.NewAPIKeyService(store, config.Crypto.SecretKey, cryptoService, permissionService, apikey.Config{
+		Rotation: apikey.RotationConfig{
+			DefaultGrace: config.APIKey.RotationGrace,
+			MaxGrace:     config.APIKey.MaxRotationGrace,
+			NotifyBefore: config.APIKey.RotationNotifyBefore,
+		},
+		DisableUnused: time.Duration(config.APIKey.DisableUnusedDays) * 24 * time.Hour,
+		MaxClockSkew:  config.APIKey.SignatureMaxSkew,
+	})
+	if err := migrateLegacyAPIKeys(apiKeyService); err != nil {
+		return nil, err
+	}
+
+	// 每分钟检查一次即将结束的轮换宽限期
+	apiKeyService.StartRotationNotifyTask(time.Minute)
+	// 每小时检查一次长期未使用的密钥
+	apiKeyService.StartUnusedKeyDisableTask(time.Hour)
+
+	// 创建OAuth2客户端服务
+	oauthService := oauth.NewOAuthService(store, config.Crypto.SecretKey, jwtService, permissionService)
+
 	return &AuthServices{
 		JWTService:        jwtService,
 		APIKeyService:     apiKeyService,
+		OAuthService:      oauthService,
 		CryptoService:     cryptoService,
 		CacheService:      cacheService,
 		PermissionService: permissionService,
 	}, nil
 }
 
+// newKeyManager 创建签名密钥管理器
+// 配置了私钥时使用固定密钥；否则使用加密保存在数据库中的密钥，不存在时自动生成，并定期轮换
+func newKeyManager(config JWTConfig, store store.Store) (*jwt.KeyManager, error) {
+	if config.PrivateKeyPEM != "" {
+		return jwt.NewStaticKeyManager(config.PrivateKeyPEM)
+	}
+
+	if config.Secret == "" {
+		return nil, errors.New("JWT密钥未设置，无法加密保存签名密钥")
+	}
+
+	// 重叠期不小于用户访问令牌和客户端访问令牌中较长的有效期，保证轮换前签发的令牌在过期前都能验证
+	keyManager := jwt.NewKeyManager(store, crypto.NewAESCryptoService(config.Secret), jwt.KeyConfig{
+		RotationInterval: config.KeyRotationInterval,
+		Overlap:          config.KeyOverlap,
+	}, max(config.AccessExpiry, config.ClientTokenExpiry))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := keyManager.Rotate(ctx, false); err != nil {
+		return nil, fmt.Errorf("初始化签名密钥失败: %w", err)
+	}
+
+	// 每10分钟检查一次是否需要轮换，同时同步其他实例创建的密钥
+	keyManager.StartRotationTask(10 * time.Minute)
+
+	return keyManager, nil
+}
+
+// migrateLegacyAPIKeys 将旧版本AES加密保存的APIKey迁移为HMAC哈希
+func migrateLegacyAPIKeys(apiKeyService *apikey.APIKeyService) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	migrated, err := apiKeyService.MigrateLegacyKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		slog.Info("已将旧版本API密钥迁移为哈希存储", "count", migrated)
+	}
+	return nil
+}
+
 // DefaultAuthConfig 默认认证配置
 func DefaultAuthConfig() AuthConfig {
 	return AuthConfig{
 		JWT: JWTConfig{
-			AccessExpiry: 24 * time.Hour, // 访问令牌24小时过期
-			Issuer:       "apihub",
+			AccessExpiry:        15 * time.Minute,   // 访问令牌15分钟过期，通过刷新令牌续期
+			RefreshExpiry:       7 * 24 * time.Hour, // 刷新令牌7天过期
+			Issuer:              "apihub",
+			KeyRotationInterval: 30 * 24 * time.Hour, // 签名密钥每30天轮换一次
+			KeyOverlap:          48 * time.Hour,      // 提前48小时发布新密钥，旧密钥继续验证48小时
+			ClientTokenExpiry:   15 * time.Minute,    // OAuth2客户端访问令牌15分钟过期，到期后重新申请
 		},
 		Crypto: CryptoConfig{
 			SecretKey: "default-secret-key-change-in-production", // 生产环境需要更改
 		},
+		APIKey: APIKeyConfig{
+			RotationGrace:        7 * 24 * time.Hour,  // 轮换后旧密钥默认继续有效7天
+			MaxRotationGrace:     30 * 24 * time.Hour, // 宽限期最长30天
+			RotationNotifyBefore: 24 * time.Hour,      // 宽限期结束前24小时发送通知
+			DisableUnusedDays:    0,                   // 默认不自动禁用未使用的密钥
+			SignatureMaxSkew:     5 * time.Minute,     // 签名请求时间戳允许前后偏差5分钟
+		},
 		Cache: CacheConfig{
 			DefaultExpiration: 30 * time.Minute, // 默认缓存30分钟
 			CleanupInterval:   10 * time.Minute, // 每10分钟清理一次过期缓存
@@ -19,6 +19,8 @@ type CacheService interface {
 
 	// 通用缓存操作
 	Set(key string, value interface{}, expiration time.Duration) error
+	// Add 仅在键不存在或已过期时设置缓存，键已存在时返回false
+	Add(key string, value interface{}, expiration time.Duration) bool
 	Get(key string) (interface{}, bool)
 	Delete(key string) error
 	Clear() error
@@ -71,6 +73,11 @@ func (s *GoCacheService) Set(key string, value interface{}, expiration time.Dura
 	return nil
 }
 
+// Add 仅在键不存在或已过期时设置缓存，键已存在时返回false
+func (s *GoCacheService) Add(key string, value interface{}, expiration time.Duration) bool {
+	return s.cache.Add(key, value, expiration) == nil
+}
+
 // Get 通用获取缓存
 func (s *GoCacheService) Get(key string) (interface{}, bool) {
 	return s.cache.Get(key)
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"crypto/aes"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -132,3 +133,11 @@ func GenerateSecretKey() (string, error) {
 	}
 	return base64.StdEncoding.EncodeToString(key), nil
 }
+
+// DeriveKey 从主密钥派生指定用途的子密钥，即 HMAC-SHA256(secretKey, purpose)
+// 同一个主密钥用于多种算法时，各用途使用不同的purpose派生独立的子密钥
+func DeriveKey(secretKey, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -5,6 +5,7 @@ import (
 )
 
 // CustomClaims 自定义JWT Claims
+// RegisteredClaims.ID（jti）为登录会话ID
 type CustomClaims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
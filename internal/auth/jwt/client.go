@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientTokenAudience OAuth2客户端访问令牌的aud，用于和用户访问令牌区分
+const ClientTokenAudience = "apihub:client"
+
+// clientCacheTTL 客户端状态的缓存时间
+// 本实例修改或删除客户端时立即清除缓存，其他实例的修改最多在此时间后生效
+const clientCacheTTL = 30 * time.Second
+
+// ErrClientToken 令牌为OAuth2客户端访问令牌，不能作为用户访问令牌使用
+var ErrClientToken = errors.New("token is an OAuth client access token")
+
+// ClientClaims OAuth2客户端访问令牌的Claims
+// sub为客户端ID，user_id为客户端所属用户，scope为以空格分隔的权限范围
+type ClientClaims struct {
+	ClientID string `json:"client_id"`
+	UserID   int    `json:"user_id"`
+	Scope    string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Scopes 获取令牌的权限范围列表
+func (c *ClientClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// AllowsService 检查令牌是否允许调用指定服务
+func (c *ClientClaims) AllowsService(serviceName string) bool {
+	return model.ScopesAllowService(c.Scopes(), serviceName)
+}
+
+// clientState 缓存的客户端及其所属用户
+type clientState struct {
+	client *model.OAuthClient
+	owner  *model.User
+}
+
+// clientCacheKey 客户端状态的缓存键
+func clientCacheKey(clientID string) string {
+	return "oauth_client:" + clientID
+}
+
+// GenerateClientToken 为OAuth2客户端签发访问令牌，scopes为本次授予的权限范围
+// 令牌不关联登录会话，jti为随机ID
+func (s *JWTService) GenerateClientToken(client *model.OAuthClient, scopes []string) (*TokenResponse, error) {
+	now := time.Now()
+
+	tokenID, err := NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := ClientClaims{
+		ClientID: client.ClientID,
+		UserID:   client.UserID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			Subject:   client.ClientID,
+			Audience:  jwt.ClaimStrings{ClientTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.clientTokenExpiry)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	tokenString, err := s.sign(claims, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken: tokenString,
+		ExpiresIn:   int64(s.clientTokenExpiry.Seconds()),
+	}, nil
+}
+
+// ValidateClientToken 验证OAuth2客户端访问令牌，返回令牌Claims和客户端所属用户
+// 客户端已删除或禁用、所属用户已禁用，或令牌的权限范围已不在客户端允许的范围内时令牌立即失效
+func (s *JWTService) ValidateClientToken(tokenString string) (*ClientClaims, *model.User, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ClientClaims{}, s.verificationKeyFunc,
+		jwt.WithAudience(ClientTokenAudience))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	if !token.Valid {
+		return nil, nil, errors.New("token is invalid")
+	}
+
+	claims, ok := token.Claims.(*ClientClaims)
+	if !ok || claims.ClientID == "" {
+		return nil, nil, errors.New("invalid token claims")
+	}
+
+	state, err := s.lookupClient(claims.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.client.UserID != claims.UserID {
+		return nil, nil, errors.New("client does not belong to token subject")
+	}
+	if !state.client.IsActive() {
+		return nil, nil, errors.New("client has been disabled")
+	}
+	if !state.owner.IsActive() {
+		return nil, nil, errors.New("client owner has been disabled")
+	}
+	for _, scope := range claims.Scopes() {
+		if !state.client.AllowsScope(scope) {
+			return nil, nil, fmt.Errorf("scope is no longer granted: %s", scope)
+		}
+	}
+
+	return claims, state.owner, nil
+}
+
+// lookupClient 获取客户端及其所属用户，优先使用缓存
+func (s *JWTService) lookupClient(clientID string) (*clientState, error) {
+	if cached, found := s.cacheService.Get(clientCacheKey(clientID)); found {
+		if state, ok := cached.(*clientState); ok {
+			return state, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionLookupTimeout)
+	defer cancel()
+
+	client, err := s.store.OAuthClients().GetByClientID(ctx, clientID)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, errors.New("client not found")
+		}
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+
+	owner, err := s.store.Users().GetByID(ctx, client.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client owner: %w", err)
+	}
+
+	state := &clientState{client: client, owner: owner}
+	_ = s.cacheService.Set(clientCacheKey(clientID), state, clientCacheTTL)
+	return state, nil
+}
+
+// InvalidateClient 清除客户端状态缓存，修改或删除客户端后调用
+func (s *JWTService) InvalidateClient(clientID string) {
+	_ = s.cacheService.Delete(clientCacheKey(clientID))
+}
+
+// ClientTokenExpiry 获取客户端访问令牌过期时间
+func (s *JWTService) ClientTokenExpiry() time.Duration {
+	return s.clientTokenExpiry
+}
@@ -7,29 +7,33 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"apihub/internal/auth/cache"
 	"apihub/internal/model"
+	"apihub/internal/store"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // JWTService JWT服务
 type JWTService struct {
-	privateKey   *rsa.PrivateKey
-	publicKey    *rsa.PublicKey
-	accessExpiry time.Duration
-	issuer       string
-	cacheService cache.CacheService
+	keys              *KeyManager
+	accessExpiry      time.Duration
+	refreshExpiry     time.Duration
+	clientTokenExpiry time.Duration
+	issuer            string
+	store             store.Store
+	cacheService      cache.CacheService
 }
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	PrivateKeyPEM string        // RSA私钥PEM格式
-	PublicKeyPEM  string        // RSA公钥PEM格式
-	AccessExpiry  time.Duration // 访问令牌过期时间
-	Issuer        string        // 签发者
+	AccessExpiry      time.Duration // 访问令牌过期时间
+	RefreshExpiry     time.Duration // 刷新令牌过期时间
+	ClientTokenExpiry time.Duration // OAuth2客户端访问令牌过期时间，为0时与访问令牌相同
+	Issuer            string        // 签发者
 }
 
 // TokenResponse Token响应
@@ -39,36 +43,26 @@ type TokenResponse struct {
 }
 
 // NewJWTService 创建JWT服务实例
-func NewJWTService(config JWTConfig, cacheService cache.CacheService) (*JWTService, error) {
-	service := &JWTService{
-		accessExpiry: config.AccessExpiry,
-		issuer:       config.Issuer,
-		cacheService: cacheService,
+// keys负责签名密钥的加载和轮换，令牌头中的kid标识签名所用的密钥；
+// 令牌中的jti为登录会话ID，验证时通过cacheService缓存的会话状态检查会话是否已撤销
+func NewJWTService(config JWTConfig, keys *KeyManager, store store.Store, cacheService cache.CacheService) *JWTService {
+	if config.ClientTokenExpiry <= 0 {
+		config.ClientTokenExpiry = config.AccessExpiry
 	}
 
-	// 解析私钥
-	if config.PrivateKeyPEM != "" {
-		privateKey, err := parsePrivateKey(config.PrivateKeyPEM)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse private key: %w", err)
-		}
-		service.privateKey = privateKey
-		service.publicKey = &privateKey.PublicKey
-	} else {
-		// 如果没有提供密钥，生成新的密钥对
-		privateKey, err := generateRSAKeyPair()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate RSA key pair: %w", err)
-		}
-		service.privateKey = privateKey
-		service.publicKey = &privateKey.PublicKey
+	return &JWTService{
+		keys:              keys,
+		accessExpiry:      config.AccessExpiry,
+		refreshExpiry:     config.RefreshExpiry,
+		clientTokenExpiry: config.ClientTokenExpiry,
+		issuer:            config.Issuer,
+		store:             store,
+		cacheService:      cacheService,
 	}
-
-	return service, nil
 }
 
-// GenerateToken 生成访问令牌
-func (s *JWTService) GenerateToken(user *model.User) (*TokenResponse, error) {
+// GenerateToken 为登录会话生成访问令牌，sessionID写入jti
+func (s *JWTService) GenerateToken(user *model.User, sessionID string) (*TokenResponse, error) {
 	now := time.Now()
 
 	// 生成访问令牌
@@ -77,6 +71,7 @@ func (s *JWTService) GenerateToken(user *model.User) (*TokenResponse, error) {
 		Username: user.Username,
 		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			Issuer:    s.issuer,
 			Subject:   fmt.Sprintf("%d", user.ID),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -85,10 +80,9 @@ func (s *JWTService) GenerateToken(user *model.User) (*TokenResponse, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	tokenString, err := token.SignedString(s.privateKey)
+	tokenString, err := s.sign(claims, now)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign access token: %w", err)
+		return nil, err
 	}
 
 	return &TokenResponse{
@@ -97,22 +91,10 @@ func (s *JWTService) GenerateToken(user *model.User) (*TokenResponse, error) {
 	}, nil
 }
 
-// ValidateToken 验证Token
+// ValidateToken 验证Token，并检查令牌所属的会话未撤销、未过期
 func (s *JWTService) ValidateToken(tokenString string) (*CustomClaims, error) {
-	// 检查Token是否在黑名单中
-	if s.cacheService.IsBlacklisted(tokenString) {
-		return nil, errors.New("token is blacklisted")
-	}
-
 	// 解析Token
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.publicKey, nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, s.verificationKeyFunc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -127,47 +109,68 @@ func (s *JWTService) ValidateToken(tokenString string) (*CustomClaims, error) {
 		return nil, errors.New("invalid token claims")
 	}
 
+	// 客户端访问令牌没有登录会话，由ValidateClientToken验证
+	if slices.Contains(claims.Audience, ClientTokenAudience) {
+		return nil, ErrClientToken
+	}
+
+	// 检查会话状态
+	if err := s.checkSession(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
-// RevokeToken 撤销Token
-func (s *JWTService) RevokeToken(tokenString string) error {
-	// 验证Token以获取过期时间
-	claims, err := s.ValidateToken(tokenString)
+// sign 使用当前签名密钥对Claims签名，令牌头中的kid标识所用的密钥
+func (s *JWTService) sign(claims jwt.Claims, now time.Time) (string, error) {
+	key := s.keys.signingKey(now)
+	if key == nil {
+		return "", errors.New("no signing key available")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.meta.KID
+	tokenString, err := token.SignedString(key.privateKey)
 	if err != nil {
-		// 即使Token无效，也尝试加入黑名单
-		return s.cacheService.AddToBlacklist(tokenString, s.accessExpiry)
+		return "", fmt.Errorf("failed to sign access token: %w", err)
 	}
+	return tokenString, nil
+}
 
-	// 计算剩余有效时间
-	remainingTime := time.Until(claims.ExpiresAt.Time)
-	if remainingTime <= 0 {
-		// Token已过期，无需加入黑名单
-		return nil
+// verificationKeyFunc 根据令牌头中的kid查找验证公钥
+func (s *JWTService) verificationKeyFunc(token *jwt.Token) (interface{}, error) {
+	// 验证签名方法
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 
-	// 加入黑名单
-	err = s.cacheService.AddToBlacklist(tokenString, remainingTime)
-	if err != nil {
-		return fmt.Errorf("failed to add token to blacklist: %w", err)
+	// 根据kid查找签名密钥，已过验证截止时间的密钥签发的令牌视为无效
+	kid, _ := token.Header["kid"].(string)
+	publicKey, exists := s.keys.verificationKey(kid)
+	if !exists {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
 	}
+	return publicKey, nil
+}
+
+// RefreshExpiry 获取刷新令牌过期时间
+func (s *JWTService) RefreshExpiry() time.Duration {
+	return s.refreshExpiry
+}
 
-	return nil
+// Keys 获取签名密钥管理器
+func (s *JWTService) Keys() *KeyManager {
+	return s.keys
 }
 
-// GetPublicKeyPEM 获取公钥PEM格式
+// GetPublicKeyPEM 获取当前签名密钥的公钥PEM格式
 func (s *JWTService) GetPublicKeyPEM() (string, error) {
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(s.publicKey)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	key := s.keys.signingKey(time.Now())
+	if key == nil {
+		return "", errors.New("no signing key available")
 	}
-
-	publicKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: publicKeyBytes,
-	})
-
-	return string(publicKeyPEM), nil
+	return key.meta.PublicKey, nil
 }
 
 // parsePrivateKey 解析私钥PEM
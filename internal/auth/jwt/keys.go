@@ -0,0 +1,413 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"apihub/internal/auth/crypto"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// ErrStaticKey 使用配置文件中的固定密钥时不支持轮换
+var ErrStaticKey = errors.New("使用配置文件中的固定签名密钥，不支持轮换")
+
+// signingAlgorithm 签名算法
+const signingAlgorithm = "RS256"
+
+// KeyConfig 签名密钥轮换配置
+type KeyConfig struct {
+	RotationInterval time.Duration // 每个密钥用于签名的时长
+	Overlap          time.Duration // 新密钥提前发布的时长，也是旧密钥停止签名后继续用于验证的时长
+}
+
+// loadedKey 已加载的签名密钥
+type loadedKey struct {
+	meta       *model.SigningKey
+	privateKey *rsa.PrivateKey // 解密失败时为nil，只能用于验证
+	publicKey  *rsa.PublicKey
+}
+
+// KeyManager JWT签名密钥管理器
+// 密钥加密保存在数据库中，多个实例共享同一组密钥，令牌头中的kid标识签名所用的密钥。
+// 新密钥在开始签名前提前发布到JWKS，旧密钥停止签名后在重叠期内仍可验证已签发的令牌
+type KeyManager struct {
+	store  store.Store // 为nil时使用配置文件中的固定密钥，不轮换
+	crypto crypto.CryptoService
+	config KeyConfig
+
+	mu    sync.RWMutex
+	keys  []*loadedKey // 按开始签名时间升序
+	byKID map[string]*loadedKey
+
+	rotateMu sync.Mutex // 避免同一实例内并发轮换
+}
+
+// NewKeyManager 创建基于数据库的签名密钥管理器
+// 重叠期不小于访问令牌有效期，保证轮换前签发的令牌在过期前始终可以验证
+func NewKeyManager(store store.Store, cryptoService crypto.CryptoService, config KeyConfig, accessExpiry time.Duration) *KeyManager {
+	if config.RotationInterval <= 0 {
+		config.RotationInterval = 30 * 24 * time.Hour
+	}
+	if config.Overlap < accessExpiry {
+		config.Overlap = accessExpiry
+	}
+
+	return &KeyManager{
+		store:  store,
+		crypto: cryptoService,
+		config: config,
+		byKID:  make(map[string]*loadedKey),
+	}
+}
+
+// NewStaticKeyManager 使用配置文件中的固定私钥创建签名密钥管理器，kid由公钥指纹生成
+func NewStaticKeyManager(privateKeyPEM string) (*KeyManager, error) {
+	privateKey, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	publicKeyPEM, err := encodePublicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	thumbprint := sha256.Sum256(der)
+
+	key := &loadedKey{
+		meta: &model.SigningKey{
+			KID:         base64.RawURLEncoding.EncodeToString(thumbprint[:16]),
+			Algorithm:   signingAlgorithm,
+			PublicKey:   publicKeyPEM,
+			SignUntil:   time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+			VerifyUntil: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		privateKey: privateKey,
+		publicKey:  &privateKey.PublicKey,
+	}
+
+	return &KeyManager{
+		keys:  []*loadedKey{key},
+		byKID: map[string]*loadedKey{key.meta.KID: key},
+	}, nil
+}
+
+// Reload 从数据库重新加载签名密钥
+// 私钥解密失败的密钥仍加载公钥用于验证，但不会用于签名
+func (m *KeyManager) Reload(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	keys, err := m.store.SigningKeys().ListValid(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("加载签名密钥失败: %w", err)
+	}
+
+	loaded := make([]*loadedKey, 0, len(keys))
+	byKID := make(map[string]*loadedKey, len(keys))
+	for _, meta := range keys {
+		key := &loadedKey{meta: meta}
+
+		publicKey, err := parsePublicKey(meta.PublicKey)
+		if err != nil {
+			slog.WarnContext(ctx, "忽略无效的签名密钥", "kid", meta.KID, "error", err)
+			continue
+		}
+		key.publicKey = publicKey
+
+		if privateKeyPEM, err := m.crypto.Decrypt(meta.PrivateKey); err != nil {
+			slog.WarnContext(ctx, "解密签名私钥失败，该密钥仅用于验证", "kid", meta.KID, "error", err)
+		} else if privateKey, err := parsePrivateKey(privateKeyPEM); err != nil {
+			slog.WarnContext(ctx, "解析签名私钥失败，该密钥仅用于验证", "kid", meta.KID, "error", err)
+		} else {
+			key.privateKey = privateKey
+		}
+
+		loaded = append(loaded, key)
+		byKID[meta.KID] = key
+	}
+
+	m.mu.Lock()
+	m.keys = loaded
+	m.byKID = byKID
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Rotate 按计划轮换签名密钥
+// 没有可用的签名密钥时立即启用新密钥；当前密钥在重叠期内即将停止签名且尚无后续密钥时，
+// 创建在其停止签名时启用的新密钥并提前发布。force为true时立即启用新密钥，其余密钥停止签名。
+// 同时删除已超过验证截止时间的密钥
+func (m *KeyManager) Rotate(ctx context.Context, force bool) error {
+	if m.store == nil {
+		if force {
+			return ErrStaticKey
+		}
+		return nil
+	}
+
+	m.rotateMu.Lock()
+	defer m.rotateMu.Unlock()
+
+	if err := m.Reload(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	current := m.signingKey(now)
+
+	var activatesAt time.Time
+	switch {
+	case force || current == nil || !now.Before(current.meta.SignUntil):
+		activatesAt = now
+	case current.meta.SignUntil.Sub(now) <= m.config.Overlap && !m.hasSuccessor(current):
+		activatesAt = current.meta.SignUntil
+	}
+
+	if !activatesAt.IsZero() {
+		err := store.WithTx(ctx, m.store, func(tx store.Transaction) error {
+			if force {
+				if err := m.retireAll(ctx, tx, now); err != nil {
+					return err
+				}
+			}
+			return m.createKey(ctx, tx, activatesAt)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if deleted, err := m.store.SigningKeys().DeleteExpired(ctx, now); err != nil {
+		slog.WarnContext(ctx, "删除过期签名密钥失败", "error", err)
+	} else if deleted > 0 {
+		slog.InfoContext(ctx, "已删除过期签名密钥", "count", deleted)
+	}
+
+	if activatesAt.IsZero() {
+		return nil
+	}
+	return m.Reload(ctx)
+}
+
+// StartRotationTask 启动定期轮换任务
+// 同时用于同步其他实例创建的密钥
+func (m *KeyManager) StartRotationTask(interval time.Duration) {
+	if m.store == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := m.Rotate(ctx, false); err != nil {
+				slog.Error("轮换签名密钥失败", "error", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// hasSuccessor 检查是否已有在当前密钥之后启用的密钥
+func (m *KeyManager) hasSuccessor(current *loadedKey) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.privateKey != nil && key.meta.ActivatesAt.After(current.meta.ActivatesAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// retireAll 令所有密钥停止签名，已签发的令牌在各自的验证截止时间前仍可验证
+func (m *KeyManager) retireAll(ctx context.Context, tx store.Transaction, now time.Time) error {
+	m.mu.RLock()
+	keys := m.keys
+	m.mu.RUnlock()
+
+	for _, key := range keys {
+		if key.meta.SignUntil.After(now) {
+			if err := tx.SigningKeys().UpdateSignUntil(ctx, key.meta.ID, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createKey 生成并加密保存新的签名密钥
+func (m *KeyManager) createKey(ctx context.Context, tx store.Transaction, activatesAt time.Time) error {
+	privateKey, err := generateRSAKeyPair()
+	if err != nil {
+		return err
+	}
+
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	encrypted, err := m.crypto.Encrypt(string(privateKeyPEM))
+	if err != nil {
+		return fmt.Errorf("加密签名私钥失败: %w", err)
+	}
+	publicKeyPEM, err := encodePublicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	kid := make([]byte, 12)
+	if _, err := rand.Read(kid); err != nil {
+		return fmt.Errorf("生成密钥ID失败: %w", err)
+	}
+
+	signUntil := activatesAt.Add(m.config.RotationInterval)
+	key := &model.SigningKey{
+		KID:         base64.RawURLEncoding.EncodeToString(kid),
+		Algorithm:   signingAlgorithm,
+		PrivateKey:  encrypted,
+		PublicKey:   publicKeyPEM,
+		ActivatesAt: activatesAt,
+		SignUntil:   signUntil,
+		VerifyUntil: signUntil.Add(m.config.Overlap),
+	}
+	if err := tx.SigningKeys().Create(ctx, key); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "已创建签名密钥",
+		"kid", key.KID,
+		"activates_at", key.ActivatesAt,
+		"sign_until", key.SignUntil,
+	)
+	return nil
+}
+
+// signingKey 获取当前用于签名的密钥
+// 选择已启用且未停止签名的最新密钥；轮换任务未能及时执行时退回到最近启用过的密钥，避免无法签发令牌
+func (m *KeyManager) signingKey(now time.Time) *loadedKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var fallback *loadedKey
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		key := m.keys[i]
+		if key.privateKey == nil || now.Before(key.meta.ActivatesAt) {
+			continue
+		}
+		if now.Before(key.meta.SignUntil) {
+			return key
+		}
+		if fallback == nil {
+			fallback = key
+		}
+	}
+	return fallback
+}
+
+// verificationKey 根据kid获取验证令牌的公钥
+func (m *KeyManager) verificationKey(kid string) (*rsa.PublicKey, bool) {
+	m.mu.RLock()
+	key, exists := m.byKID[kid]
+	m.mu.RUnlock()
+
+	if !exists || !time.Now().Before(key.meta.VerifyUntil) {
+		return nil, false
+	}
+	return key.publicKey, true
+}
+
+// JWKS 获取所有可用于验证的公钥，包括尚未开始签名的密钥
+func (m *KeyManager) JWKS() *model.JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	jwks := &model.JWKS{Keys: make([]model.JWK, 0, len(m.keys))}
+	for _, key := range m.keys {
+		if !now.Before(key.meta.VerifyUntil) {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, model.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: key.meta.Algorithm,
+			Kid: key.meta.KID,
+			N:   base64.RawURLEncoding.EncodeToString(key.publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.publicKey.E)).Bytes()),
+		})
+	}
+	return jwks
+}
+
+// List 获取所有可用于验证的密钥及其状态
+func (m *KeyManager) List() []*model.SigningKeyInfo {
+	now := time.Now()
+	current := m.signingKey(now)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]*model.SigningKeyInfo, 0, len(m.keys))
+	for _, key := range m.keys {
+		infos = append(infos, &model.SigningKeyInfo{
+			SigningKey: key.meta,
+			Status:     key.meta.Status(now),
+			Current:    key == current,
+		})
+	}
+	return infos
+}
+
+// encodePublicKeyPEM 将公钥编码为PEM格式
+func encodePublicKeyPEM(publicKey *rsa.PublicKey) (string, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	})), nil
+}
+
+// parsePublicKey 解析公钥PEM
+func parsePublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("key is not RSA public key")
+	}
+	return rsaKey, nil
+}
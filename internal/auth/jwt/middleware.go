@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"apihub/internal/metrics"
 	"apihub/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +22,8 @@ const (
 	UsernameKey ContextKey = "username"
 	// UserRoleKey 用户角色在上下文中的键
 	UserRoleKey ContextKey = "user_role"
+	// ClientClaimsKey OAuth2客户端访问令牌Claims在上下文中的键
+	ClientClaimsKey ContextKey = "client_claims"
 )
 
 // JWTAuthMiddleware JWT认证中间件
@@ -29,6 +32,7 @@ func JWTAuthMiddleware(jwtService *JWTService) gin.HandlerFunc {
 		// 从请求头获取Token
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			metrics.AuthFailed(metrics.AuthMethodNone)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "缺少授权头"))
 			c.Abort()
 			return
@@ -37,6 +41,7 @@ func JWTAuthMiddleware(jwtService *JWTService) gin.HandlerFunc {
 		// 检查Bearer前缀
 		const bearerPrefix = "Bearer "
 		if !strings.HasPrefix(authHeader, bearerPrefix) {
+			metrics.AuthFailed(metrics.AuthMethodJWT)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "授权头格式无效"))
 			c.Abort()
 			return
@@ -45,6 +50,7 @@ func JWTAuthMiddleware(jwtService *JWTService) gin.HandlerFunc {
 		// 提取Token
 		tokenString := authHeader[len(bearerPrefix):]
 		if tokenString == "" {
+			metrics.AuthFailed(metrics.AuthMethodJWT)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "缺少Token"))
 			c.Abort()
 			return
@@ -53,11 +59,14 @@ func JWTAuthMiddleware(jwtService *JWTService) gin.HandlerFunc {
 		// 验证Token
 		claims, err := jwtService.ValidateToken(tokenString)
 		if err != nil {
+			metrics.AuthFailed(metrics.AuthMethodJWT)
 			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeTokenInvalid, "Token无效: "+err.Error()))
 			c.Abort()
 			return
 		}
 
+		jwtService.TouchSession(c.Request.Context(), claims.ID, c.ClientIP(), c.Request.UserAgent())
+
 		// 将用户信息存入上下文
 		c.Set(string(UserClaimsKey), claims)
 		c.Set(string(UserIDKey), claims.UserID)
@@ -104,6 +113,8 @@ func OptionalJWTAuthMiddleware(jwtService *JWTService) gin.HandlerFunc {
 			return
 		}
 
+		jwtService.TouchSession(c.Request.Context(), claims.ID, c.ClientIP(), c.Request.UserAgent())
+
 		// 将用户信息存入上下文
 		c.Set(string(UserClaimsKey), claims)
 		c.Set(string(UserIDKey), claims.UserID)
@@ -125,6 +136,17 @@ func GetUserClaims(c *gin.Context) (*CustomClaims, bool) {
 	return userClaims, ok
 }
 
+// GetClientClaims 从上下文获取OAuth2客户端访问令牌的Claims
+func GetClientClaims(c *gin.Context) (*ClientClaims, bool) {
+	claims, exists := c.Get(string(ClientClaimsKey))
+	if !exists {
+		return nil, false
+	}
+
+	clientClaims, ok := claims.(*ClientClaims)
+	return clientClaims, ok
+}
+
 // GetUserID 从上下文获取用户ID
 func GetUserID(c *gin.Context) (int, bool) {
 	userID, exists := c.Get(string(UserIDKey))
@@ -0,0 +1,101 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+const (
+	// sessionCacheTTL 会话状态的缓存时间
+	// 本实例撤销会话时立即清除缓存，其他实例撤销的会话最多在此时间后失效
+	sessionCacheTTL = 30 * time.Second
+	// sessionTouchInterval 更新会话最后活动时间的最小间隔
+	sessionTouchInterval = time.Minute
+	// sessionLookupTimeout 验证令牌时查询会话的超时时间
+	sessionLookupTimeout = 5 * time.Second
+)
+
+// sessionCacheKey 会话状态的缓存键
+func sessionCacheKey(id string) string {
+	return "session:" + id
+}
+
+// sessionTouchKey 会话最后活动时间更新记录的缓存键
+func sessionTouchKey(id string) string {
+	return "session_touch:" + id
+}
+
+// checkSession 检查令牌所属的会话未撤销、未过期
+func (s *JWTService) checkSession(claims *CustomClaims) error {
+	if claims.ID == "" {
+		return errors.New("token has no session")
+	}
+
+	session, err := s.lookupSession(claims.ID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != claims.UserID {
+		return errors.New("session does not belong to token subject")
+	}
+	if session.RevokedAt != nil {
+		return errors.New("session has been revoked")
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return errors.New("session has expired")
+	}
+
+	return nil
+}
+
+// lookupSession 获取会话，优先使用缓存
+func (s *JWTService) lookupSession(id string) (*model.Session, error) {
+	if cached, found := s.cacheService.Get(sessionCacheKey(id)); found {
+		if session, ok := cached.(*model.Session); ok {
+			return session, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionLookupTimeout)
+	defer cancel()
+
+	session, err := s.store.Sessions().GetByID(ctx, id)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, errors.New("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	_ = s.cacheService.Set(sessionCacheKey(id), session, sessionCacheTTL)
+	return session, nil
+}
+
+// InvalidateSessions 清除会话状态缓存，撤销或延长会话后调用
+func (s *JWTService) InvalidateSessions(ids ...string) {
+	for _, id := range ids {
+		_ = s.cacheService.Delete(sessionCacheKey(id))
+	}
+}
+
+// TouchSession 记录会话的最后活动时间和客户端信息，同一会话每分钟最多写入一次
+func (s *JWTService) TouchSession(ctx context.Context, id, clientIP, userAgent string) {
+	if id == "" {
+		return
+	}
+	if _, found := s.cacheService.Get(sessionTouchKey(id)); found {
+		return
+	}
+	_ = s.cacheService.Set(sessionTouchKey(id), true, sessionTouchInterval)
+
+	if err := s.store.Sessions().Touch(ctx, id, time.Now(), clientIP, userAgent); err != nil {
+		slog.WarnContext(ctx, "更新会话最后活动时间失败", "session_id", id, "error", err)
+	}
+}
@@ -0,0 +1,360 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/auth/crypto"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/auth/permission"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// OAuth2客户端凭据的固定前缀
+// 客户端ID格式为 ahc_<随机部分>，客户端密钥格式为 ahcs_<随机部分>
+const (
+	ClientIDPrefix     = "ahc"
+	ClientSecretPrefix = "ahcs"
+)
+
+// secretHashPurpose 从主密钥派生客户端密钥哈希密钥时使用的用途标识
+const secretHashPurpose = "apihub/oauth-client-hash"
+
+const (
+	clientIDBytes     = 12
+	clientSecretBytes = 32
+)
+
+// OAuth2客户端管理相关错误
+var (
+	ErrClientNotFound    = errors.New("OAuth2客户端不存在")
+	ErrInvalidScope      = errors.New("无效的权限范围")
+	ErrScopeNotPermitted = errors.New("权限范围超出用户自身权限")
+)
+
+// TokenError 令牌端点错误，Code为RFC 6749定义的错误码
+type TokenError struct {
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// OAuthService OAuth2客户端服务
+// 客户端密钥与APIKey一样只保存HMAC-SHA256哈希，明文只在创建和重置时返回一次；
+// 客户端凭据模式签发的访问令牌由JWTService签名，以客户端所属用户的身份调用服务
+type OAuthService struct {
+	store             store.Store
+	hashSecret        []byte
+	jwtService        *jwt.JWTService
+	permissionService *permission.PermissionService
+}
+
+// NewOAuthService 创建OAuth2客户端服务实例
+// hashSecret为主密钥，客户端密钥的HMAC哈希使用由其派生的子密钥计算，修改后已有的客户端密钥全部失效
+func NewOAuthService(store store.Store, hashSecret string, jwtService *jwt.JWTService, permissionService *permission.PermissionService) *OAuthService {
+	return &OAuthService{
+		store:             store,
+		hashSecret:        crypto.DeriveKey(hashSecret, secretHashPurpose),
+		jwtService:        jwtService,
+		permissionService: permissionService,
+	}
+}
+
+// ListClients 获取用户的OAuth2客户端，不包含客户端密钥
+func (s *OAuthService) ListClients(ctx context.Context, userID int) ([]*model.OAuthClient, error) {
+	clients, err := s.store.OAuthClients().ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取OAuth2客户端失败: %w", err)
+	}
+	return clients, nil
+}
+
+// CreateClient 为用户创建OAuth2客户端，scopes为空时允许申请所有服务的权限范围
+// 返回值中包含仅此一次可见的客户端密钥
+func (s *OAuthService) CreateClient(ctx context.Context, userID int, name string, scopes []string) (*model.OAuthClient, error) {
+	owner, err := s.store.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	scopes, err = s.normalizeScopes(ctx, owner, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	random, err := randomHex(clientIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &model.OAuthClient{
+		UserID:     userID,
+		Name:       name,
+		ClientID:   ClientIDPrefix + "_" + random,
+		SecretHash: s.hashSecretKey(secret),
+		Scopes:     scopes,
+		Status:     model.OAuthClientStatusActive,
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.OAuthClients().Create(ctx, client); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionOAuthClientCreate,
+			model.AuditTargetOAuthClient, strconv.Itoa(client.ID), nil, client)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建OAuth2客户端失败: %w", err)
+	}
+
+	client.ClientSecret = secret
+	return client, nil
+}
+
+// UpdateClient 更新用户自己的OAuth2客户端的名称、权限范围或状态
+// 禁用客户端或缩小权限范围后，已签发的访问令牌随之失效
+func (s *OAuthService) UpdateClient(ctx context.Context, userID int, req *model.UpdateOAuthClientRequest) (*model.OAuthClient, error) {
+	client, err := s.getOwnedClient(ctx, userID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	before := *client
+
+	if req.Name != "" {
+		client.Name = req.Name
+	}
+	if len(req.Scopes) > 0 {
+		owner, err := s.store.Users().GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("获取用户失败: %w", err)
+		}
+		client.Scopes, err = s.normalizeScopes(ctx, owner, req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Status != nil {
+		client.Status = *req.Status
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.OAuthClients().Update(ctx, client); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionOAuthClientUpdate,
+			model.AuditTargetOAuthClient, strconv.Itoa(client.ID), &before, client)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新OAuth2客户端失败: %w", err)
+	}
+
+	s.jwtService.InvalidateClient(client.ClientID)
+	return client, nil
+}
+
+// ResetSecret 重新生成用户自己的OAuth2客户端的密钥，原密钥立即失效
+// 已签发的访问令牌在过期前仍然有效，需要立即停止访问时应禁用客户端
+func (s *OAuthService) ResetSecret(ctx context.Context, userID, id int) (*model.OAuthClient, error) {
+	client, err := s.getOwnedClient(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.newSecret()
+	if err != nil {
+		return nil, err
+	}
+	client.SecretHash = s.hashSecretKey(secret)
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.OAuthClients().Update(ctx, client); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionOAuthClientResetSecret,
+			model.AuditTargetOAuthClient, strconv.Itoa(client.ID), nil, map[string]any{"client_id": client.ClientID})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("重置OAuth2客户端密钥失败: %w", err)
+	}
+
+	client.ClientSecret = secret
+	return client, nil
+}
+
+// DeleteClient 删除用户自己的OAuth2客户端，已签发的访问令牌随之失效
+func (s *OAuthService) DeleteClient(ctx context.Context, userID, id int) error {
+	client, err := s.getOwnedClient(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.OAuthClients().Delete(ctx, client.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionOAuthClientDelete,
+			model.AuditTargetOAuthClient, strconv.Itoa(client.ID), client, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("删除OAuth2客户端失败: %w", err)
+	}
+
+	s.jwtService.InvalidateClient(client.ClientID)
+	return nil
+}
+
+// IssueToken 客户端凭据模式：验证客户端凭据并签发访问令牌
+// scope为以空格分隔的权限范围，为空时授予客户端的全部权限范围，否则必须是客户端权限范围的子集
+func (s *OAuthService) IssueToken(ctx context.Context, clientID, clientSecret, scope string) (*model.OAuthTokenResponse, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, &TokenError{Code: model.OAuthErrInvalidClient, Description: "缺少客户端凭据"}
+	}
+
+	client, err := s.store.OAuthClients().GetByClientID(ctx, clientID)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, &TokenError{Code: model.OAuthErrInvalidClient, Description: "客户端认证失败"}
+		}
+		return nil, fmt.Errorf("获取OAuth2客户端失败: %w", err)
+	}
+	if !hmac.Equal([]byte(client.SecretHash), []byte(s.hashSecretKey(clientSecret))) {
+		return nil, &TokenError{Code: model.OAuthErrInvalidClient, Description: "客户端认证失败"}
+	}
+	if !client.IsActive() {
+		return nil, &TokenError{Code: model.OAuthErrUnauthorizedClient, Description: "客户端已禁用"}
+	}
+
+	owner, err := s.store.Users().GetByID(ctx, client.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端所属用户失败: %w", err)
+	}
+	if !owner.IsActive() {
+		return nil, &TokenError{Code: model.OAuthErrUnauthorizedClient, Description: "客户端所属用户已禁用"}
+	}
+
+	scopes := client.Scopes
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		scopes = make([]string, 0, len(requested))
+		seen := make(map[string]bool, len(requested))
+		for _, requestedScope := range requested {
+			if seen[requestedScope] {
+				continue
+			}
+			seen[requestedScope] = true
+			if !client.AllowsScope(requestedScope) {
+				return nil, &TokenError{Code: model.OAuthErrInvalidScope, Description: "权限范围超出客户端允许的范围: " + requestedScope}
+			}
+			scopes = append(scopes, requestedScope)
+		}
+	}
+
+	token, err := s.jwtService.GenerateClientToken(client, scopes)
+	if err != nil {
+		return nil, fmt.Errorf("签发访问令牌失败: %w", err)
+	}
+
+	if err := s.store.OAuthClients().TouchLastToken(ctx, client.ID, time.Now()); err != nil {
+		slog.WarnContext(ctx, "更新OAuth2客户端最后获取令牌时间失败", "client_id", client.ClientID, "error", err)
+	}
+
+	return &model.OAuthTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   model.OAuthTokenTypeBearer,
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// getOwnedClient 获取属于指定用户的OAuth2客户端，不存在或不属于该用户时返回ErrClientNotFound
+func (s *OAuthService) getOwnedClient(ctx context.Context, userID, id int) (*model.OAuthClient, error) {
+	client, err := s.store.OAuthClients().GetByID(ctx, id)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("获取OAuth2客户端失败: %w", err)
+	}
+	if client.UserID != userID {
+		return nil, ErrClientNotFound
+	}
+	return client, nil
+}
+
+// normalizeScopes 校验并去重权限范围，为空时允许申请所有服务
+// 客户端只能申请服务权限范围，要求用户可以使用服务且服务存在
+func (s *OAuthService) normalizeScopes(ctx context.Context, owner *model.User, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		scopes = model.DefaultAPIKeyScopes
+	}
+	if !s.permissionService.HasPermission(owner.Role, permission.PermUseService) {
+		return nil, ErrScopeNotPermitted
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		serviceName, ok := strings.CutPrefix(scope, model.APIKeyScopeServicePrefix)
+		if !ok || serviceName == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if scope != model.APIKeyScopeServiceAll {
+			if _, err := s.store.Services().GetByName(ctx, serviceName); err != nil {
+				return nil, fmt.Errorf("%w: 服务不存在: %s", ErrInvalidScope, serviceName)
+			}
+		}
+
+		normalized = append(normalized, scope)
+	}
+
+	return normalized, nil
+}
+
+// newSecret 生成新的客户端密钥
+func (s *OAuthService) newSecret() (string, error) {
+	random, err := randomHex(clientSecretBytes)
+	if err != nil {
+		return "", err
+	}
+	return ClientSecretPrefix + "_" + random, nil
+}
+
+// hashSecretKey 计算客户端密钥的HMAC-SHA256
+func (s *OAuthService) hashSecretKey(secret string) string {
+	mac := hmac.New(sha256.New, s.hashSecret)
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomHex 生成n个随机字节并编码为十六进制
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
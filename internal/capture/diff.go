@@ -0,0 +1,181 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"apihub/internal/audit"
+	"apihub/internal/model"
+)
+
+// Comparer 比较原始响应体和重放响应体
+type Comparer struct {
+	ignore [][]string
+}
+
+// NewComparer 创建响应比较器，ignorePaths中的JSON路径无效时返回错误
+func NewComparer(ignorePaths []string) (*Comparer, error) {
+	ignore := make([][]string, 0, len(ignorePaths))
+	for _, path := range ignorePaths {
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		ignore = append(ignore, segments)
+	}
+	return &Comparer{ignore: ignore}, nil
+}
+
+// Diff 比较原始响应体和重放响应体
+// 两者都是JSON时按字段比较，忽略路径匹配的字段及原始响应中已脱敏的字段不参与比较；
+// 否则按文本整体比较
+func (c *Comparer) Diff(original, replayed string) []*model.ReplayDifference {
+	diffs := make([]*model.ReplayDifference, 0)
+
+	originalDoc, originalOK := decodeJSON(original)
+	replayedDoc, replayedOK := decodeJSON(replayed)
+	if !originalOK || !replayedOK {
+		if original != replayed {
+			diffs = append(diffs, &model.ReplayDifference{
+				Kind:     model.ReplayDiffChanged,
+				Original: original,
+				Replayed: replayed,
+			})
+		}
+		return diffs
+	}
+
+	diffNode(nil, originalDoc, replayedDoc, c.ignore, &diffs)
+	return diffs
+}
+
+// decodeJSON 解析JSON文本，数字保留原始表示
+func decodeJSON(s string) (any, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(trimmed)))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil || decoder.More() {
+		return nil, false
+	}
+	return doc, true
+}
+
+// diffNode 递归比较两个JSON节点，差异追加到diffs
+func diffNode(path []string, original, replayed any, ignore [][]string, diffs *[]*model.ReplayDifference) {
+	if matchesAnyPath(path, ignore) {
+		return
+	}
+	if s, ok := original.(string); ok && s == audit.Redacted {
+		return
+	}
+
+	switch o := original.(type) {
+	case map[string]any:
+		if r, ok := replayed.(map[string]any); ok {
+			keys := make([]string, 0, len(o)+len(r))
+			for key := range o {
+				keys = append(keys, key)
+			}
+			for key := range r {
+				if _, exists := o[key]; !exists {
+					keys = append(keys, key)
+				}
+			}
+			slices.Sort(keys)
+
+			for _, key := range keys {
+				ov, inOriginal := o[key]
+				rv, inReplayed := r[key]
+				diffChild(append(path, key), ov, rv, inOriginal, inReplayed, ignore, diffs)
+			}
+			return
+		}
+
+	case []any:
+		if r, ok := replayed.([]any); ok {
+			for i := 0; i < max(len(o), len(r)); i++ {
+				var ov, rv any
+				if i < len(o) {
+					ov = o[i]
+				}
+				if i < len(r) {
+					rv = r[i]
+				}
+				diffChild(append(path, strconv.Itoa(i)), ov, rv, i < len(o), i < len(r), ignore, diffs)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(original, replayed) {
+		*diffs = append(*diffs, &model.ReplayDifference{
+			Path:     formatJSONPath(path),
+			Kind:     model.ReplayDiffChanged,
+			Original: original,
+			Replayed: replayed,
+		})
+	}
+}
+
+// diffChild 比较对象字段或数组元素，处理只存在于一侧的情况
+func diffChild(path []string, original, replayed any, inOriginal, inReplayed bool, ignore [][]string, diffs *[]*model.ReplayDifference) {
+	// 复制路径，避免append共享底层数组导致后续修改
+	path = slices.Clone(path)
+
+	switch {
+	case inOriginal && inReplayed:
+		diffNode(path, original, replayed, ignore, diffs)
+	case matchesAnyPath(path, ignore):
+		return
+	case inOriginal:
+		*diffs = append(*diffs, &model.ReplayDifference{
+			Path:     formatJSONPath(path),
+			Kind:     model.ReplayDiffRemoved,
+			Original: original,
+		})
+	default:
+		*diffs = append(*diffs, &model.ReplayDifference{
+			Path:     formatJSONPath(path),
+			Kind:     model.ReplayDiffAdded,
+			Replayed: replayed,
+		})
+	}
+}
+
+// matchesAnyPath 检查字段路径是否匹配任一忽略路径，*匹配任意字段或数组元素
+func matchesAnyPath(path []string, patterns [][]string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// formatJSONPath 将字段路径格式化为点分隔形式，根节点为$
+func formatJSONPath(path []string) string {
+	if len(path) == 0 {
+		return "$"
+	}
+	return strings.Join(path, ".")
+}
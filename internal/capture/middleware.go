@@ -0,0 +1,125 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"apihub/internal/auth/apikey"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limitedBuffer 最多保存limit字节的缓冲区，超出部分丢弃并标记为截断
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write 写入缓冲区，始终返回len(p)
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// teeReadCloser 读取请求体的同时保存到缓冲区
+type teeReadCloser struct {
+	io.ReadCloser
+	buf *limitedBuffer
+}
+
+// Read 读取请求体并保存已读取的内容
+func (r *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// bodyWriter 写入响应的同时保存响应体
+type bodyWriter struct {
+	gin.ResponseWriter
+	buf *limitedBuffer
+}
+
+// Write 写入响应体
+func (w *bodyWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应体
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.buf.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware 请求/响应抓取中间件
+// 应放在认证之后，以便按API密钥匹配规则；未命中规则或未被采样的请求不做任何处理
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceName := c.Param("service")
+		var apiKeyID int
+		if apiKey, exists := apikey.GetAPIKey(c); exists {
+			apiKeyID = apiKey.ID
+		}
+
+		rule := r.match(serviceName, apiKeyID)
+		if rule == nil || !rule.sampled() {
+			c.Next()
+			return
+		}
+
+		requestBody := &limitedBuffer{limit: rule.maxBodyBytes}
+		body := &teeReadCloser{ReadCloser: c.Request.Body, buf: requestBody}
+		c.Request.Body = body
+
+		responseBody := &limitedBuffer{limit: rule.maxBodyBytes}
+		c.Writer = &bodyWriter{ResponseWriter: c.Writer, buf: responseBody}
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start)
+
+		// 服务未读取或被拒绝的请求补读请求体，最多多读一个字节用于判断是否截断
+		if !requestBody.truncated {
+			io.Copy(io.Discard, io.LimitReader(body, int64(rule.maxBodyBytes-requestBody.buf.Len()+1)))
+		}
+
+		userID, _ := middleware.GetCurrentUserID(c)
+		r.enqueue(&exchange{
+			rule: rule,
+			meta: &model.CapturedExchange{
+				RuleID:            rule.rule.ID,
+				RequestID:         middleware.GetRequestID(c),
+				ServiceName:       serviceName,
+				UserID:            userID,
+				APIKeyID:          apiKeyID,
+				Method:            c.Request.Method,
+				Endpoint:          c.Request.URL.Path,
+				Status:            c.Writer.Status(),
+				DurationMs:        duration.Milliseconds(),
+				RequestTruncated:  requestBody.truncated,
+				ResponseTruncated: responseBody.truncated,
+				CreatedAt:         time.Now(),
+			},
+			query:           c.Request.URL.RawQuery,
+			requestHeaders:  c.Request.Header.Clone(),
+			requestBody:     requestBody.buf.Bytes(),
+			responseHeaders: c.Writer.Header().Clone(),
+			responseBody:    responseBody.buf.Bytes(),
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apihub/internal/auth/crypto"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// ErrNotReplayable 抓取记录没有保存重放请求
+var ErrNotReplayable = errors.New("抓取记录不可重放：规则未开启重放或请求体被截断")
+
+// Config 请求/响应抓取配置
+type Config struct {
+	Enabled             bool          // 是否允许抓取，关闭时忽略所有抓取规则
+	QueueSize           int           // 待写入队列容量，队列满时丢弃新的抓取记录
+	DefaultMaxBodyBytes int           // 规则未设置时请求体和响应体各自最多保存的字节数
+	MaxBodyBytes        int           // 规则可设置的最大保存字节数
+	DefaultRetention    time.Duration // 规则未设置时抓取记录的保留时间
+	MaxRetention        time.Duration // 规则可设置的最长保留时间
+}
+
+// Stats 抓取统计信息
+type Stats struct {
+	Rules    int   `json:"rules"`    // 已加载的启用规则数
+	Captured int64 `json:"captured"` // 已写入的抓取记录数
+	Dropped  int64 `json:"dropped"`  // 因队列满或已关闭被丢弃的记录数
+	Failed   int64 `json:"failed"`   // 脱敏、加密或写入失败的记录数
+}
+
+// compiledRule 编译后的抓取规则
+type compiledRule struct {
+	rule         *model.CaptureRule
+	redactor     *Redactor
+	maxBodyBytes int
+	retention    time.Duration
+}
+
+// sampled 按采样率决定本次请求是否抓取
+func (r *compiledRule) sampled() bool {
+	return r.rule.SampleRate >= 1 || rand.Float64() < r.rule.SampleRate
+}
+
+// exchange 待写入的原始抓取内容，由后台协程脱敏、加密后写入
+type exchange struct {
+	rule            *compiledRule
+	meta            *model.CapturedExchange
+	query           string
+	requestHeaders  http.Header
+	requestBody     []byte
+	responseHeaders http.Header
+	responseBody    []byte
+}
+
+// Recorder 请求/响应抓取记录器
+// 规则保存在数据库中，内存中缓存编译后的规则，规则变更后需调用Reload。
+// 抓取内容放入有界队列，由后台协程脱敏、加密后写入，不阻塞请求处理
+type Recorder struct {
+	store  store.Store
+	crypto crypto.CryptoService
+	config Config
+
+	mu    sync.RWMutex
+	rules map[string]*compiledRule // 作用范围:目标 -> 规则
+
+	queue   chan *exchange
+	qmu     sync.RWMutex // 保护closed与关闭队列之间的竞争
+	closed  bool
+	done    chan struct{}
+	started bool
+
+	captured atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewRecorder 创建请求/响应抓取记录器
+func NewRecorder(store store.Store, cryptoService crypto.CryptoService, config Config) *Recorder {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = 256 * 1024
+	}
+	if config.DefaultMaxBodyBytes <= 0 || config.DefaultMaxBodyBytes > config.MaxBodyBytes {
+		config.DefaultMaxBodyBytes = min(16*1024, config.MaxBodyBytes)
+	}
+	if config.MaxRetention <= 0 {
+		config.MaxRetention = 7 * 24 * time.Hour
+	}
+	if config.DefaultRetention <= 0 || config.DefaultRetention > config.MaxRetention {
+		config.DefaultRetention = min(24*time.Hour, config.MaxRetention)
+	}
+
+	return &Recorder{
+		store:  store,
+		crypto: cryptoService,
+		config: config,
+		rules:  make(map[string]*compiledRule),
+		queue:  make(chan *exchange, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// captureRuleKey 生成规则的索引键
+func captureRuleKey(scope, target string) string {
+	return scope + ":" + target
+}
+
+// Validate 校验抓取规则的取值范围和脱敏规则
+func (r *Recorder) Validate(rule *model.CaptureRule) error {
+	if rule.MaxBodyBytes > r.config.MaxBodyBytes {
+		return fmt.Errorf("最大保存字节数不能超过%d", r.config.MaxBodyBytes)
+	}
+	if maxHours := int(r.config.MaxRetention / time.Hour); rule.RetentionHours > maxHours {
+		return fmt.Errorf("保留时间不能超过%d小时", maxHours)
+	}
+	_, err := NewRedactor(rule)
+	return err
+}
+
+// Reload 从数据库重新加载抓取规则
+func (r *Recorder) Reload(ctx context.Context) error {
+	rules, err := r.store.CaptureRules().List(ctx, "", "")
+	if err != nil {
+		return fmt.Errorf("加载抓取规则失败: %w", err)
+	}
+
+	compiled := make(map[string]*compiledRule)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		redactor, err := NewRedactor(rule)
+		if err != nil {
+			slog.WarnContext(ctx, "忽略无效的抓取规则", "rule_id", rule.ID, "error", err)
+			continue
+		}
+
+		entry := &compiledRule{
+			rule:         rule,
+			redactor:     redactor,
+			maxBodyBytes: r.config.DefaultMaxBodyBytes,
+			retention:    r.config.DefaultRetention,
+		}
+		if rule.MaxBodyBytes > 0 {
+			entry.maxBodyBytes = min(rule.MaxBodyBytes, r.config.MaxBodyBytes)
+		}
+		if rule.RetentionHours > 0 {
+			entry.retention = min(time.Duration(rule.RetentionHours)*time.Hour, r.config.MaxRetention)
+		}
+		compiled[captureRuleKey(rule.Scope, rule.Target)] = entry
+	}
+
+	r.mu.Lock()
+	r.rules = compiled
+	r.mu.Unlock()
+
+	return nil
+}
+
+// StartReloadTask 启动定期重新加载规则的任务
+// 用于同步其他实例对规则的修改
+func (r *Recorder) StartReloadTask(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := r.Reload(ctx); err != nil {
+					slog.Error("定期加载抓取规则失败", "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// match 查找请求适用的抓取规则，API密钥级规则优先于服务级规则
+func (r *Recorder) match(serviceName string, apiKeyID int) *compiledRule {
+	if !r.config.Enabled {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.rules) == 0 {
+		return nil
+	}
+	if apiKeyID > 0 {
+		if rule, exists := r.rules[captureRuleKey(model.CaptureScopeAPIKey, strconv.Itoa(apiKeyID))]; exists {
+			return rule
+		}
+	}
+	return r.rules[captureRuleKey(model.CaptureScopeService, serviceName)]
+}
+
+// Start 启动后台写入协程
+func (r *Recorder) Start() {
+	r.started = true
+	go r.run()
+}
+
+// enqueue 将抓取内容放入写入队列，队列已满或记录器已关闭时丢弃
+func (r *Recorder) enqueue(item *exchange) {
+	r.qmu.RLock()
+	defer r.qmu.RUnlock()
+
+	if r.closed {
+		r.dropped.Add(1)
+		return
+	}
+
+	select {
+	case r.queue <- item:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Stats 获取抓取统计信息
+func (r *Recorder) Stats() Stats {
+	r.mu.RLock()
+	rules := len(r.rules)
+	r.mu.RUnlock()
+
+	return Stats{
+		Rules:    rules,
+		Captured: r.captured.Load(),
+		Dropped:  r.dropped.Load(),
+		Failed:   r.failed.Load(),
+	}
+}
+
+// Close 停止接收新的抓取内容并写入队列中剩余的记录
+func (r *Recorder) Close(ctx context.Context) error {
+	r.qmu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.qmu.Unlock()
+
+	if !r.started {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待抓取记录写入完成超时，剩余%d条: %w", len(r.queue), ctx.Err())
+	}
+}
+
+// run 后台写入循环
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	for item := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := r.write(ctx, item); err != nil {
+			r.failed.Add(1)
+			slog.ErrorContext(ctx, "写入抓取记录失败",
+				"rule_id", item.rule.rule.ID, "request_id", item.meta.RequestID, "error", err)
+		} else {
+			r.captured.Add(1)
+		}
+		cancel()
+	}
+}
+
+// write 脱敏、加密并写入一条抓取记录
+func (r *Recorder) write(ctx context.Context, item *exchange) error {
+	redactor := item.rule.redactor
+	payload := &model.CapturePayload{
+		Query:           redactor.Query(item.query),
+		RequestHeaders:  redactor.Headers(item.requestHeaders),
+		RequestBody:     redactor.Body(item.requestBody),
+		ResponseHeaders: redactor.Headers(item.responseHeaders),
+		ResponseBody:    redactor.Body(item.responseBody),
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化抓取内容失败: %w", err)
+	}
+	encrypted, err := r.crypto.Encrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("加密抓取内容失败: %w", err)
+	}
+
+	meta := item.meta
+	meta.Payload = encrypted
+	meta.ExpiresAt = meta.CreatedAt.Add(item.rule.retention)
+
+	// 请求体被截断时无法原样重放，不保存重放请求
+	if item.rule.rule.Replayable && !meta.RequestTruncated {
+		replay, err := r.encryptReplay(item)
+		if err != nil {
+			return err
+		}
+		meta.ReplayPayload = replay
+	}
+
+	return r.store.Captures().Create(ctx, meta)
+}
+
+// encryptReplay 加密保存用于重放的原始请求，请求体不脱敏，认证相关请求头不保存
+func (r *Recorder) encryptReplay(item *exchange) (string, error) {
+	data, err := json.Marshal(&model.ReplayRequest{
+		Method:  item.meta.Method,
+		Query:   item.query,
+		Headers: item.rule.redactor.StripHeaders(item.requestHeaders),
+		Body:    item.requestBody,
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化重放请求失败: %w", err)
+	}
+	encrypted, err := r.crypto.Encrypt(string(data))
+	if err != nil {
+		return "", fmt.Errorf("加密重放请求失败: %w", err)
+	}
+	return encrypted, nil
+}
+
+// Decrypt 解密抓取记录的内容
+func (r *Recorder) Decrypt(exchange *model.CapturedExchange) (*model.CapturePayload, error) {
+	data, err := r.crypto.Decrypt(exchange.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("解密抓取内容失败: %w", err)
+	}
+
+	payload := &model.CapturePayload{}
+	if err := json.Unmarshal([]byte(data), payload); err != nil {
+		return nil, fmt.Errorf("解析抓取内容失败: %w", err)
+	}
+	return payload, nil
+}
+
+// DecryptReplay 解密抓取记录中用于重放的原始请求
+func (r *Recorder) DecryptReplay(exchange *model.CapturedExchange) (*model.ReplayRequest, error) {
+	if exchange.ReplayPayload == "" {
+		return nil, ErrNotReplayable
+	}
+
+	data, err := r.crypto.Decrypt(exchange.ReplayPayload)
+	if err != nil {
+		return nil, fmt.Errorf("解密重放请求失败: %w", err)
+	}
+
+	request := &model.ReplayRequest{}
+	if err := json.Unmarshal([]byte(data), request); err != nil {
+		return nil, fmt.Errorf("解析重放请求失败: %w", err)
+	}
+	return request, nil
+}
+
+// Redactor 获取抓取记录所属规则的脱敏器，规则已删除时只应用默认脱敏规则
+func (r *Recorder) Redactor(ctx context.Context, exchange *model.CapturedExchange) (*Redactor, error) {
+	rule, err := r.store.CaptureRules().GetByID(ctx, exchange.RuleID)
+	if err != nil {
+		var dbErr *store.DBError
+		if !errors.As(err, &dbErr) || dbErr.Code != store.ErrNotFound {
+			return nil, err
+		}
+		rule = &model.CaptureRule{}
+	}
+	return NewRedactor(rule)
+}
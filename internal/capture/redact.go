@@ -0,0 +1,236 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"apihub/internal/audit"
+	"apihub/internal/model"
+)
+
+// defaultRedactHeaders 始终脱敏的请求头和响应头
+var defaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-API-Key",
+}
+
+// defaultRedactFields 始终脱敏的JSON字段名和查询参数名，不区分大小写，匹配任意层级
+var defaultRedactFields = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"api_key":       true,
+	"apikey":        true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+// defaultRedactPatterns 始终脱敏的内容
+var defaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`),
+}
+
+// Redactor 按抓取规则对请求和响应内容脱敏
+type Redactor struct {
+	headers  map[string]bool
+	paths    [][]string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor 根据抓取规则创建脱敏器，规则中的正则表达式或JSON路径无效时返回错误
+func NewRedactor(rule *model.CaptureRule) (*Redactor, error) {
+	r := &Redactor{
+		headers:  make(map[string]bool),
+		patterns: append([]*regexp.Regexp(nil), defaultRedactPatterns...),
+	}
+
+	for _, name := range defaultRedactHeaders {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range rule.RedactHeaders {
+		r.headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+
+	for _, path := range rule.RedactJSONPaths {
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, segments)
+	}
+
+	for _, pattern := range rule.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的脱敏正则表达式 %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// parseJSONPath 解析以点分隔的JSON字段路径，*匹配任意字段或数组元素，可带$.前缀
+func parseJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("无效的JSON路径: %q", path)
+	}
+
+	segments := strings.Split(trimmed, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("无效的JSON路径: %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// Headers 返回脱敏后的请求头或响应头副本
+func (r *Redactor) Headers(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{audit.Redacted}
+			continue
+		}
+		copied := make([]string, len(values))
+		for i, value := range values {
+			copied[i] = r.text(value)
+		}
+		redacted[name] = copied
+	}
+	return redacted
+}
+
+// StripHeaders 返回去掉需脱敏请求头后的副本，用于保存重放请求
+func (r *Redactor) StripHeaders(header http.Header) http.Header {
+	stripped := make(http.Header, len(header))
+	for name, values := range header {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		stripped[name] = append([]string(nil), values...)
+	}
+	return stripped
+}
+
+// Query 返回脱敏后的查询字符串
+func (r *Redactor) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return r.text(rawQuery)
+	}
+	for name, list := range values {
+		for i := range list {
+			if defaultRedactFields[strings.ToLower(name)] {
+				list[i] = audit.Redacted
+			} else {
+				list[i] = r.text(list[i])
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// Body 返回脱敏后的请求体或响应体
+// JSON内容按字段路径和默认敏感字段名脱敏后再应用正则表达式；
+// 形似JSON但无法解析（如被截断）时无法可靠地按字段脱敏，不保存内容
+func (r *Redactor) Body(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("[二进制内容已省略，共%d字节]", len(body))
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+
+		var doc any
+		if err := decoder.Decode(&doc); err != nil || decoder.More() {
+			return fmt.Sprintf("[无法解析的JSON内容已省略，共%d字节]", len(body))
+		}
+
+		doc = redactFields(doc)
+		for _, path := range r.paths {
+			doc = redactPath(doc, path)
+		}
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Sprintf("[无法解析的JSON内容已省略，共%d字节]", len(body))
+		}
+		return r.text(strings.TrimSuffix(buf.String(), "\n"))
+	}
+
+	return r.text(string(body))
+}
+
+// text 对文本应用脱敏正则表达式
+func (r *Redactor) text(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, audit.Redacted)
+	}
+	return s
+}
+
+// redactFields 替换任意层级中的默认敏感字段
+func redactFields(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if defaultRedactFields[strings.ToLower(key)] {
+				v[key] = audit.Redacted
+			} else {
+				v[key] = redactFields(child)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactFields(child)
+		}
+	}
+	return node
+}
+
+// redactPath 替换路径匹配的字段
+func redactPath(node any, path []string) any {
+	if len(path) == 0 {
+		return audit.Redacted
+	}
+
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = redactPath(child, path[1:])
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				v[i] = redactPath(child, path[1:])
+			}
+		}
+	}
+	return node
+}
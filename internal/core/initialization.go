@@ -5,7 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -28,19 +28,19 @@ func NewInitializationService(store store.Store) *InitializationService {
 
 // InitializeSystem 初始化系统
 func (s *InitializationService) InitializeSystem(ctx context.Context) error {
-	log.Println("开始系统初始化...")
+	slog.InfoContext(ctx, "开始系统初始化")
 
 	// 1. 连接数据库
 	if err := s.store.Connect(); err != nil {
 		return fmt.Errorf("数据库连接失败: %w", err)
 	}
-	log.Println("数据库连接成功")
+	slog.InfoContext(ctx, "数据库连接成功")
 
 	// 2. 执行数据库迁移
 	if err := s.store.Migrate(); err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
 	}
-	log.Println("数据库迁移完成")
+	slog.InfoContext(ctx, "数据库迁移完成")
 
 	// 3. 检查系统是否已初始化
 	initialized, err := s.isSystemInitialized(ctx)
@@ -49,7 +49,7 @@ func (s *InitializationService) InitializeSystem(ctx context.Context) error {
 	}
 
 	if initialized {
-		log.Println("系统已初始化，跳过初始化步骤")
+		slog.InfoContext(ctx, "系统已初始化，跳过初始化步骤")
 		return nil
 	}
 
@@ -58,26 +58,26 @@ func (s *InitializationService) InitializeSystem(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("创建默认管理员失败: %w", err)
 	}
-	log.Printf("默认管理员创建成功: %s", adminUser.Username)
+	slog.InfoContext(ctx, "默认管理员创建成功", "username", adminUser.Username)
 
 	// 5. 生成JWT密钥
 	if err := s.generateJWTSecret(ctx); err != nil {
 		return fmt.Errorf("生成JWT密钥失败: %w", err)
 	}
-	log.Println("JWT密钥生成完成")
+	slog.InfoContext(ctx, "JWT密钥生成完成")
 
 	// 6. 生成APIKey密钥
 	if err := s.generateAPIKeySecret(ctx); err != nil {
 		return fmt.Errorf("生成APIKey密钥失败: %w", err)
 	}
-	log.Println("APIKey密钥生成完成")
+	slog.InfoContext(ctx, "APIKey密钥生成完成")
 
 	// 7. 标记系统已初始化
 	if err := s.markSystemInitialized(ctx); err != nil {
 		return fmt.Errorf("标记系统初始化失败: %w", err)
 	}
 
-	log.Println("系统初始化完成")
+	slog.InfoContext(ctx, "系统初始化完成")
 	return nil
 }
 
@@ -132,10 +132,10 @@ func (s *InitializationService) createDefaultAdmin(ctx context.Context) (*model.
 	}
 
 	// 输出默认密码到日志（仅用于开发环境）
-	log.Printf("默认管理员账号创建成功:")
-	log.Printf("用户名: %s", admin.Username)
-	log.Printf("密码: %s", defaultPassword)
-	log.Printf("请及时修改默认密码！")
+	slog.WarnContext(ctx, "默认管理员账号创建成功，请及时修改默认密码！",
+		"username", admin.Username,
+		"password", defaultPassword,
+	)
 
 	return admin, nil
 }
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"apihub/internal/dashboard/service"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导出格式常量
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery 导出时每写入多少条刷新一次响应
+const exportFlushEvery = 200
+
+// accessLogCSVHeader CSV导出表头
+var accessLogCSVHeader = []string{
+	"id", "created_at", "request_id", "user_id", "api_key_id", "auth_method",
+	"service_name", "method", "endpoint", "status", "error_code", "cost",
+	"duration_ms", "client_ip", "user_agent", "request_size", "response_size",
+}
+
+// AccessLogHandler 访问日志处理器
+type AccessLogHandler struct {
+	accessLogService *service.AccessLogService
+}
+
+// NewAccessLogHandler 创建访问日志处理器实例
+func NewAccessLogHandler(accessLogService *service.AccessLogService) *AccessLogHandler {
+	return &AccessLogHandler{
+		accessLogService: accessLogService,
+	}
+}
+
+// ExportAccessLogsRequest 导出访问日志请求
+type ExportAccessLogsRequest struct {
+	model.AccessLogQueryRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+}
+
+// ListLogs 分页查询访问日志
+// @Summary 查询访问日志
+// @Description 按时间范围、用户、密钥、服务、状态码分类和端点前缀查询访问日志，使用游标分页。普通用户只能查看自己的日志
+// @Tags 访问日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param start_time query string false "开始时间（RFC3339，包含）"
+// @Param end_time query string false "结束时间（RFC3339，不包含）"
+// @Param user_id query int false "用户ID（仅管理员可指定其他用户）"
+// @Param api_key_id query int false "API密钥ID"
+// @Param service_name query string false "服务名称"
+// @Param endpoint query string false "端点前缀"
+// @Param status_class query string false "状态码分类" Enums(2xx, 3xx, 4xx, 5xx)
+// @Param cursor query int false "游标，取上一页返回的next_cursor"
+// @Param limit query int false "每页条数，默认50" minimum(1) maximum(500)
+// @Success 200 {object} model.APIResponse{data=model.AccessLogListResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/accesslogs/list [get]
+func (h *AccessLogHandler) ListLogs(c *gin.Context) {
+	var req model.AccessLogQueryRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	response, err := h.accessLogService.QueryLogs(c.Request.Context(), userID, isAdmin, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrAccessLogForbidden) {
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(
+				model.CodeForbidden,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"查询访问日志失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// ExportLogs 流式导出访问日志
+// @Summary 导出访问日志
+// @Description 按查询条件流式导出访问日志，支持CSV和NDJSON格式，适用于大时间范围。普通用户只能导出自己的日志
+// @Tags 访问日志
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "导出格式，默认ndjson" Enums(csv, ndjson)
+// @Param start_time query string false "开始时间（RFC3339，包含）"
+// @Param end_time query string false "结束时间（RFC3339，不包含）"
+// @Param user_id query int false "用户ID（仅管理员可指定其他用户）"
+// @Param api_key_id query int false "API密钥ID"
+// @Param service_name query string false "服务名称"
+// @Param endpoint query string false "端点前缀"
+// @Param status_class query string false "状态码分类" Enums(2xx, 3xx, 4xx, 5xx)
+// @Success 200 {file} file
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/accesslogs/export [get]
+func (h *AccessLogHandler) ExportLogs(c *gin.Context) {
+	var req ExportAccessLogsRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	if !isAdmin && filter.UserID != 0 && filter.UserID != userID {
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(
+			model.CodeForbidden,
+			service.ErrAccessLogForbidden.Error(),
+		))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = ExportFormatNDJSON
+	}
+
+	// 大范围导出耗时较长，取消服务器的写超时
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(c.Request.Context(), "取消导出写超时失败", "error", err)
+	}
+
+	filename := fmt.Sprintf("access_logs_%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	if format == ExportFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	write := newAccessLogEncoder(c.Writer, format)
+	count := 0
+	err = h.accessLogService.ExportLogs(c.Request.Context(), userID, isAdmin, filter, func(accessLog *model.AccessLog) error {
+		if err := write(accessLog); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = write(nil)
+	}
+	c.Writer.Flush()
+
+	// 响应头已发送，出错时只能中断输出并记录日志
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "导出访问日志中断", "exported", count, "error", err)
+	}
+}
+
+// newAccessLogEncoder 创建访问日志编码函数
+// 传入nil表示导出结束
+func newAccessLogEncoder(w io.Writer, format string) func(*model.AccessLog) error {
+	if format != ExportFormatCSV {
+		encoder := json.NewEncoder(w)
+		return func(accessLog *model.AccessLog) error {
+			if accessLog == nil {
+				return nil
+			}
+			return encoder.Encode(accessLog)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	headerWritten := false
+	return func(accessLog *model.AccessLog) error {
+		if !headerWritten {
+			headerWritten = true
+			if err := writer.Write(accessLogCSVHeader); err != nil {
+				return err
+			}
+		}
+
+		if accessLog == nil {
+			writer.Flush()
+			return writer.Error()
+		}
+
+		record := []string{
+			strconv.Itoa(accessLog.ID),
+			accessLog.CreatedAt.Format(time.RFC3339Nano),
+			accessLog.RequestID,
+			strconv.Itoa(accessLog.UserID),
+			strconv.Itoa(accessLog.APIKeyID),
+			accessLog.AuthMethod,
+			accessLog.ServiceName,
+			accessLog.Method,
+			accessLog.Endpoint,
+			strconv.Itoa(accessLog.Status),
+			strconv.Itoa(accessLog.ErrorCode),
+			strconv.Itoa(accessLog.Cost),
+			strconv.FormatInt(accessLog.DurationMs, 10),
+			accessLog.ClientIP,
+			accessLog.UserAgent,
+			strconv.FormatInt(accessLog.RequestSize, 10),
+			strconv.FormatInt(accessLog.ResponseSize, 10),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		// csv.Writer自带缓冲，刷新后才能写到响应
+		writer.Flush()
+		return writer.Error()
+	}
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"apihub/internal/auth/apikey"
+	"apihub/internal/middleware"
 	"apihub/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -24,12 +27,12 @@ func NewAPIKeyHandler(apiKeyService *apikey.APIKeyService) *APIKeyHandler {
 
 // ListAPIKeys 列出当前用户的所有API密钥
 // @Summary 列出API密钥
-// @Description 列出当前用户的所有API密钥
+// @Description 列出当前用户的所有API密钥，只返回密钥前缀
 // @Tags API密钥
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} model.APIResponse{data=[]model.APIKey}
+// @Success 200 {object} model.APIResponse{data=[]model.APIKeyResponse}
 // @Failure 401 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /dashboard/apikeys/list [get]
@@ -63,20 +66,27 @@ func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
 		return
 	}
 
-	// 返回API密钥列表
-	c.JSON(http.StatusOK, model.NewSuccessResponse(apiKeys))
+	// 返回API密钥列表，只包含密钥前缀
+	responses := make([]*model.APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		responses = append(responses, apiKey.ToResponse())
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(responses))
 }
 
 // GenerateAPIKey 请求体
 type GenerateAPIKeyRequest struct {
-	Name        string     `json:"name" binding:"required"`
-	Description string     `json:"description"`
-	ExpiresAt   *time.Time `json:"expires_at"`
+	Name         string                   `json:"name" binding:"required"`
+	Description  string                   `json:"description"`
+	ExpiresAt    *time.Time               `json:"expires_at"`
+	Scopes       []string                 `json:"scopes" binding:"max=50"` // 为空时默认为service:*
+	Restrictions model.APIKeyRestrictions `json:"restrictions"`            // 使用限制，为空时不限制
 }
 
 // GenerateAPIKey 为当前用户生成新的API密钥
 // @Summary 生成API密钥
-// @Description 为当前用户生成新的API密钥
+// @Description 为当前用户生成新的API密钥，完整密钥只在本次响应中返回，之后无法再次查看。
+// @Description scopes可包含service:<服务名称>、service:*和dashboard:read，不能超出用户自身权限，为空时默认为service:*
 // @Tags API密钥
 // @Accept json
 // @Produce json
@@ -85,6 +95,7 @@ type GenerateAPIKeyRequest struct {
 // @Success 200 {object} model.APIResponse{data=model.APIKey}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /dashboard/apikeys/generate [post]
 func (h *APIKeyHandler) GenerateAPIKey(c *gin.Context) {
@@ -119,8 +130,11 @@ func (h *APIKeyHandler) GenerateAPIKey(c *gin.Context) {
 	}
 
 	// 生成API密钥
-	apiKey, err := h.apiKeyService.CreateAPIKey(userID, req.Name, req.Description, req.ExpiresAt, nil)
+	apiKey, err := h.apiKeyService.CreateAPIKey(auditContext(c), userID, req.Name, req.Description, req.ExpiresAt, req.Scopes, req.Restrictions)
 	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
 			model.CodeInternalError,
 			"生成API密钥失败: "+err.Error(),
@@ -128,7 +142,7 @@ func (h *APIKeyHandler) GenerateAPIKey(c *gin.Context) {
 		return
 	}
 
-	// 返回生成的API密钥
+	// 返回生成的API密钥，包含仅此一次可见的完整密钥
 	c.JSON(http.StatusOK, model.NewSuccessResponse(apiKey))
 }
 
@@ -210,7 +224,7 @@ func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
 	}
 
 	// 删除API密钥
-	if err := h.apiKeyService.DeleteAPIKey(req.APIKeyID); err != nil {
+	if err := h.apiKeyService.DeleteAPIKey(auditContext(c), req.APIKeyID); err != nil {
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
 			model.CodeInternalError,
 			"删除API密钥失败: "+err.Error(),
@@ -223,3 +237,391 @@ func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
 		"message": "API密钥删除成功",
 	}))
 }
+
+// UpdateAPIKeyScopes 更新当前用户API密钥的权限范围
+// @Summary 更新API密钥权限范围
+// @Description 更新当前用户指定API密钥的权限范围，不能超出用户自身权限，立即生效
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateAPIKeyScopesRequest true "更新权限范围请求"
+// @Success 200 {object} model.APIResponse{data=model.APIKeyResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/update-scopes [post]
+func (h *APIKeyHandler) UpdateAPIKeyScopes(c *gin.Context) {
+	var req model.UpdateAPIKeyScopesRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	apiKey, err := h.apiKeyService.UpdateAPIKeyScopes(auditContext(c), userID, req.APIKeyID, req.Scopes)
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"更新API密钥权限范围失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(apiKey.ToResponse()))
+}
+
+// UpdateAPIKeyRestrictions 更新当前用户API密钥的使用限制
+// @Summary 更新API密钥使用限制
+// @Description 更新当前用户指定API密钥的请求来源、使用时间段和最大使用次数，立即生效；restrictions为空对象时清除所有限制；允许的网段通过IP访问规则设置
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateAPIKeyRestrictionsRequest true "更新使用限制请求"
+// @Success 200 {object} model.APIResponse{data=model.APIKeyResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/update-restrictions [post]
+func (h *APIKeyHandler) UpdateAPIKeyRestrictions(c *gin.Context) {
+	var req model.UpdateAPIKeyRestrictionsRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	apiKey, err := h.apiKeyService.UpdateAPIKeyRestrictions(auditContext(c), userID, req.APIKeyID, req.Restrictions)
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"更新API密钥使用限制失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(apiKey.ToResponse()))
+}
+
+// RotateAPIKey 轮换当前用户的API密钥
+// @Summary 轮换API密钥
+// @Description 为当前用户指定的API密钥创建继任密钥，继承名称、权限范围、使用限制、过期时间和IP访问规则。
+// @Description 旧密钥在宽限期内继续有效，之后自动过期；grace_minutes为空时使用默认宽限期，为0时旧密钥立即失效。
+// @Description 完整的新密钥只在本次响应中返回
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.RotateAPIKeyRequest true "轮换API密钥请求"
+// @Success 200 {object} model.APIResponse{data=model.RotateAPIKeyResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	var req model.RotateAPIKeyRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	var grace *time.Duration
+	if req.GraceMinutes != nil {
+		duration := time.Duration(*req.GraceMinutes) * time.Minute
+		grace = &duration
+	}
+
+	newKey, oldKey, err := h.apiKeyService.RotateAPIKey(auditContext(c), userID, req.APIKeyID, grace)
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.RotateAPIKeyResponse{
+		NewKey: newKey,
+		OldKey: oldKey.ToResponse(),
+	}))
+}
+
+// GetRotationStatus 获取当前用户API密钥的轮换状态
+// @Summary 获取API密钥轮换状态
+// @Description 获取旧密钥和继任密钥、宽限期结束时间，以及轮换以来两个密钥各自的调用次数、最后调用时间和客户端IP，
+// @Description 用于确认调用方是否已切换到新密钥。api_key_id可以是旧密钥或继任密钥的ID
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param api_key_id query int true "API密钥ID"
+// @Success 200 {object} model.APIResponse{data=model.APIKeyRotationStatus}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/rotation-status [get]
+func (h *APIKeyHandler) GetRotationStatus(c *gin.Context) {
+	apiKeyID, err := strconv.Atoi(c.Query("api_key_id"))
+	if err != nil || apiKeyID <= 0 {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: 无效的api_key_id",
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	status, err := h.apiKeyService.GetRotationStatus(c.Request.Context(), userID, apiKeyID)
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取API密钥轮换状态失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(status))
+}
+
+// GetAPIKeyUsage 获取当前用户API密钥的使用情况
+// @Summary 获取API密钥使用情况
+// @Description 获取密钥的最后使用时间和IP，以及最近24小时、7天、30天的调用次数和最近30天每天的调用情况。
+// @Description 24小时和7天按小时汇总滚动计算，30天按UTC自然日汇总；启用了自动禁用时返回继续不使用将被禁用的时间
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param api_key_id query int true "API密钥ID"
+// @Success 200 {object} model.APIResponse{data=model.APIKeyUsage}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/usage [get]
+func (h *APIKeyHandler) GetAPIKeyUsage(c *gin.Context) {
+	apiKeyID, err := strconv.Atoi(c.Query("api_key_id"))
+	if err != nil || apiKeyID <= 0 {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: 无效的api_key_id",
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	usage, err := h.apiKeyService.GetAPIKeyUsage(c.Request.Context(), userID, apiKeyID, time.Now())
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取API密钥使用情况失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(usage))
+}
+
+// SetSigningSecret 为当前用户的API密钥生成签名密钥
+// @Summary 生成API密钥签名密钥
+// @Description 为当前用户指定的API密钥生成签名密钥，启用请求签名认证，已有的签名密钥立即失效。
+// @Description 访问密钥即API密钥的前缀，签名密钥只在本次响应中返回
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.APIKeySigningRequest true "生成签名密钥请求"
+// @Success 200 {object} model.APIResponse{data=model.APIKeySigningSecretResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/signing-secret [post]
+func (h *APIKeyHandler) SetSigningSecret(c *gin.Context) {
+	var req model.APIKeySigningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	result, err := h.apiKeyService.SetSigningSecret(auditContext(c), userID, req.APIKeyID)
+	if err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// DeleteSigningSecret 删除当前用户的API密钥的签名密钥
+// @Summary 删除API密钥签名密钥
+// @Description 删除当前用户指定的API密钥的签名密钥，之后该密钥不能再用于请求签名认证，不影响直接使用API密钥调用
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.APIKeySigningRequest true "删除签名密钥请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/apikeys/delete-signing-secret [post]
+func (h *APIKeyHandler) DeleteSigningSecret(c *gin.Context) {
+	var req model.APIKeySigningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	if err := h.apiKeyService.DeleteSigningSecret(auditContext(c), userID, req.APIKeyID); err != nil {
+		if respondAPIKeyError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(nil))
+}
+
+// respondAPIKeyError 处理API密钥操作的业务错误，已处理时返回true
+func respondAPIKeyError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, apikey.ErrInvalidScope), errors.Is(err, apikey.ErrInvalidRestrictions):
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return true
+	case errors.Is(err, apikey.ErrScopeNotPermitted):
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(
+			model.CodeForbidden,
+			err.Error(),
+		))
+		return true
+	case errors.Is(err, apikey.ErrAPIKeyNotFound), errors.Is(err, apikey.ErrAPIKeyNotRotated):
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			err.Error(),
+		))
+		return true
+	case errors.Is(err, apikey.ErrAPIKeyInactive), errors.Is(err, apikey.ErrAPIKeyRotated),
+		errors.Is(err, apikey.ErrGracePeriodTooLong), errors.Is(err, apikey.ErrLegacyKeyNoSigning):
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditLogCSVHeader CSV导出表头
+var auditLogCSVHeader = []string{
+	"id", "created_at", "actor_id", "actor_name", "action", "target_type", "target_id",
+	"before", "after", "client_ip", "request_id", "prev_hash", "hash",
+}
+
+// auditContext 返回带有当前操作人信息的请求context，供需要记录审计日志的服务使用
+func auditContext(c *gin.Context) context.Context {
+	actor := audit.Actor{ClientIP: c.ClientIP()}
+	if userID, ok := middleware.GetCurrentUserID(c); ok {
+		actor.UserID = userID
+	}
+	if username, ok := middleware.GetCurrentUsername(c); ok {
+		actor.Username = username
+	}
+	return audit.WithActor(c.Request.Context(), actor)
+}
+
+// AuditLogHandler 审计日志处理器
+type AuditLogHandler struct {
+	auditLogService *service.AuditLogService
+}
+
+// NewAuditLogHandler 创建审计日志处理器实例
+func NewAuditLogHandler(auditLogService *service.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditLogService: auditLogService,
+	}
+}
+
+// ExportAuditLogsRequest 导出审计日志请求
+type ExportAuditLogsRequest struct {
+	model.AuditLogQueryRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+}
+
+// ListLogs 分页查询审计日志
+// @Summary 查询审计日志
+// @Description 按时间范围、操作人、操作类型和操作对象查询审计日志，使用游标分页
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param start_time query string false "开始时间（RFC3339，包含）"
+// @Param end_time query string false "结束时间（RFC3339，不包含）"
+// @Param actor_id query int false "操作人用户ID"
+// @Param action query string false "操作类型，如user.delete"
+// @Param target_type query string false "操作对象类型" Enums(user, apikey, iprule, ratelimit, capture_rule, capture, signing_key, session)
+// @Param target_id query string false "操作对象ID"
+// @Param cursor query int false "游标，取上一页返回的next_cursor"
+// @Param limit query int false "每页条数，默认50" minimum(1) maximum(500)
+// @Success 200 {object} model.APIResponse{data=model.AuditLogListResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/auditlogs/list [get]
+func (h *AuditLogHandler) ListLogs(c *gin.Context) {
+	var req model.AuditLogQueryRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	response, err := h.auditLogService.QueryLogs(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"查询审计日志失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// ExportLogs 流式导出审计日志
+// @Summary 导出审计日志
+// @Description 按查询条件流式导出审计日志，支持CSV和NDJSON格式，导出内容包含哈希链字段可用于离线校验
+// @Tags 审计日志
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "导出格式，默认ndjson" Enums(csv, ndjson)
+// @Param start_time query string false "开始时间（RFC3339，包含）"
+// @Param end_time query string false "结束时间（RFC3339，不包含）"
+// @Param actor_id query int false "操作人用户ID"
+// @Param action query string false "操作类型，如user.delete"
+// @Param target_type query string false "操作对象类型" Enums(user, apikey, iprule, ratelimit, capture_rule, capture, signing_key, session)
+// @Param target_id query string false "操作对象ID"
+// @Success 200 {file} file
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/auditlogs/export [get]
+func (h *AuditLogHandler) ExportLogs(c *gin.Context) {
+	var req ExportAuditLogsRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = ExportFormatNDJSON
+	}
+
+	// 大范围导出耗时较长，取消服务器的写超时
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(c.Request.Context(), "取消导出写超时失败", "error", err)
+	}
+
+	filename := fmt.Sprintf("audit_logs_%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	if format == ExportFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	write := newAuditLogEncoder(c.Writer, format)
+	count := 0
+	err = h.auditLogService.ExportLogs(c.Request.Context(), filter, func(auditLog *model.AuditLog) error {
+		if err := write(auditLog); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = write(nil)
+	}
+	c.Writer.Flush()
+
+	// 响应头已发送，出错时只能中断输出并记录日志
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "导出审计日志中断", "exported", count, "error", err)
+	}
+}
+
+// VerifyChain 校验审计日志哈希链
+// @Summary 校验审计日志
+// @Description 从第一条记录开始校验哈希链，报告第一条被修改、删除或插入的记录。返回的last_hash可留存，用于发现末尾记录被截断
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=model.AuditVerifyResponse}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/auditlogs/verify [get]
+func (h *AuditLogHandler) VerifyChain(c *gin.Context) {
+	// 记录较多时校验耗时较长，取消服务器的写超时
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(c.Request.Context(), "取消校验写超时失败", "error", err)
+	}
+
+	result, err := h.auditLogService.VerifyChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"校验审计日志失败: "+err.Error(),
+		))
+		return
+	}
+
+	if !result.Valid {
+		slog.ErrorContext(c.Request.Context(), "审计日志哈希链校验失败",
+			"broken_id", result.BrokenID, "reason", result.Reason)
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// newAuditLogEncoder 创建审计日志编码函数
+// 传入nil表示导出结束
+func newAuditLogEncoder(w io.Writer, format string) func(*model.AuditLog) error {
+	if format != ExportFormatCSV {
+		encoder := json.NewEncoder(w)
+		return func(auditLog *model.AuditLog) error {
+			if auditLog == nil {
+				return nil
+			}
+			return encoder.Encode(auditLog)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	headerWritten := false
+	return func(auditLog *model.AuditLog) error {
+		if !headerWritten {
+			headerWritten = true
+			if err := writer.Write(auditLogCSVHeader); err != nil {
+				return err
+			}
+		}
+
+		if auditLog == nil {
+			writer.Flush()
+			return writer.Error()
+		}
+
+		record := []string{
+			strconv.Itoa(auditLog.ID),
+			auditLog.CreatedAt.UTC().Format(model.AuditTimeFormat),
+			strconv.Itoa(auditLog.ActorID),
+			auditLog.ActorName,
+			auditLog.Action,
+			auditLog.TargetType,
+			auditLog.TargetID,
+			string(auditLog.Before),
+			string(auditLog.After),
+			auditLog.ClientIP,
+			auditLog.RequestID,
+			auditLog.PrevHash,
+			auditLog.Hash,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		// csv.Writer自带缓冲，刷新后才能写到响应
+		writer.Flush()
+		return writer.Error()
+	}
+}
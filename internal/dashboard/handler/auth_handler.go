@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"apihub/internal/dashboard/service"
+	"apihub/internal/metrics"
 	"apihub/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -46,8 +48,9 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// 调用服务层处理登录
-	response, err := h.authService.Login(c.Request.Context(), &req)
+	response, err := h.authService.Login(auditContext(c), &req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
+		metrics.AuthFailed(metrics.AuthMethodPassword)
 		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
 			model.CodeInvalidCredentials,
 			err.Error(),
@@ -59,9 +62,55 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
 }
 
+// Refresh 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即失效；已使用的刷新令牌再次使用时撤销所属的会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshTokenRequest true "刷新令牌请求"
+// @Success 200 {object} model.APIResponse{data=model.LoginResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req model.RefreshTokenRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	// 调用服务层刷新令牌
+	response, err := h.authService.Refresh(auditContext(c), &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			metrics.AuthFailed(metrics.AuthMethodRefresh)
+			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+				model.CodeTokenInvalid,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"刷新令牌失败: "+err.Error(),
+		))
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
 // Logout 用户登出
 // @Summary 用户登出
-// @Description 用户登出，撤销JWT Token
+// @Description 用户登出，撤销当前会话，会话的访问令牌和刷新令牌立即失效
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -205,7 +254,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// 调用服务层更新个人资料
-	user, err := h.authService.UpdateProfile(c.Request.Context(), userID, &req)
+	user, err := h.authService.UpdateProfile(auditContext(c), userID, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -226,7 +275,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 // ChangePassword 修改密码
 // @Summary 修改密码
-// @Description 修改当前登录用户的密码
+// @Description 修改当前登录用户的密码，同时撤销该用户的所有会话
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -269,7 +318,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// 调用服务层修改密码
-	err := h.authService.ChangePassword(c.Request.Context(), userID, &req)
+	err := h.authService.ChangePassword(auditContext(c), userID, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -280,7 +329,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	// 构造响应
 	response := &model.ChangePasswordResponse{
-		Message: "密码修改成功",
+		Message: "密码修改成功，请重新登录",
 	}
 
 	// 返回成功响应
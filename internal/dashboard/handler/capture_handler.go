@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptureHandler 请求/响应抓取处理器
+type CaptureHandler struct {
+	captureService *service.CaptureService
+}
+
+// NewCaptureHandler 创建请求/响应抓取处理器实例
+func NewCaptureHandler(captureService *service.CaptureService) *CaptureHandler {
+	return &CaptureHandler{
+		captureService: captureService,
+	}
+}
+
+// ListCaptureRulesRequest 列出抓取规则请求
+type ListCaptureRulesRequest struct {
+	Scope  string `form:"scope" binding:"omitempty,oneof=service apikey"`
+	Target string `form:"target"`
+}
+
+// DeleteCaptureRuleRequest 删除抓取规则请求
+type DeleteCaptureRuleRequest struct {
+	RuleID int `json:"rule_id" binding:"required"`
+}
+
+// GetCaptureRequest 获取抓取记录详情请求
+type GetCaptureRequest struct {
+	ID int `form:"id" binding:"required,min=1"`
+}
+
+// ListRules 列出抓取规则
+// @Summary 列出抓取规则
+// @Description 列出按服务或API密钥配置的请求/响应抓取规则
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param scope query string false "作用范围" Enums(service, apikey)
+// @Param target query string false "作用目标（服务名称或API密钥ID）"
+// @Success 200 {object} model.APIResponse{data=[]model.CaptureRule}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/rules/list [get]
+func (h *CaptureHandler) ListRules(c *gin.Context) {
+	var req ListCaptureRulesRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	rules, err := h.captureService.ListRules(c.Request.Context(), req.Scope, req.Target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取抓取规则失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(rules))
+}
+
+// CreateRule 创建抓取规则
+// @Summary 创建抓取规则
+// @Description 为服务或API密钥开启请求/响应抓取，可设置采样率、最大保存字节数、脱敏规则、保留时间以及是否保存用于重放的原始请求。每个作用目标只能有一条规则，API密钥级规则优先于服务级规则
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreateCaptureRuleRequest true "创建抓取规则请求"
+// @Success 200 {object} model.APIResponse{data=model.CaptureRule}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/rules/create [post]
+func (h *CaptureHandler) CreateRule(c *gin.Context) {
+	var req model.CreateCaptureRuleRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	rule, err := h.captureService.CreateRule(auditContext(c), userID, &req)
+	if err != nil {
+		h.handleError(c, "创建抓取规则失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(rule))
+}
+
+// UpdateRule 更新抓取规则
+// @Summary 更新抓取规则
+// @Description 更新抓取规则的启用状态、采样率、最大保存字节数、脱敏规则、保留时间或重放开关，未设置的字段保持不变
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateCaptureRuleRequest true "更新抓取规则请求"
+// @Success 200 {object} model.APIResponse{data=model.CaptureRule}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/rules/update [post]
+func (h *CaptureHandler) UpdateRule(c *gin.Context) {
+	var req model.UpdateCaptureRuleRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	rule, err := h.captureService.UpdateRule(auditContext(c), &req)
+	if err != nil {
+		h.handleError(c, "更新抓取规则失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(rule))
+}
+
+// DeleteRule 删除抓取规则
+// @Summary 删除抓取规则
+// @Description 删除抓取规则，已抓取的记录保留到各自的过期时间
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body handler.DeleteCaptureRuleRequest true "删除抓取规则请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/rules/delete [post]
+func (h *CaptureHandler) DeleteRule(c *gin.Context) {
+	var req DeleteCaptureRuleRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	if err := h.captureService.DeleteRule(auditContext(c), req.RuleID); err != nil {
+		h.handleError(c, "删除抓取规则失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(map[string]string{
+		"message": "抓取规则已删除",
+	}))
+}
+
+// ListCaptures 分页查询抓取记录
+// @Summary 查询抓取记录
+// @Description 按服务、API密钥、用户、请求ID和时间范围查询未过期的抓取记录，只返回元数据，使用游标分页
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param service_name query string false "服务名称"
+// @Param api_key_id query int false "API密钥ID"
+// @Param user_id query int false "用户ID"
+// @Param request_id query string false "请求ID"
+// @Param start_time query string false "开始时间（RFC3339，包含）"
+// @Param end_time query string false "结束时间（RFC3339，不包含）"
+// @Param cursor query int false "游标，取上一页返回的next_cursor"
+// @Param limit query int false "每页条数，默认50" minimum(1) maximum(500)
+// @Success 200 {object} model.APIResponse{data=model.CaptureListResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/list [get]
+func (h *CaptureHandler) ListCaptures(c *gin.Context) {
+	var req model.CaptureQueryRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	response, err := h.captureService.QueryCaptures(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"查询抓取记录失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetCapture 获取抓取记录详情
+// @Summary 获取抓取记录详情
+// @Description 获取抓取记录并解密已脱敏的请求头、请求体、响应头和响应体，查看操作会记录审计日志
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id query int true "抓取记录ID"
+// @Success 200 {object} model.APIResponse{data=model.CapturedExchangeDetail}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/detail [get]
+func (h *CaptureHandler) GetCapture(c *gin.Context) {
+	var req GetCaptureRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	detail, err := h.captureService.GetCapture(auditContext(c), req.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrCaptureNotFound) {
+			c.JSON(http.StatusNotFound, model.NewErrorResponse(
+				model.CodeNotFound,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取抓取记录失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(detail))
+}
+
+// ReplayCapture 重放抓取记录
+// @Summary 重放抓取记录
+// @Description 使用抓取时保存的原始请求在当前版本的服务上重新执行，并与原始响应比较。只有开启重放的规则抓取且请求体未被截断的记录可以重放。重放不经过认证、IP规则和限流检查；非试运行时按原调用方记录访问日志并消耗其配额，试运行（dry_run）时两者都不做。重放操作会记录审计日志
+// @Tags 请求抓取
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ReplayCaptureRequest true "重放抓取记录请求"
+// @Success 200 {object} model.APIResponse{data=model.ReplayResult}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/captures/replay [post]
+func (h *CaptureHandler) ReplayCapture(c *gin.Context) {
+	var req model.ReplayCaptureRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	result, err := h.captureService.ReplayCapture(auditContext(c), &req)
+	if err != nil {
+		h.handleError(c, "重放抓取记录失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// handleError 根据错误类型返回对应的错误响应
+func (h *CaptureHandler) handleError(c *gin.Context, prefix string, err error) {
+	if errors.Is(err, service.ErrCaptureRuleNotFound) || errors.Is(err, service.ErrCaptureNotFound) {
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			err.Error(),
+		))
+		return
+	}
+	c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+		model.CodeInvalidParams,
+		prefix+": "+err.Error(),
+	))
+}
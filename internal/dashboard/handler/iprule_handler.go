@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPRuleHandler IP访问规则处理器
+type IPRuleHandler struct {
+	ipRuleService *service.IPRuleService
+}
+
+// NewIPRuleHandler 创建IP访问规则处理器实例
+func NewIPRuleHandler(ipRuleService *service.IPRuleService) *IPRuleHandler {
+	return &IPRuleHandler{
+		ipRuleService: ipRuleService,
+	}
+}
+
+// ListIPRulesRequest 列出IP规则请求
+type ListIPRulesRequest struct {
+	Scope  string `form:"scope" binding:"omitempty,oneof=global service apikey"`
+	Target string `form:"target"`
+}
+
+// DeleteIPRuleRequest 删除IP规则请求
+type DeleteIPRuleRequest struct {
+	RuleID int `json:"rule_id" binding:"required"`
+}
+
+// currentUser 获取当前用户ID及是否为管理员
+func currentUser(c *gin.Context) (int, bool, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		return 0, false, false
+	}
+	role, _ := middleware.GetCurrentUserRole(c)
+	return userID, role == model.RoleAdmin, true
+}
+
+// ListRules 列出IP规则
+// @Summary 列出IP规则
+// @Description 管理员可查看所有IP规则，普通用户只能查看自己API密钥上的规则
+// @Tags IP访问控制
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param scope query string false "作用范围" Enums(global, service, apikey)
+// @Param target query string false "作用目标（服务名称或API密钥ID）"
+// @Success 200 {object} model.APIResponse{data=[]model.IPRule}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/iprules/list [get]
+func (h *IPRuleHandler) ListRules(c *gin.Context) {
+	var req ListIPRulesRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	rules, err := h.ipRuleService.ListRules(c.Request.Context(), userID, isAdmin, req.Scope, req.Target)
+	if err != nil {
+		h.handleError(c, "获取IP规则失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(rules))
+}
+
+// CreateRule 创建IP规则
+// @Summary 创建IP规则
+// @Description 创建全局、服务或API密钥级别的IP允许/拒绝规则，支持单个IP或CIDR。普通用户只能为自己的API密钥创建规则
+// @Tags IP访问控制
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreateIPRuleRequest true "创建IP规则请求"
+// @Success 200 {object} model.APIResponse{data=model.IPRule}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/iprules/create [post]
+func (h *IPRuleHandler) CreateRule(c *gin.Context) {
+	var req model.CreateIPRuleRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	rule, err := h.ipRuleService.CreateRule(auditContext(c), userID, isAdmin, &req)
+	if err != nil {
+		h.handleError(c, "创建IP规则失败", err)
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(rule))
+}
+
+// DeleteRule 删除IP规则
+// @Summary 删除IP规则
+// @Description 删除指定的IP规则，普通用户只能删除自己API密钥上的规则
+// @Tags IP访问控制
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body handler.DeleteIPRuleRequest true "删除IP规则请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/iprules/delete [post]
+func (h *IPRuleHandler) DeleteRule(c *gin.Context) {
+	var req DeleteIPRuleRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	if err := h.ipRuleService.DeleteRule(auditContext(c), userID, isAdmin, req.RuleID); err != nil {
+		h.handleError(c, "删除IP规则失败", err)
+		return
+	}
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(map[string]string{
+		"message": "IP规则已删除",
+	}))
+}
+
+// handleError 根据错误类型返回对应的错误响应
+func (h *IPRuleHandler) handleError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, service.ErrIPRuleForbidden):
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(
+			model.CodeForbidden,
+			err.Error(),
+		))
+	case errors.Is(err, service.ErrIPRuleNotFound):
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			err.Error(),
+		))
+	default:
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			prefix+": "+err.Error(),
+		))
+	}
+}
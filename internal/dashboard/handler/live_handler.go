@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"apihub/internal/live"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 实时流参数
+const (
+	liveDefaultSamples = 10               // 默认每秒推送的原始事件数
+	liveWriteTimeout   = 10 * time.Second // 单次写入超时，超时视为客户端已失效
+)
+
+// LiveHandler 实时访问流处理器
+type LiveHandler struct {
+	hub *live.Hub
+}
+
+// NewLiveHandler 创建实时访问流处理器实例
+func NewLiveHandler(hub *live.Hub) *LiveHandler {
+	return &LiveHandler{
+		hub: hub,
+	}
+}
+
+// LiveStreamRequest 实时访问流请求
+type LiveStreamRequest struct {
+	ServiceName string `form:"service"`
+	UserID      int    `form:"user_id" binding:"min=0"`
+	StatusClass string `form:"status_class" binding:"omitempty,oneof=2xx 3xx 4xx 5xx"`
+	Samples     *int   `form:"samples" binding:"omitempty,min=0,max=100"` // 每秒最多推送的原始事件数，默认10，0表示只推送汇总帧
+}
+
+// Stream 以SSE推送实时访问流
+// @Summary 实时访问流
+// @Description 以Server-Sent Events推送功能API的实时调用情况：每秒一个frame事件（总调用数、错误数、限流数及按服务统计），以及抽样的sample原始访问日志事件。浏览器EventSource无法设置请求头时可通过access_token参数传递JWT
+// @Tags 实时监控
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param service query string false "服务名称"
+// @Param user_id query int false "用户ID"
+// @Param status_class query string false "状态码分类" Enums(2xx, 3xx, 4xx, 5xx)
+// @Param samples query int false "每秒最多推送的原始事件数，默认10" minimum(0) maximum(100)
+// @Param access_token query string false "JWT令牌，未设置Authorization请求头时使用"
+// @Success 200 {object} model.LiveFrame
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/live [get]
+func (h *LiveHandler) Stream(c *gin.Context) {
+	var req LiveStreamRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	samples := liveDefaultSamples
+	if req.Samples != nil {
+		samples = *req.Samples
+	}
+
+	sub := h.hub.Subscribe(live.Filter{
+		ServiceName: req.ServiceName,
+		UserID:      req.UserID,
+		StatusClass: req.StatusClass,
+	})
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁止反向代理缓冲
+	c.Status(http.StatusOK)
+
+	// 客户端读取过慢时写入会阻塞，为每次写入设置超时，超时后结束推送
+	controller := http.NewResponseController(c.Writer)
+	write := func(event string, data any) error {
+		if err := controller.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err != nil {
+			return err
+		}
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	ctx := c.Request.Context()
+	if err := controller.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err == nil {
+		fmt.Fprint(c.Writer, "retry: 3000\n\n")
+		controller.Flush()
+	}
+
+	aggregator := live.NewAggregator(samples)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case accessLog := <-sub.Events():
+			aggregator.Add(accessLog)
+		case now := <-ticker.C:
+			frame, sampled := aggregator.Flush(now, sub.TakeDropped())
+			for _, accessLog := range sampled {
+				if err := write(model.LiveEventSample, accessLog); err != nil {
+					slog.DebugContext(ctx, "实时访问流写入失败", "error", err)
+					return
+				}
+			}
+			if err := write(model.LiveEventFrame, frame); err != nil {
+				slog.DebugContext(ctx, "实时访问流写入失败", "error", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 站内通知处理器
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler 创建站内通知处理器实例
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// ListNotifications 列出当前用户的通知
+// @Summary 列出我的通知
+// @Description 按时间倒序列出当前用户的站内通知，同时返回未读通知总数
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread_only query bool false "只返回未读通知"
+// @Param offset query int false "偏移量"
+// @Param limit query int false "返回条数，默认20，最大100"
+// @Success 200 {object} model.APIResponse{data=model.NotificationListResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/notifications/list [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	var req model.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	result, err := h.notificationService.ListNotifications(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取通知失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// MarkRead 将当前用户的通知标记为已读
+// @Summary 标记通知已读
+// @Description 将当前用户指定的通知标记为已读，ids为空时标记全部通知
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MarkNotificationsReadRequest true "标记已读请求"
+// @Success 200 {object} model.APIResponse{data=model.MarkNotificationsReadResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/notifications/mark-read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var req model.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	marked, err := h.notificationService.MarkRead(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"标记通知已读失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.MarkNotificationsReadResponse{Marked: marked}))
+}
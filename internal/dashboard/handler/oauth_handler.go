@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"apihub/internal/auth/oauth"
+	"apihub/internal/metrics"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// OAuthHandler OAuth2客户端处理器
+type OAuthHandler struct {
+	oauthService *oauth.OAuthService
+}
+
+// NewOAuthHandler 创建OAuth2客户端处理器实例
+func NewOAuthHandler(oauthService *oauth.OAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// Token OAuth2令牌端点
+// @Summary 获取OAuth2访问令牌
+// @Description 客户端凭据模式（grant_type=client_credentials），客户端凭据通过HTTP Basic认证或client_id、client_secret参数提供。
+// @Description scope为以空格分隔的权限范围，为空时授予客户端的全部权限范围。返回的访问令牌以Bearer方式调用服务，使用量和配额计入客户端所属用户
+// @Tags 认证
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "授权类型，固定为client_credentials"
+// @Param scope formData string false "申请的权限范围，以空格分隔"
+// @Param client_id formData string false "客户端ID，未使用HTTP Basic认证时必填"
+// @Param client_secret formData string false "客户端密钥，未使用HTTP Basic认证时必填"
+// @Success 200 {object} model.OAuthTokenResponse
+// @Failure 400 {object} model.OAuthErrorResponse
+// @Failure 401 {object} model.OAuthErrorResponse
+// @Failure 500 {object} model.OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	// 令牌响应不能被缓存（RFC 6749 5.1）
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req model.OAuthTokenRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, model.OAuthErrInvalidRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if req.GrantType == "" {
+		respondOAuthError(c, http.StatusBadRequest, model.OAuthErrInvalidRequest, "缺少grant_type参数")
+		return
+	}
+	if req.GrantType != model.OAuthGrantClientCredentials {
+		respondOAuthError(c, http.StatusBadRequest, model.OAuthErrUnsupportedGrantType, "只支持client_credentials授权类型")
+		return
+	}
+
+	// HTTP Basic认证中的客户端凭据按application/x-www-form-urlencoded编码（RFC 6749 2.3.1）
+	clientID, clientSecret := req.ClientID, req.ClientSecret
+	username, password, basicAuth := c.Request.BasicAuth()
+	if basicAuth {
+		if clientID != "" || clientSecret != "" {
+			respondOAuthError(c, http.StatusBadRequest, model.OAuthErrInvalidRequest, "不能同时使用多种客户端认证方式")
+			return
+		}
+		var err1, err2 error
+		clientID, err1 = url.QueryUnescape(username)
+		clientSecret, err2 = url.QueryUnescape(password)
+		if err1 != nil || err2 != nil {
+			respondOAuthError(c, http.StatusBadRequest, model.OAuthErrInvalidRequest, "客户端凭据编码不正确")
+			return
+		}
+	}
+
+	token, err := h.oauthService.IssueToken(c.Request.Context(), clientID, clientSecret, req.Scope)
+	if err != nil {
+		var tokenErr *oauth.TokenError
+		if !errors.As(err, &tokenErr) {
+			respondOAuthError(c, http.StatusInternalServerError, model.OAuthErrServerError, "签发访问令牌失败")
+			return
+		}
+
+		status := http.StatusBadRequest
+		switch tokenErr.Code {
+		case model.OAuthErrInvalidClient:
+			metrics.AuthFailed(metrics.AuthMethodClient)
+			status = http.StatusUnauthorized
+			if basicAuth {
+				c.Header("WWW-Authenticate", `Basic realm="apihub"`)
+			}
+		case model.OAuthErrUnauthorizedClient:
+			metrics.AuthFailed(metrics.AuthMethodClient)
+		}
+		respondOAuthError(c, status, tokenErr.Code, tokenErr.Description)
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// respondOAuthError 返回RFC 6749格式的错误响应
+func respondOAuthError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, &model.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// ListClients 列出当前用户的OAuth2客户端
+// @Summary 列出OAuth2客户端
+// @Description 列出当前用户的所有OAuth2客户端，不包含客户端密钥
+// @Tags OAuth2客户端
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.OAuthClient}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/oauth-clients/list [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	clients, err := h.oauthService.ListClients(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取OAuth2客户端失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(clients))
+}
+
+// CreateClient 为当前用户创建OAuth2客户端
+// @Summary 创建OAuth2客户端
+// @Description 为当前用户创建OAuth2客户端，客户端密钥只在本次响应中返回，之后无法再次查看。
+// @Description scopes可包含service:<服务名称>和service:*，为空时默认为service:*
+// @Tags OAuth2客户端
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreateOAuthClientRequest true "创建OAuth2客户端请求"
+// @Success 200 {object} model.APIResponse{data=model.OAuthClient}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/oauth-clients/create [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req model.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	client, err := h.oauthService.CreateClient(auditContext(c), userID, req.Name, req.Scopes)
+	if err != nil {
+		if respondOAuthClientError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"创建OAuth2客户端失败: "+err.Error(),
+		))
+		return
+	}
+
+	// 返回创建的客户端，包含仅此一次可见的客户端密钥
+	c.JSON(http.StatusOK, model.NewSuccessResponse(client))
+}
+
+// UpdateClient 更新当前用户的OAuth2客户端
+// @Summary 更新OAuth2客户端
+// @Description 更新当前用户的OAuth2客户端的名称、权限范围或状态，未设置的字段保持不变。禁用客户端或缩小权限范围后，已签发的访问令牌随之失效
+// @Tags OAuth2客户端
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateOAuthClientRequest true "更新OAuth2客户端请求"
+// @Success 200 {object} model.APIResponse{data=model.OAuthClient}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/oauth-clients/update [post]
+func (h *OAuthHandler) UpdateClient(c *gin.Context) {
+	var req model.UpdateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	client, err := h.oauthService.UpdateClient(auditContext(c), userID, &req)
+	if err != nil {
+		if respondOAuthClientError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"更新OAuth2客户端失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(client))
+}
+
+// ResetClientSecret 重置当前用户的OAuth2客户端密钥
+// @Summary 重置OAuth2客户端密钥
+// @Description 重新生成客户端密钥，原密钥立即失效，新密钥只在本次响应中返回。已签发的访问令牌在过期前仍然有效，需要立即停止访问时应禁用客户端
+// @Tags OAuth2客户端
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.OAuthClientIDRequest true "重置客户端密钥请求"
+// @Success 200 {object} model.APIResponse{data=model.OAuthClient}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/oauth-clients/reset-secret [post]
+func (h *OAuthHandler) ResetClientSecret(c *gin.Context) {
+	var req model.OAuthClientIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	client, err := h.oauthService.ResetSecret(auditContext(c), userID, req.ID)
+	if err != nil {
+		if respondOAuthClientError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"重置OAuth2客户端密钥失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(client))
+}
+
+// DeleteClient 删除当前用户的OAuth2客户端
+// @Summary 删除OAuth2客户端
+// @Description 删除当前用户的OAuth2客户端，已签发的访问令牌随之失效
+// @Tags OAuth2客户端
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.OAuthClientIDRequest true "删除OAuth2客户端请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /dashboard/oauth-clients/delete [post]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	var req model.OAuthClientIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(auditContext(c), userID, req.ID); err != nil {
+		if respondOAuthClientError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"删除OAuth2客户端失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(nil))
+}
+
+// respondOAuthClientError 处理OAuth2客户端操作的业务错误，已处理时返回true
+func respondOAuthClientError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, oauth.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return true
+	case errors.Is(err, oauth.ErrScopeNotPermitted):
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(
+			model.CodeForbidden,
+			err.Error(),
+		))
+		return true
+	case errors.Is(err, oauth.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			err.Error(),
+		))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitHandler 限流管理处理器
+type RateLimitHandler struct {
+	rateLimiter *middleware.RateLimiter
+	store       store.Store
+}
+
+// NewRateLimitHandler 创建限流管理处理器实例
+func NewRateLimitHandler(rateLimiter *middleware.RateLimiter, store store.Store) *RateLimitHandler {
+	return &RateLimitHandler{
+		rateLimiter: rateLimiter,
+		store:       store,
+	}
+}
+
+// ResetRateLimitRequest 重置限流条目请求
+type ResetRateLimitRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=ip user api_key"`
+	Subject     string `json:"subject" binding:"required"`
+}
+
+// CreateRateLimitOverrideRequest 创建临时限流覆盖请求
+type CreateRateLimitOverrideRequest struct {
+	SubjectType     string `json:"subject_type" binding:"required,oneof=ip user api_key"`
+	Subject         string `json:"subject" binding:"required"`
+	Limit           int    `json:"limit" binding:"min=-1"`                    // -1表示解除限流
+	DurationSeconds int    `json:"duration_seconds" binding:"required,min=1"` // 覆盖有效期（秒）
+	Reason          string `json:"reason" binding:"max=200"`
+}
+
+// DeleteRateLimitOverrideRequest 删除临时限流覆盖请求
+type DeleteRateLimitOverrideRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=ip user api_key"`
+	Subject     string `json:"subject" binding:"required"`
+}
+
+// ListEntries 列出当前限流器条目
+// @Summary 列出限流器条目
+// @Description 列出当前内存中所有IP、用户和API密钥的限流状态
+// @Tags 限流管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]middleware.RateLimitEntry}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/ratelimit/entries [get]
+func (h *RateLimitHandler) ListEntries(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(h.rateLimiter.ListEntries()))
+}
+
+// ResetEntry 重置指定主体的限流条目
+// @Summary 重置限流条目
+// @Description 清除指定IP、用户或API密钥当前时间窗口内的请求计数
+// @Tags 限流管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body handler.ResetRateLimitRequest true "重置限流条目请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/ratelimit/reset [post]
+func (h *RateLimitHandler) ResetEntry(c *gin.Context) {
+	var req ResetRateLimitRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	found, err := h.rateLimiter.ResetEntry(req.SubjectType, req.Subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			"限流条目不存在",
+		))
+		return
+	}
+
+	h.recordAudit(auditContext(c), model.AuditActionRateLimitReset, req.SubjectType, req.Subject, nil, nil)
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(map[string]string{
+		"message": "限流条目已重置",
+	}))
+}
+
+// ListOverrides 列出临时限流覆盖
+// 只包含当前实例内存中的覆盖，其他实例设置的覆盖不会出现在结果中
+// @Summary 列出临时限流覆盖
+// @Description 列出当前实例所有未过期的临时限流覆盖
+// @Tags 限流管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]middleware.RateLimitOverride}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/ratelimit/overrides [get]
+func (h *RateLimitHandler) ListOverrides(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(h.rateLimiter.ListOverrides()))
+}
+
+// CreateOverride 创建临时限流覆盖
+// 覆盖只保存在当前实例的内存中，进程重启后丢失，多实例部署时需要在每个实例上分别设置；
+// 响应中的scope字段固定为instance以提示调用方
+// @Summary 创建临时限流覆盖
+// @Description 在指定时间内调整当前实例上IP、用户或API密钥的限流值，limit为-1表示解除限流，到期后自动失效。覆盖不持久化，进程重启后丢失，也不在多个实例之间共享
+// @Tags 限流管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body handler.CreateRateLimitOverrideRequest true "创建临时限流覆盖请求"
+// @Success 200 {object} model.APIResponse{data=middleware.RateLimitOverride}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/ratelimit/overrides/create [post]
+func (h *RateLimitHandler) CreateOverride(c *gin.Context) {
+	var req CreateRateLimitOverrideRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	override, err := h.rateLimiter.SetOverride(
+		req.SubjectType,
+		req.Subject,
+		req.Limit,
+		time.Duration(req.DurationSeconds)*time.Second,
+		req.Reason,
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"创建临时限流覆盖失败: "+err.Error(),
+		))
+		return
+	}
+
+	h.recordAudit(auditContext(c), model.AuditActionRateLimitOverrideCreate, req.SubjectType, req.Subject, nil, override)
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(override))
+}
+
+// DeleteOverride 删除临时限流覆盖
+// @Summary 删除临时限流覆盖
+// @Description 提前移除指定IP、用户或API密钥的临时限流覆盖
+// @Tags 限流管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body handler.DeleteRateLimitOverrideRequest true "删除临时限流覆盖请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/v1/dashboard/ratelimit/overrides/delete [post]
+func (h *RateLimitHandler) DeleteOverride(c *gin.Context) {
+	var req DeleteRateLimitOverrideRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	if !h.rateLimiter.RemoveOverride(req.SubjectType, req.Subject) {
+		c.JSON(http.StatusNotFound, model.NewErrorResponse(
+			model.CodeNotFound,
+			"临时限流覆盖不存在",
+		))
+		return
+	}
+
+	h.recordAudit(auditContext(c), model.AuditActionRateLimitOverrideDelete, req.SubjectType, req.Subject, req, nil)
+
+	// 返回成功响应
+	c.JSON(http.StatusOK, model.NewSuccessResponse(map[string]string{
+		"message": "临时限流覆盖已删除",
+	}))
+}
+
+// recordAudit 记录限流管理操作的审计日志
+// 限流状态只保存在内存中，变更已生效，审计日志写入失败时只记录错误
+func (h *RateLimitHandler) recordAudit(ctx context.Context, action, subjectType, subject string, before, after any) {
+	err := audit.Record(ctx, h.store.AuditLogs(), action, model.AuditTargetRateLimit, subjectType+":"+subject, before, after)
+	if err != nil {
+		slog.ErrorContext(ctx, "记录限流管理审计日志失败", "action", action, "error", err)
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionHandler 数据保留清理处理器
+type RetentionHandler struct {
+	job *retention.Job
+}
+
+// NewRetentionHandler 创建数据保留清理处理器实例
+func NewRetentionHandler(job *retention.Job) *RetentionHandler {
+	return &RetentionHandler{
+		job: job,
+	}
+}
+
+// RunRetentionRequest 手动执行清理请求
+type RunRetentionRequest struct {
+	Async bool `json:"async"` // 为true时在后台执行并立即返回，结果通过状态接口查询
+}
+
+// GetStatus 获取清理任务状态
+// @Summary 获取数据清理任务状态
+// @Description 获取保留策略、下次执行时间和最近一次执行报告
+// @Tags 数据保留
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=model.RetentionStatusResponse}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/retention/status [get]
+func (h *RetentionHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(h.job.Status()))
+}
+
+// Run 手动执行一次清理
+// @Summary 手动执行数据清理
+// @Description 按保留策略立即清理过期数据，默认等待执行完成并返回执行报告
+// @Tags 数据保留
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RunRetentionRequest false "执行清理请求"
+// @Success 200 {object} model.APIResponse{data=model.RetentionReport}
+// @Success 202 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Router /api/v1/dashboard/retention/run [post]
+func (h *RetentionHandler) Run(c *gin.Context) {
+	var req RunRetentionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+				model.CodeInvalidParams,
+				"请求参数错误: "+err.Error(),
+			))
+			return
+		}
+	}
+
+	if req.Async {
+		if err := h.job.RunAsync(model.RetentionTriggerManual); err != nil {
+			h.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, model.NewSuccessResponse(nil))
+		return
+	}
+
+	// 清理大量数据耗时较长，取消服务器的写超时
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	report, err := h.job.Run(c.Request.Context(), model.RetentionTriggerManual)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(report))
+}
+
+// handleError 处理清理任务错误
+func (h *RetentionHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, retention.ErrRunning) {
+		c.JSON(http.StatusConflict, model.NewErrorResponse(model.CodeTaskRunning, model.MsgTaskRunning))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, model.NewErrorResponse(model.CodeInternalError, err.Error()))
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录会话处理器
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// NewSessionHandler 创建登录会话处理器实例
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// ListUserSessionsRequest 管理员查看用户会话请求
+type ListUserSessionsRequest struct {
+	UserID int `form:"user_id" binding:"required,min=1"`
+}
+
+// currentSession 获取当前用户ID及当前请求所在的会话ID
+func currentSession(c *gin.Context) (int, string, bool) {
+	claims, ok := jwt.GetUserClaims(c)
+	if !ok {
+		return 0, "", false
+	}
+	return claims.UserID, claims.ID, true
+}
+
+// ListSessions 列出当前用户的会话
+// @Summary 列出我的会话
+// @Description 列出当前用户未撤销且未过期的登录会话，current标记当前请求所在的会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.SessionInfo}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /auth/sessions/list [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, sessionID, ok := currentSession(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取会话列表失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(sessions))
+}
+
+// RevokeSession 撤销当前用户的一个会话
+// @Summary 撤销我的会话
+// @Description 撤销当前用户的一个会话，该会话的访问令牌和刷新令牌立即失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.RevokeSessionRequest true "撤销会话请求"
+// @Success 200 {object} model.APIResponse{data=model.RevokeSessionsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /auth/sessions/revoke [post]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	var req model.RevokeSessionRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	userID, _, ok := currentSession(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(auditContext(c), userID, req.SessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, model.NewErrorResponse(
+				model.CodeNotFound,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"撤销会话失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.RevokeSessionsResponse{Revoked: 1}))
+}
+
+// RevokeAllSessions 撤销当前用户的所有会话
+// @Summary 撤销我的所有会话
+// @Description 撤销当前用户的所有会话，keep_current为true时保留当前会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.RevokeAllSessionsRequest false "撤销全部会话请求"
+// @Success 200 {object} model.APIResponse{data=model.RevokeSessionsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /auth/sessions/revoke-all [post]
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	var req model.RevokeAllSessionsRequest
+
+	// 请求体可为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+				model.CodeInvalidParams,
+				"请求参数错误: "+err.Error(),
+			))
+			return
+		}
+	}
+
+	userID, sessionID, ok := currentSession(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAllSessions(auditContext(c), userID, sessionID, req.KeepCurrent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"撤销会话失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.RevokeSessionsResponse{Revoked: revoked}))
+}
+
+// ListUserSessions 管理员查看用户的会话
+// @Summary 查看用户会话
+// @Description 管理员查看指定用户未撤销且未过期的登录会话
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int true "用户ID"
+// @Success 200 {object} model.APIResponse{data=[]model.SessionInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/v1/dashboard/user/sessions [get]
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	var req ListUserSessionsRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	_, currentID, _ := currentSession(c)
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), req.UserID, currentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"获取会话列表失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(sessions))
+}
+
+// ForceLogout 管理员强制用户下线
+// @Summary 强制用户下线
+// @Description 撤销指定用户的所有会话，其访问令牌和刷新令牌立即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ForceLogoutRequest true "强制下线请求"
+// @Success 200 {object} model.APIResponse{data=model.RevokeSessionsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/v1/dashboard/user/force-logout [post]
+func (h *SessionHandler) ForceLogout(c *gin.Context) {
+	var req model.ForceLogoutRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	revoked, err := h.sessionService.ForceLogout(auditContext(c), req.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, model.NewErrorResponse(
+				model.CodeNotFound,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"强制下线失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.RevokeSessionsResponse{Revoked: revoked}))
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SigningKeyHandler JWT签名密钥处理器
+type SigningKeyHandler struct {
+	signingKeyService *service.SigningKeyService
+}
+
+// NewSigningKeyHandler 创建JWT签名密钥处理器实例
+func NewSigningKeyHandler(signingKeyService *service.SigningKeyService) *SigningKeyHandler {
+	return &SigningKeyHandler{
+		signingKeyService: signingKeyService,
+	}
+}
+
+// JWKS 获取公钥集合
+// @Summary 获取JWT公钥集合
+// @Description 以JWKS格式发布所有可用于验证APIHub令牌的公钥，包括即将启用的密钥，令牌头中的kid对应其中的密钥
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} model.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *SigningKeyHandler) JWKS(c *gin.Context) {
+	// 允许验证方短时间缓存，新密钥在启用前已提前发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.signingKeyService.JWKS())
+}
+
+// ListKeys 获取签名密钥列表
+// @Summary 获取签名密钥列表
+// @Description 获取所有可用于验证的JWT签名密钥及其状态，不包含私钥
+// @Tags 签名密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.SigningKeyInfo}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/signing-keys/list [get]
+func (h *SigningKeyHandler) ListKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(h.signingKeyService.ListKeys()))
+}
+
+// RotateKey 立即轮换签名密钥
+// @Summary 立即轮换签名密钥
+// @Description 生成新的签名密钥并立即用于签名，原有密钥停止签名，已签发的令牌在重叠期内仍可验证。使用配置文件中的固定密钥时不支持轮换
+// @Tags 签名密钥
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.SigningKeyInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/v1/dashboard/signing-keys/rotate [post]
+func (h *SigningKeyHandler) RotateKey(c *gin.Context) {
+	keys, err := h.signingKeyService.RotateKey(auditContext(c))
+	if err != nil {
+		if errors.Is(err, jwt.ErrStaticKey) {
+			c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+				model.CodeInvalidParams,
+				err.Error(),
+			))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+			model.CodeInternalError,
+			"轮换签名密钥失败: "+err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(keys))
+}
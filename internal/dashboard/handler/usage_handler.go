@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"apihub/internal/dashboard/service"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler 使用量统计处理器
+type UsageHandler struct {
+	usageService *service.UsageService
+}
+
+// NewUsageHandler 创建使用量统计处理器实例
+func NewUsageHandler(usageService *service.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// QueryStats 查询使用量统计
+// @Summary 查询使用量统计
+// @Description 按小时/天/月粒度查询调用量、错误数、计费和延迟，可按服务、API密钥、状态码分类和用户分组。时间桶按UTC划分，普通用户只能查询自己的统计
+// @Tags 使用统计
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param granularity query string false "统计粒度，默认day" Enums(hour, day, month)
+// @Param start_time query string false "开始时间（RFC3339），默认按粒度取最近24小时/30天/12个月"
+// @Param end_time query string false "结束时间（RFC3339），默认当前时间"
+// @Param user_id query int false "用户ID（仅管理员可指定其他用户）"
+// @Param api_key_id query int false "API密钥ID"
+// @Param service_name query string false "服务名称"
+// @Param group_by query []string false "分组维度，可重复或逗号分隔" collectionFormat(multi) Enums(service, api_key, status, user)
+// @Success 200 {object} model.APIResponse{data=model.UsageStatsQueryResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/usage/stats [get]
+func (h *UsageHandler) QueryStats(c *gin.Context) {
+	var req model.UsageStatsQueryRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	filter, err := req.ToFilter(time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			err.Error(),
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	response, err := h.usageService.QueryStats(c.Request.Context(), userID, isAdmin, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetSummary 获取使用概况
+// @Summary 获取使用概况
+// @Description 获取单个用户按天的调用量、成功/失败数、计费和延迟，默认最近30天。普通用户只能查询自己的概况
+// @Tags 使用统计
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param user_id query int false "用户ID（仅管理员可指定其他用户），默认当前用户"
+// @Param service_name query string false "服务名称"
+// @Param start_date query string false "开始日期（YYYY-MM-DD，UTC）"
+// @Param end_date query string false "结束日期（YYYY-MM-DD，UTC）"
+// @Success 200 {object} model.APIResponse{data=model.UsageStatsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/v1/dashboard/usage/summary [get]
+func (h *UsageHandler) GetSummary(c *gin.Context) {
+	var req model.UsageStatsRequest
+
+	// 绑定请求参数
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"请求参数错误: "+err.Error(),
+		))
+		return
+	}
+
+	now := time.Now()
+	if req.EndDate == "" {
+		req.EndDate = model.UsageBucket(now, model.GranularityDay)
+	}
+	if req.StartDate == "" {
+		req.StartDate = model.UsageBucket(now.AddDate(0, 0, -30), model.GranularityDay)
+	}
+	if req.EndDate < req.StartDate {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
+			model.CodeInvalidParams,
+			"结束日期不能早于开始日期",
+		))
+		return
+	}
+
+	userID, isAdmin, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(
+			model.CodeUnauthorized,
+			"用户信息不存在",
+		))
+		return
+	}
+
+	response, err := h.usageService.GetSummary(c.Request.Context(), userID, isAdmin, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// handleError 根据错误类型返回对应的错误响应
+func (h *UsageHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUsageStatsForbidden) {
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(
+			model.CodeForbidden,
+			err.Error(),
+		))
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
+		model.CodeInternalError,
+		"获取使用统计失败: "+err.Error(),
+	))
+}
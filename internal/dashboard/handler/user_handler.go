@@ -184,7 +184,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	}
 
 	// 调用服务层创建用户
-	user, err := h.userService.CreateUser(c.Request.Context(), modelReq)
+	user, err := h.userService.CreateUser(auditContext(c), modelReq)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -243,7 +243,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	// 调用服务层更新用户
-	user, err := h.userService.UpdateUser(c.Request.Context(), userID, modelReq)
+	user, err := h.userService.UpdateUser(auditContext(c), userID, modelReq)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -282,7 +282,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	}
 
 	// 调用服务层删除用户
-	err := h.userService.DeleteUser(c.Request.Context(), req.UserID)
+	err := h.userService.DeleteUser(auditContext(c), req.UserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -324,7 +324,7 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 	}
 
 	// 调用服务层重置密码
-	err := h.userService.ResetPassword(c.Request.Context(), req.UserID, req.NewPassword)
+	err := h.userService.ResetPassword(auditContext(c), req.UserID, req.NewPassword)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
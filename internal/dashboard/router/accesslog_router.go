@@ -0,0 +1,89 @@
+package router
+
+import (
+	"apihub/internal/auth"
+	"apihub/internal/auth/apikey"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/auth/permission"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogRouter 访问日志路由
+type AccessLogRouter struct {
+	accessLogHandler  *handler.AccessLogHandler
+	jwtService        *jwt.JWTService
+	apiKeyService     *apikey.APIKeyService
+	permissionService *permission.PermissionService
+}
+
+// NewAccessLogRouter 创建访问日志路由实例
+func NewAccessLogRouter(store store.Store, authServices *auth.AuthServices) *AccessLogRouter {
+	// 创建访问日志服务
+	accessLogService := service.NewAccessLogService(store)
+
+	return &AccessLogRouter{
+		accessLogHandler:  handler.NewAccessLogHandler(accessLogService),
+		jwtService:        authServices.JWTService,
+		apiKeyService:     authServices.APIKeyService,
+		permissionService: authServices.PermissionService,
+	}
+}
+
+// RegisterRoutes 注册访问日志相关路由
+func (r *AccessLogRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 访问日志路由组，需要JWT认证或具有dashboard:read权限范围的API密钥，普通用户只能查看自己的日志
+	accessLogGroup := router.Group("/accesslogs")
+	accessLogGroup.Use(middleware.JWTOrAPIKeyScopeMiddleware(r.jwtService, r.apiKeyService, model.APIKeyScopeDashboardRead))
+	accessLogGroup.Use(permission.RequirePermissionMiddleware(r.permissionService, permission.PermListAccessLogs))
+
+	{
+		// @Summary      查询访问日志
+		// @Description  按时间范围、用户、密钥、服务、状态码分类和端点前缀查询访问日志，使用游标分页
+		// @Tags         访问日志
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Security     ApiKeyAuth
+		// @Param        start_time    query     string  false  "开始时间（RFC3339，包含）"
+		// @Param        end_time      query     string  false  "结束时间（RFC3339，不包含）"
+		// @Param        user_id       query     int     false  "用户ID（仅管理员可指定其他用户）"
+		// @Param        api_key_id    query     int     false  "API密钥ID"
+		// @Param        service_name  query     string  false  "服务名称"
+		// @Param        endpoint      query     string  false  "端点前缀"
+		// @Param        status_class  query     string  false  "状态码分类"  Enums(2xx, 3xx, 4xx, 5xx)
+		// @Param        cursor        query     int     false  "游标，取上一页返回的next_cursor"
+		// @Param        limit         query     int     false  "每页条数，默认50"  minimum(1) maximum(500)
+		// @Success      200           {object}  model.APIResponse{data=model.AccessLogListResponse}
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/accesslogs/list [get]
+		accessLogGroup.GET("/list", r.accessLogHandler.ListLogs)
+
+		// @Summary      导出访问日志
+		// @Description  按查询条件流式导出访问日志，支持CSV和NDJSON格式
+		// @Tags         访问日志
+		// @Produce      text/csv
+		// @Produce      application/x-ndjson
+		// @Security     BearerAuth
+		// @Security     ApiKeyAuth
+		// @Param        format        query     string  false  "导出格式，默认ndjson"  Enums(csv, ndjson)
+		// @Param        start_time    query     string  false  "开始时间（RFC3339，包含）"
+		// @Param        end_time      query     string  false  "结束时间（RFC3339，不包含）"
+		// @Param        user_id       query     int     false  "用户ID（仅管理员可指定其他用户）"
+		// @Param        service_name  query     string  false  "服务名称"
+		// @Param        status_class  query     string  false  "状态码分类"  Enums(2xx, 3xx, 4xx, 5xx)
+		// @Success      200           {file}    file
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/accesslogs/export [get]
+		accessLogGroup.GET("/export", r.accessLogHandler.ExportLogs)
+	}
+}
@@ -35,18 +35,18 @@ func (r *APIKeyRouter) RegisterRoutes(router *gin.RouterGroup) {
 
 	{
 		// @Summary      列出用户的API密钥
-		// @Description  列出当前用户的所有API密钥
+		// @Description  列出当前用户的所有API密钥，只返回密钥前缀
 		// @Tags         API密钥
 		// @Accept       json
 		// @Produce      json
 		// @Security     BearerAuth
-		// @Success      200  {object}  model.APIResponse{data=[]model.APIKey}
+		// @Success      200  {object}  model.APIResponse{data=[]model.APIKeyResponse}
 		// @Failure      401  {object}  model.APIResponse
 		// @Router       /api/v1/dashboard/apikeys/list [get]
 		apiKeyGroup.GET("/list", r.apiKeyHandler.ListAPIKeys)
 
 		// @Summary      生成API密钥
-		// @Description  为当前用户生成新的API密钥
+		// @Description  为当前用户生成新的API密钥，完整密钥只在本次响应中返回，之后无法再次查看
 		// @Tags         API密钥
 		// @Accept       json
 		// @Produce      json
@@ -55,6 +55,7 @@ func (r *APIKeyRouter) RegisterRoutes(router *gin.RouterGroup) {
 		// @Success      200  {object}  model.APIResponse{data=model.APIKey}
 		// @Failure      400  {object}  model.APIResponse
 		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
 		// @Router       /api/v1/dashboard/apikeys/generate [post]
 		apiKeyGroup.POST("/generate", r.apiKeyHandler.GenerateAPIKey)
 
@@ -71,5 +72,104 @@ func (r *APIKeyRouter) RegisterRoutes(router *gin.RouterGroup) {
 		// @Failure      403  {object}  model.APIResponse
 		// @Router       /api/v1/dashboard/apikeys/delete [post]
 		apiKeyGroup.POST("/delete", r.apiKeyHandler.DeleteAPIKey)
+
+		// @Summary      更新API密钥权限范围
+		// @Description  更新当前用户指定API密钥的权限范围，不能超出用户自身权限，立即生效
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.UpdateAPIKeyScopesRequest true "更新权限范围请求"
+		// @Success      200  {object}  model.APIResponse{data=model.APIKeyResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/update-scopes [post]
+		apiKeyGroup.POST("/update-scopes", r.apiKeyHandler.UpdateAPIKeyScopes)
+
+		// @Summary      更新API密钥使用限制
+		// @Description  更新当前用户指定API密钥的请求来源、使用时间段和最大使用次数，立即生效；restrictions为空对象时清除所有限制；允许的网段通过IP访问规则设置
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.UpdateAPIKeyRestrictionsRequest true "更新使用限制请求"
+		// @Success      200  {object}  model.APIResponse{data=model.APIKeyResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/update-restrictions [post]
+		apiKeyGroup.POST("/update-restrictions", r.apiKeyHandler.UpdateAPIKeyRestrictions)
+
+		// @Summary      轮换API密钥
+		// @Description  为当前用户指定的API密钥创建继任密钥，旧密钥在宽限期内继续有效，之后自动过期；完整的新密钥只在本次响应中返回
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.RotateAPIKeyRequest true "轮换API密钥请求"
+		// @Success      200  {object}  model.APIResponse{data=model.RotateAPIKeyResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/rotate [post]
+		apiKeyGroup.POST("/rotate", r.apiKeyHandler.RotateAPIKey)
+
+		// @Summary      获取API密钥轮换状态
+		// @Description  获取旧密钥和继任密钥、宽限期结束时间，以及轮换以来两个密钥各自的调用情况
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        api_key_id query int true "API密钥ID"
+		// @Success      200  {object}  model.APIResponse{data=model.APIKeyRotationStatus}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/rotation-status [get]
+		apiKeyGroup.GET("/rotation-status", r.apiKeyHandler.GetRotationStatus)
+
+		// @Summary      获取API密钥使用情况
+		// @Description  获取密钥的最后使用时间和IP，以及最近24小时、7天、30天的调用次数和最近30天每天的调用情况
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        api_key_id query int true "API密钥ID"
+		// @Success      200  {object}  model.APIResponse{data=model.APIKeyUsage}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/usage [get]
+		apiKeyGroup.GET("/usage", r.apiKeyHandler.GetAPIKeyUsage)
+
+		// @Summary      生成API密钥签名密钥
+		// @Description  为当前用户指定的API密钥生成签名密钥，启用请求签名认证，已有的签名密钥立即失效；签名密钥只在本次响应中返回
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.APIKeySigningRequest true "生成签名密钥请求"
+		// @Success      200  {object}  model.APIResponse{data=model.APIKeySigningSecretResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/signing-secret [post]
+		apiKeyGroup.POST("/signing-secret", r.apiKeyHandler.SetSigningSecret)
+
+		// @Summary      删除API密钥签名密钥
+		// @Description  删除当前用户指定的API密钥的签名密钥，之后该密钥不能再用于请求签名认证
+		// @Tags         API密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.APIKeySigningRequest true "删除签名密钥请求"
+		// @Success      200  {object}  model.APIResponse
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/apikeys/delete-signing-secret [post]
+		apiKeyGroup.POST("/delete-signing-secret", r.apiKeyHandler.DeleteSigningSecret)
 	}
 }
@@ -0,0 +1,92 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogRouter 审计日志路由
+type AuditLogRouter struct {
+	auditLogHandler *handler.AuditLogHandler
+	jwtService      *jwt.JWTService
+}
+
+// NewAuditLogRouter 创建审计日志路由实例
+func NewAuditLogRouter(store store.Store, jwtService *jwt.JWTService) *AuditLogRouter {
+	// 创建审计日志服务
+	auditLogService := service.NewAuditLogService(store)
+
+	return &AuditLogRouter{
+		auditLogHandler: handler.NewAuditLogHandler(auditLogService),
+		jwtService:      jwtService,
+	}
+}
+
+// RegisterRoutes 注册审计日志相关路由
+func (r *AuditLogRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 审计日志路由组，需要JWT认证
+	auditLogGroup := router.Group("/auditlogs")
+	auditLogGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	auditLogGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      查询审计日志
+		// @Description  按时间范围、操作人、操作类型和操作对象查询审计日志，使用游标分页
+		// @Tags         审计日志
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        start_time   query     string  false  "开始时间（RFC3339，包含）"
+		// @Param        end_time     query     string  false  "结束时间（RFC3339，不包含）"
+		// @Param        actor_id     query     int     false  "操作人用户ID"
+		// @Param        action       query     string  false  "操作类型，如user.delete"
+		// @Param        target_type  query     string  false  "操作对象类型"  Enums(user, apikey, iprule, ratelimit, capture_rule, capture, signing_key, session)
+		// @Param        target_id    query     string  false  "操作对象ID"
+		// @Param        cursor       query     int     false  "游标，取上一页返回的next_cursor"
+		// @Param        limit        query     int     false  "每页条数，默认50"  minimum(1) maximum(500)
+		// @Success      200          {object}  model.APIResponse{data=model.AuditLogListResponse}
+		// @Failure      400          {object}  model.APIResponse
+		// @Failure      401          {object}  model.APIResponse
+		// @Failure      403          {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/auditlogs/list [get]
+		auditLogGroup.GET("/list", r.auditLogHandler.ListLogs)
+
+		// @Summary      导出审计日志
+		// @Description  按查询条件流式导出审计日志，支持CSV和NDJSON格式
+		// @Tags         审计日志
+		// @Produce      text/csv
+		// @Produce      application/x-ndjson
+		// @Security     BearerAuth
+		// @Param        format       query     string  false  "导出格式，默认ndjson"  Enums(csv, ndjson)
+		// @Param        start_time   query     string  false  "开始时间（RFC3339，包含）"
+		// @Param        end_time     query     string  false  "结束时间（RFC3339，不包含）"
+		// @Param        actor_id     query     int     false  "操作人用户ID"
+		// @Param        action       query     string  false  "操作类型，如user.delete"
+		// @Success      200          {file}    file
+		// @Failure      400          {object}  model.APIResponse
+		// @Failure      401          {object}  model.APIResponse
+		// @Failure      403          {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/auditlogs/export [get]
+		auditLogGroup.GET("/export", r.auditLogHandler.ExportLogs)
+
+		// @Summary      校验审计日志
+		// @Description  从第一条记录开始校验哈希链，报告第一条被修改、删除或插入的记录
+		// @Tags         审计日志
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=model.AuditVerifyResponse}
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/auditlogs/verify [get]
+		auditLogGroup.GET("/verify", r.auditLogHandler.VerifyChain)
+	}
+}
@@ -12,8 +12,9 @@ import (
 
 // AuthRouter 认证路由
 type AuthRouter struct {
-	authHandler *handler.AuthHandler
-	authService *auth.AuthServices
+	authHandler    *handler.AuthHandler
+	sessionHandler *handler.SessionHandler
+	authService    *auth.AuthServices
 }
 
 // NewAuthRouter 创建认证路由实例
@@ -24,9 +25,13 @@ func NewAuthRouter(store store.Store, authServices *auth.AuthServices) *AuthRout
 	// 创建认证处理器
 	authHandler := handler.NewAuthHandler(authService)
 
+	// 创建登录会话处理器
+	sessionHandler := handler.NewSessionHandler(service.NewSessionService(store, authServices.JWTService))
+
 	return &AuthRouter{
-		authHandler: authHandler,
-		authService: authServices,
+		authHandler:    authHandler,
+		sessionHandler: sessionHandler,
+		authService:    authServices,
 	}
 }
 
@@ -49,12 +54,24 @@ func (r *AuthRouter) RegisterRoutes(router *gin.RouterGroup) {
 		// @Router       /api/v1/auth/login [post]
 		authGroup.POST("/login", r.authHandler.Login)
 
+		// @Summary      刷新令牌
+		// @Description  使用刷新令牌换取新的访问令牌和刷新令牌，每个刷新令牌只能使用一次
+		// @Tags         认证
+		// @Accept       json
+		// @Produce      json
+		// @Param        request  body      model.RefreshTokenRequest  true  "刷新令牌请求"
+		// @Success      200      {object}  model.APIResponse{data=model.LoginResponse}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Router       /api/v1/auth/refresh [post]
+		authGroup.POST("/refresh", r.authHandler.Refresh)
+
 		// 需要认证的路由
 		protected := authGroup.Group("")
 		protected.Use(middleware.JWTOnlyMiddleware(r.authService.JWTService))
 		{
 			// @Summary      用户登出
-			// @Description  使当前JWT令牌失效
+			// @Description  撤销当前会话，会话的访问令牌和刷新令牌立即失效
 			// @Tags         认证
 			// @Accept       json
 			// @Produce      json
@@ -100,6 +117,44 @@ func (r *AuthRouter) RegisterRoutes(router *gin.RouterGroup) {
 			// @Failure      401      {object}  model.APIResponse
 			// @Router       /api/v1/auth/password/change [post]
 			protected.POST("/password/change", r.authHandler.ChangePassword)
+
+			// @Summary      列出我的会话
+			// @Description  列出当前用户未撤销且未过期的登录会话，current标记当前请求所在的会话
+			// @Tags         认证
+			// @Accept       json
+			// @Produce      json
+			// @Security     BearerAuth
+			// @Success      200  {object}  model.APIResponse{data=[]model.SessionInfo}
+			// @Failure      401  {object}  model.APIResponse
+			// @Router       /api/v1/auth/sessions/list [get]
+			protected.GET("/sessions/list", r.sessionHandler.ListSessions)
+
+			// @Summary      撤销我的会话
+			// @Description  撤销当前用户的一个会话，该会话的访问令牌和刷新令牌立即失效
+			// @Tags         认证
+			// @Accept       json
+			// @Produce      json
+			// @Security     BearerAuth
+			// @Param        request  body      model.RevokeSessionRequest  true  "撤销会话请求"
+			// @Success      200      {object}  model.APIResponse{data=model.RevokeSessionsResponse}
+			// @Failure      400      {object}  model.APIResponse
+			// @Failure      401      {object}  model.APIResponse
+			// @Failure      404      {object}  model.APIResponse
+			// @Router       /api/v1/auth/sessions/revoke [post]
+			protected.POST("/sessions/revoke", r.sessionHandler.RevokeSession)
+
+			// @Summary      撤销我的所有会话
+			// @Description  撤销当前用户的所有会话，keep_current为true时保留当前会话
+			// @Tags         认证
+			// @Accept       json
+			// @Produce      json
+			// @Security     BearerAuth
+			// @Param        request  body      model.RevokeAllSessionsRequest  false  "撤销全部会话请求"
+			// @Success      200      {object}  model.APIResponse{data=model.RevokeSessionsResponse}
+			// @Failure      400      {object}  model.APIResponse
+			// @Failure      401      {object}  model.APIResponse
+			// @Router       /api/v1/auth/sessions/revoke-all [post]
+			protected.POST("/sessions/revoke-all", r.sessionHandler.RevokeAllSessions)
 		}
 	}
 }
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/capture"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/provider"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptureRouter 请求/响应抓取路由
+type CaptureRouter struct {
+	captureHandler *handler.CaptureHandler
+	jwtService     *jwt.JWTService
+}
+
+// NewCaptureRouter 创建请求/响应抓取路由实例
+func NewCaptureRouter(store store.Store, recorder *capture.Recorder, replayer *provider.Replayer, jwtService *jwt.JWTService) *CaptureRouter {
+	// 创建请求/响应抓取服务
+	captureService := service.NewCaptureService(store, recorder, replayer)
+
+	return &CaptureRouter{
+		captureHandler: handler.NewCaptureHandler(captureService),
+		jwtService:     jwtService,
+	}
+}
+
+// RegisterRoutes 注册请求/响应抓取相关路由
+func (r *CaptureRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 请求抓取路由组，需要JWT认证
+	captureGroup := router.Group("/captures")
+	captureGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	captureGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      列出抓取规则
+		// @Description  列出按服务或API密钥配置的请求/响应抓取规则
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        scope   query     string  false  "作用范围"  Enums(service, apikey)
+		// @Param        target  query     string  false  "作用目标（服务名称或API密钥ID）"
+		// @Success      200     {object}  model.APIResponse{data=[]model.CaptureRule}
+		// @Failure      400     {object}  model.APIResponse
+		// @Failure      401     {object}  model.APIResponse
+		// @Failure      403     {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/rules/list [get]
+		captureGroup.GET("/rules/list", r.captureHandler.ListRules)
+
+		// @Summary      创建抓取规则
+		// @Description  为服务或API密钥开启请求/响应抓取
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      model.CreateCaptureRuleRequest  true  "创建抓取规则请求"
+		// @Success      200      {object}  model.APIResponse{data=model.CaptureRule}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/rules/create [post]
+		captureGroup.POST("/rules/create", r.captureHandler.CreateRule)
+
+		// @Summary      更新抓取规则
+		// @Description  更新抓取规则，未设置的字段保持不变
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      model.UpdateCaptureRuleRequest  true  "更新抓取规则请求"
+		// @Success      200      {object}  model.APIResponse{data=model.CaptureRule}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/rules/update [post]
+		captureGroup.POST("/rules/update", r.captureHandler.UpdateRule)
+
+		// @Summary      删除抓取规则
+		// @Description  删除抓取规则，已抓取的记录保留到各自的过期时间
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.DeleteCaptureRuleRequest  true  "删除抓取规则请求"
+		// @Success      200      {object}  model.APIResponse
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/rules/delete [post]
+		captureGroup.POST("/rules/delete", r.captureHandler.DeleteRule)
+
+		// @Summary      查询抓取记录
+		// @Description  查询未过期的抓取记录，只返回元数据，使用游标分页
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        service_name  query     string  false  "服务名称"
+		// @Param        api_key_id    query     int     false  "API密钥ID"
+		// @Param        user_id       query     int     false  "用户ID"
+		// @Param        request_id    query     string  false  "请求ID"
+		// @Param        start_time    query     string  false  "开始时间（RFC3339，包含）"
+		// @Param        end_time      query     string  false  "结束时间（RFC3339，不包含）"
+		// @Param        cursor        query     int     false  "游标，取上一页返回的next_cursor"
+		// @Param        limit         query     int     false  "每页条数，默认50"  minimum(1)  maximum(500)
+		// @Success      200           {object}  model.APIResponse{data=model.CaptureListResponse}
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/list [get]
+		captureGroup.GET("/list", r.captureHandler.ListCaptures)
+
+		// @Summary      获取抓取记录详情
+		// @Description  获取抓取记录并解密内容，查看操作会记录审计日志
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        id   query     int  true  "抓取记录ID"
+		// @Success      200  {object}  model.APIResponse{data=model.CapturedExchangeDetail}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/detail [get]
+		captureGroup.GET("/detail", r.captureHandler.GetCapture)
+
+		// @Summary      重放抓取记录
+		// @Description  在当前版本的服务上重放抓取记录并与原始响应比较，dry_run时不记录访问日志、不消耗配额
+		// @Tags         请求抓取
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      model.ReplayCaptureRequest  true  "重放抓取记录请求"
+		// @Success      200      {object}  model.APIResponse{data=model.ReplayResult}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/captures/replay [post]
+		captureGroup.POST("/replay", r.captureHandler.ReplayCapture)
+	}
+}
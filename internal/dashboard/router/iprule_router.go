@@ -0,0 +1,81 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPRuleRouter IP访问规则路由
+type IPRuleRouter struct {
+	ipRuleHandler *handler.IPRuleHandler
+	jwtService    *jwt.JWTService
+}
+
+// NewIPRuleRouter 创建IP访问规则路由实例
+func NewIPRuleRouter(store store.Store, ipFilter *middleware.IPFilter, jwtService *jwt.JWTService) *IPRuleRouter {
+	// 创建IP规则服务
+	ipRuleService := service.NewIPRuleService(store, ipFilter)
+
+	return &IPRuleRouter{
+		ipRuleHandler: handler.NewIPRuleHandler(ipRuleService),
+		jwtService:    jwtService,
+	}
+}
+
+// RegisterRoutes 注册IP访问规则相关路由
+func (r *IPRuleRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// IP规则路由组，需要JWT认证，权限在服务层按作用范围检查
+	ipRuleGroup := router.Group("/iprules")
+	ipRuleGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	{
+		// @Summary      列出IP规则
+		// @Description  管理员可查看所有IP规则，普通用户只能查看自己API密钥上的规则
+		// @Tags         IP访问控制
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        scope   query     string  false  "作用范围"  Enums(global, service, apikey)
+		// @Param        target  query     string  false  "作用目标（服务名称或API密钥ID）"
+		// @Success      200     {object}  model.APIResponse{data=[]model.IPRule}
+		// @Failure      400     {object}  model.APIResponse
+		// @Failure      401     {object}  model.APIResponse
+		// @Failure      403     {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/iprules/list [get]
+		ipRuleGroup.GET("/list", r.ipRuleHandler.ListRules)
+
+		// @Summary      创建IP规则
+		// @Description  创建全局、服务或API密钥级别的IP允许/拒绝规则
+		// @Tags         IP访问控制
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      model.CreateIPRuleRequest  true  "创建IP规则请求"
+		// @Success      200      {object}  model.APIResponse{data=model.IPRule}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/iprules/create [post]
+		ipRuleGroup.POST("/create", r.ipRuleHandler.CreateRule)
+
+		// @Summary      删除IP规则
+		// @Description  删除指定的IP规则
+		// @Tags         IP访问控制
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.DeleteIPRuleRequest  true  "删除IP规则请求"
+		// @Success      200      {object}  model.APIResponse
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/iprules/delete [post]
+		ipRuleGroup.POST("/delete", r.ipRuleHandler.DeleteRule)
+	}
+}
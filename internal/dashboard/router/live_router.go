@@ -0,0 +1,68 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/live"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LiveRouter 实时访问流路由
+type LiveRouter struct {
+	liveHandler *handler.LiveHandler
+	jwtService  *jwt.JWTService
+}
+
+// NewLiveRouter 创建实时访问流路由实例
+func NewLiveRouter(hub *live.Hub, jwtService *jwt.JWTService) *LiveRouter {
+	return &LiveRouter{
+		liveHandler: handler.NewLiveHandler(hub),
+		jwtService:  jwtService,
+	}
+}
+
+// RegisterRoutes 注册实时访问流路由
+func (r *LiveRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 实时访问流路由组，需要JWT认证
+	// 浏览器EventSource无法设置请求头，允许通过access_token参数传递令牌
+	liveGroup := router.Group("/live")
+	liveGroup.Use(queryTokenMiddleware())
+	liveGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	liveGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      实时访问流
+		// @Description  以Server-Sent Events推送功能API的每秒汇总帧和抽样原始访问日志
+		// @Tags         实时监控
+		// @Produce      text/event-stream
+		// @Security     BearerAuth
+		// @Param        service       query     string  false  "服务名称"
+		// @Param        user_id       query     int     false  "用户ID"
+		// @Param        status_class  query     string  false  "状态码分类"  Enums(2xx, 3xx, 4xx, 5xx)
+		// @Param        samples       query     int     false  "每秒最多推送的原始事件数，默认10"  minimum(0) maximum(100)
+		// @Param        access_token  query     string  false  "JWT令牌，未设置Authorization请求头时使用"
+		// @Success      200           {object}  model.LiveFrame
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/live [get]
+		liveGroup.GET("", r.liveHandler.Stream)
+	}
+}
+
+// queryTokenMiddleware 未设置Authorization请求头时，使用access_token参数作为Bearer令牌
+func queryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
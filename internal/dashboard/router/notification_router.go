@@ -0,0 +1,64 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationRouter 站内通知路由
+type NotificationRouter struct {
+	notificationHandler *handler.NotificationHandler
+	jwtService          *jwt.JWTService
+}
+
+// NewNotificationRouter 创建站内通知路由实例
+func NewNotificationRouter(store store.Store, jwtService *jwt.JWTService) *NotificationRouter {
+	notificationService := service.NewNotificationService(store)
+
+	return &NotificationRouter{
+		notificationHandler: handler.NewNotificationHandler(notificationService),
+		jwtService:          jwtService,
+	}
+}
+
+// RegisterRoutes 注册站内通知相关路由
+func (r *NotificationRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 通知路由组，需要JWT认证
+	notificationGroup := router.Group("/notifications")
+	notificationGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	{
+		// @Summary      列出我的通知
+		// @Description  按时间倒序列出当前用户的站内通知，同时返回未读通知总数
+		// @Tags         通知
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        unread_only query bool false "只返回未读通知"
+		// @Param        offset query int false "偏移量"
+		// @Param        limit query int false "返回条数，默认20，最大100"
+		// @Success      200  {object}  model.APIResponse{data=model.NotificationListResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/notifications/list [get]
+		notificationGroup.GET("/list", r.notificationHandler.ListNotifications)
+
+		// @Summary      标记通知已读
+		// @Description  将当前用户指定的通知标记为已读，ids为空时标记全部通知
+		// @Tags         通知
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.MarkNotificationsReadRequest true "标记已读请求"
+		// @Success      200  {object}  model.APIResponse{data=model.MarkNotificationsReadResponse}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/notifications/mark-read [post]
+		notificationGroup.POST("/mark-read", r.notificationHandler.MarkRead)
+	}
+}
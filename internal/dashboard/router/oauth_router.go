@@ -0,0 +1,116 @@
+package router
+
+import (
+	"apihub/internal/auth"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthRouter OAuth2客户端路由
+type OAuthRouter struct {
+	oauthHandler *handler.OAuthHandler
+	jwtService   *jwt.JWTService
+}
+
+// NewOAuthRouter 创建OAuth2客户端路由实例
+func NewOAuthRouter(authServices *auth.AuthServices) *OAuthRouter {
+	return &OAuthRouter{
+		oauthHandler: handler.NewOAuthHandler(authServices.OAuthService),
+		jwtService:   authServices.JWTService,
+	}
+}
+
+// RegisterWellKnownRoutes 注册公开的OAuth2令牌端点
+func (r *OAuthRouter) RegisterWellKnownRoutes(router gin.IRoutes) {
+	// @Summary      获取OAuth2访问令牌
+	// @Description  客户端凭据模式，客户端凭据通过HTTP Basic认证或client_id、client_secret参数提供
+	// @Tags         认证
+	// @Accept       x-www-form-urlencoded
+	// @Produce      json
+	// @Param        grant_type formData string true "授权类型，固定为client_credentials"
+	// @Param        scope formData string false "申请的权限范围，以空格分隔"
+	// @Success      200  {object}  model.OAuthTokenResponse
+	// @Failure      400  {object}  model.OAuthErrorResponse
+	// @Failure      401  {object}  model.OAuthErrorResponse
+	// @Router       /oauth/token [post]
+	router.POST("/oauth/token", r.oauthHandler.Token)
+}
+
+// RegisterRoutes 注册OAuth2客户端管理相关路由
+func (r *OAuthRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// OAuth2客户端路由组，需要JWT认证
+	clientGroup := router.Group("/oauth-clients")
+	clientGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	{
+		// @Summary      列出OAuth2客户端
+		// @Description  列出当前用户的所有OAuth2客户端，不包含客户端密钥
+		// @Tags         OAuth2客户端
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=[]model.OAuthClient}
+		// @Failure      401  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/oauth-clients/list [get]
+		clientGroup.GET("/list", r.oauthHandler.ListClients)
+
+		// @Summary      创建OAuth2客户端
+		// @Description  为当前用户创建OAuth2客户端，客户端密钥只在本次响应中返回
+		// @Tags         OAuth2客户端
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.CreateOAuthClientRequest true "创建OAuth2客户端请求"
+		// @Success      200  {object}  model.APIResponse{data=model.OAuthClient}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/oauth-clients/create [post]
+		clientGroup.POST("/create", r.oauthHandler.CreateClient)
+
+		// @Summary      更新OAuth2客户端
+		// @Description  更新当前用户的OAuth2客户端的名称、权限范围或状态
+		// @Tags         OAuth2客户端
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.UpdateOAuthClientRequest true "更新OAuth2客户端请求"
+		// @Success      200  {object}  model.APIResponse{data=model.OAuthClient}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/oauth-clients/update [post]
+		clientGroup.POST("/update", r.oauthHandler.UpdateClient)
+
+		// @Summary      重置OAuth2客户端密钥
+		// @Description  重新生成客户端密钥，原密钥立即失效，新密钥只在本次响应中返回
+		// @Tags         OAuth2客户端
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.OAuthClientIDRequest true "重置客户端密钥请求"
+		// @Success      200  {object}  model.APIResponse{data=model.OAuthClient}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/oauth-clients/reset-secret [post]
+		clientGroup.POST("/reset-secret", r.oauthHandler.ResetClientSecret)
+
+		// @Summary      删除OAuth2客户端
+		// @Description  删除当前用户的OAuth2客户端，已签发的访问令牌随之失效
+		// @Tags         OAuth2客户端
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request body model.OAuthClientIDRequest true "删除OAuth2客户端请求"
+		// @Success      200  {object}  model.APIResponse
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      404  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/oauth-clients/delete [post]
+		clientGroup.POST("/delete", r.oauthHandler.DeleteClient)
+	}
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRouter 限流管理路由
+type RateLimitRouter struct {
+	rateLimitHandler *handler.RateLimitHandler
+	jwtService       *jwt.JWTService
+}
+
+// NewRateLimitRouter 创建限流管理路由实例
+func NewRateLimitRouter(rateLimiter *middleware.RateLimiter, store store.Store, jwtService *jwt.JWTService) *RateLimitRouter {
+	return &RateLimitRouter{
+		rateLimitHandler: handler.NewRateLimitHandler(rateLimiter, store),
+		jwtService:       jwtService,
+	}
+}
+
+// RegisterRoutes 注册限流管理相关路由
+func (r *RateLimitRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 限流管理路由组，需要JWT认证
+	rateLimitGroup := router.Group("/ratelimit")
+	rateLimitGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	rateLimitGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      列出限流器条目
+		// @Description  列出当前内存中所有IP、用户和API密钥的限流状态
+		// @Tags         限流管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=[]middleware.RateLimitEntry}
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/ratelimit/entries [get]
+		rateLimitGroup.GET("/entries", r.rateLimitHandler.ListEntries)
+
+		// @Summary      重置限流条目
+		// @Description  清除指定IP、用户或API密钥当前时间窗口内的请求计数
+		// @Tags         限流管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.ResetRateLimitRequest  true  "重置限流条目请求"
+		// @Success      200      {object}  model.APIResponse
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/ratelimit/reset [post]
+		rateLimitGroup.POST("/reset", r.rateLimitHandler.ResetEntry)
+
+		// @Summary      列出临时限流覆盖
+		// @Description  列出当前实例所有未过期的临时限流覆盖
+		// @Tags         限流管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=[]middleware.RateLimitOverride}
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/ratelimit/overrides [get]
+		rateLimitGroup.GET("/overrides", r.rateLimitHandler.ListOverrides)
+
+		// @Summary      创建临时限流覆盖
+		// @Description  在指定时间内调整当前实例上IP、用户或API密钥的限流值，limit为-1表示解除限流，到期后自动失效。覆盖不持久化，进程重启后丢失，也不在多个实例之间共享
+		// @Tags         限流管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.CreateRateLimitOverrideRequest  true  "创建临时限流覆盖请求"
+		// @Success      200      {object}  model.APIResponse{data=middleware.RateLimitOverride}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/ratelimit/overrides/create [post]
+		rateLimitGroup.POST("/overrides/create", r.rateLimitHandler.CreateOverride)
+
+		// @Summary      删除临时限流覆盖
+		// @Description  提前移除指定IP、用户或API密钥的临时限流覆盖
+		// @Tags         限流管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.DeleteRateLimitOverrideRequest  true  "删除临时限流覆盖请求"
+		// @Success      200      {object}  model.APIResponse
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/ratelimit/overrides/delete [post]
+		rateLimitGroup.POST("/overrides/delete", r.rateLimitHandler.DeleteOverride)
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionRouter 数据保留清理路由
+type RetentionRouter struct {
+	retentionHandler *handler.RetentionHandler
+	jwtService       *jwt.JWTService
+}
+
+// NewRetentionRouter 创建数据保留清理路由实例
+func NewRetentionRouter(job *retention.Job, jwtService *jwt.JWTService) *RetentionRouter {
+	return &RetentionRouter{
+		retentionHandler: handler.NewRetentionHandler(job),
+		jwtService:       jwtService,
+	}
+}
+
+// RegisterRoutes 注册数据保留清理相关路由
+func (r *RetentionRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 数据保留清理路由组，需要JWT认证
+	retentionGroup := router.Group("/retention")
+	retentionGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	retentionGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      获取数据清理任务状态
+		// @Description  获取保留策略、下次执行时间和最近一次执行报告
+		// @Tags         数据保留
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=model.RetentionStatusResponse}
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/retention/status [get]
+		retentionGroup.GET("/status", r.retentionHandler.GetStatus)
+
+		// @Summary      手动执行数据清理
+		// @Description  按保留策略立即清理过期数据，默认等待执行完成并返回执行报告
+		// @Tags         数据保留
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      handler.RunRetentionRequest  false  "执行清理请求"
+		// @Success      200      {object}  model.APIResponse{data=model.RetentionReport}
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      409      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/retention/run [post]
+		retentionGroup.POST("/run", r.retentionHandler.Run)
+	}
+}
@@ -2,7 +2,12 @@ package router
 
 import (
 	"apihub/internal/auth"
+	"apihub/internal/capture"
+	"apihub/internal/live"
+	"apihub/internal/middleware"
 	"apihub/internal/model"
+	"apihub/internal/provider"
+	"apihub/internal/retention"
 	"apihub/internal/store"
 
 	"github.com/gin-gonic/gin"
@@ -37,19 +42,41 @@ import (
 
 // Router 主路由器
 type Router struct {
-	authRouter   *AuthRouter
-	apiKeyRouter *APIKeyRouter
-	userRouter   *UserRouter
-	authServices *auth.AuthServices
+	authRouter         *AuthRouter
+	apiKeyRouter       *APIKeyRouter
+	userRouter         *UserRouter
+	rateLimitRouter    *RateLimitRouter
+	ipRuleRouter       *IPRuleRouter
+	accessLogRouter    *AccessLogRouter
+	usageRouter        *UsageRouter
+	retentionRouter    *RetentionRouter
+	auditLogRouter     *AuditLogRouter
+	liveRouter         *LiveRouter
+	captureRouter      *CaptureRouter
+	signingKeyRouter   *SigningKeyRouter
+	notificationRouter *NotificationRouter
+	oauthRouter        *OAuthRouter
+	authServices       *auth.AuthServices
 }
 
 // NewRouter 创建主路由器实例
-func NewRouter(store store.Store, authServices *auth.AuthServices) *Router {
+func NewRouter(store store.Store, authServices *auth.AuthServices, rateLimiter *middleware.RateLimiter, ipFilter *middleware.IPFilter, retentionJob *retention.Job, liveHub *live.Hub, recorder *capture.Recorder, replayer *provider.Replayer) *Router {
 	return &Router{
-		authRouter:   NewAuthRouter(store, authServices),
-		apiKeyRouter: NewAPIKeyRouter(store, authServices),
-		userRouter:   NewUserRouter(store, authServices.JWTService),
-		authServices: authServices,
+		authRouter:         NewAuthRouter(store, authServices),
+		apiKeyRouter:       NewAPIKeyRouter(store, authServices),
+		userRouter:         NewUserRouter(store, authServices.JWTService),
+		rateLimitRouter:    NewRateLimitRouter(rateLimiter, store, authServices.JWTService),
+		ipRuleRouter:       NewIPRuleRouter(store, ipFilter, authServices.JWTService),
+		accessLogRouter:    NewAccessLogRouter(store, authServices),
+		usageRouter:        NewUsageRouter(store, authServices),
+		retentionRouter:    NewRetentionRouter(retentionJob, authServices.JWTService),
+		auditLogRouter:     NewAuditLogRouter(store, authServices.JWTService),
+		liveRouter:         NewLiveRouter(liveHub, authServices.JWTService),
+		captureRouter:      NewCaptureRouter(store, recorder, replayer, authServices.JWTService),
+		signingKeyRouter:   NewSigningKeyRouter(store, authServices.JWTService),
+		notificationRouter: NewNotificationRouter(store, authServices.JWTService),
+		oauthRouter:        NewOAuthRouter(authServices),
+		authServices:       authServices,
 	}
 }
 
@@ -68,6 +95,57 @@ func (r *Router) UserRouter() *UserRouter {
 	return r.userRouter
 }
 
+// RateLimitRouter 获取限流管理路由器
+func (r *Router) RateLimitRouter() *RateLimitRouter {
+	return r.rateLimitRouter
+}
+
+// IPRuleRouter 获取IP访问规则路由器
+func (r *Router) IPRuleRouter() *IPRuleRouter {
+	return r.ipRuleRouter
+}
+
+// AccessLogRouter 获取访问日志路由器
+func (r *Router) AccessLogRouter() *AccessLogRouter {
+	return r.accessLogRouter
+}
+
+// UsageRouter 获取使用量统计路由器
+func (r *Router) UsageRouter() *UsageRouter {
+	return r.usageRouter
+}
+
+// RetentionRouter 获取数据保留清理路由器
+func (r *Router) RetentionRouter() *RetentionRouter {
+	return r.retentionRouter
+}
+
+// AuditLogRouter 获取审计日志路由器
+func (r *Router) AuditLogRouter() *AuditLogRouter {
+	return r.auditLogRouter
+}
+
+// LiveRouter 获取实时访问流路由器
+func (r *Router) LiveRouter() *LiveRouter {
+	return r.liveRouter
+}
+
+// CaptureRouter 获取请求/响应抓取路由器
+func (r *Router) CaptureRouter() *CaptureRouter {
+	return r.captureRouter
+}
+
+// SigningKeyRouter 获取JWT签名密钥路由器
+func (r *Router) SigningKeyRouter() *SigningKeyRouter {
+	return r.signingKeyRouter
+}
+
+// RegisterWellKnownRoutes 注册根路径下的公开路由，如JWKS和OAuth2令牌端点
+func (r *Router) RegisterWellKnownRoutes(router gin.IRoutes) {
+	r.signingKeyRouter.RegisterWellKnownRoutes(router)
+	r.oauthRouter.RegisterWellKnownRoutes(router)
+}
+
 // SetupRoutes 设置所有路由
 func (r *Router) SetupRoutes() *gin.Engine {
 	// 创建Gin引擎
@@ -81,6 +159,9 @@ func (r *Router) SetupRoutes() *gin.Engine {
 	// Swagger文档路由
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// JWKS公钥和OAuth2令牌端点路由
+	r.RegisterWellKnownRoutes(engine)
+
 	// API版本1路由组
 	v1 := engine.Group("/api/v1")
 	{
@@ -92,6 +173,16 @@ func (r *Router) SetupRoutes() *gin.Engine {
 
 		// Dashboard路由（需要JWT认证）
 		dashboardGroup := v1.Group("/dashboard")
+
+		// 实时访问流路由（需要管理员权限）
+		// 需在Dashboard认证中间件之前注册，以支持通过access_token参数认证
+		r.liveRouter.RegisterRoutes(dashboardGroup)
+
+		// 访问日志和使用统计路由（需要JWT认证或具有dashboard:read权限范围的API密钥）
+		// 需在Dashboard认证中间件之前注册，以支持API密钥认证
+		r.accessLogRouter.RegisterRoutes(dashboardGroup)
+		r.usageRouter.RegisterRoutes(dashboardGroup)
+
 		r.authRouter.RegisterDashboardRoutes(dashboardGroup)
 
 		// API密钥路由（需要JWT认证）
@@ -100,6 +191,30 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		// 用户管理路由（需要JWT认证）
 		r.userRouter.RegisterRoutes(dashboardGroup)
 
+		// 限流管理路由（需要管理员权限）
+		r.rateLimitRouter.RegisterRoutes(dashboardGroup)
+
+		// IP访问规则路由（需要JWT认证）
+		r.ipRuleRouter.RegisterRoutes(dashboardGroup)
+
+		// 数据保留清理路由（需要管理员权限）
+		r.retentionRouter.RegisterRoutes(dashboardGroup)
+
+		// 审计日志路由（需要管理员权限）
+		r.auditLogRouter.RegisterRoutes(dashboardGroup)
+
+		// 请求/响应抓取路由（需要管理员权限）
+		r.captureRouter.RegisterRoutes(dashboardGroup)
+
+		// 签名密钥路由（需要管理员权限）
+		r.signingKeyRouter.RegisterRoutes(dashboardGroup)
+
+		// 站内通知路由（需要JWT认证）
+		r.notificationRouter.RegisterRoutes(dashboardGroup)
+
+		// OAuth2客户端路由（需要JWT认证）
+		r.oauthRouter.RegisterRoutes(dashboardGroup)
+
 		// API路由（支持JWT和APIKey认证）
 		r.authRouter.RegisterAPIRoutes(v1)
 	}
@@ -114,6 +229,16 @@ func (r *Router) SetupSubRoutes(v1 *gin.RouterGroup) {
 
 	// Dashboard路由（需要JWT认证）
 	dashboardGroup := v1.Group("/dashboard")
+
+	// 实时访问流路由（需要管理员权限）
+	// 需在Dashboard认证中间件之前注册，以支持通过access_token参数认证
+	r.liveRouter.RegisterRoutes(dashboardGroup)
+
+	// 访问日志和使用统计路由（需要JWT认证或具有dashboard:read权限范围的API密钥）
+	// 需在Dashboard认证中间件之前注册，以支持API密钥认证
+	r.accessLogRouter.RegisterRoutes(dashboardGroup)
+	r.usageRouter.RegisterRoutes(dashboardGroup)
+
 	r.authRouter.RegisterDashboardRoutes(dashboardGroup)
 
 	// API密钥路由（需要JWT认证）
@@ -122,6 +247,30 @@ func (r *Router) SetupSubRoutes(v1 *gin.RouterGroup) {
 	// 用户管理路由（需要JWT认证）
 	r.userRouter.RegisterRoutes(dashboardGroup)
 
+	// 限流管理路由（需要管理员权限）
+	r.rateLimitRouter.RegisterRoutes(dashboardGroup)
+
+	// IP访问规则路由（需要JWT认证）
+	r.ipRuleRouter.RegisterRoutes(dashboardGroup)
+
+	// 数据保留清理路由（需要管理员权限）
+	r.retentionRouter.RegisterRoutes(dashboardGroup)
+
+	// 审计日志路由（需要管理员权限）
+	r.auditLogRouter.RegisterRoutes(dashboardGroup)
+
+	// 请求/响应抓取路由（需要管理员权限）
+	r.captureRouter.RegisterRoutes(dashboardGroup)
+
+	// 签名密钥路由（需要管理员权限）
+	r.signingKeyRouter.RegisterRoutes(dashboardGroup)
+
+	// 站内通知路由（需要JWT认证）
+	r.notificationRouter.RegisterRoutes(dashboardGroup)
+
+	// OAuth2客户端路由（需要JWT认证）
+	r.oauthRouter.RegisterRoutes(dashboardGroup)
+
 	// API路由（支持JWT和APIKey认证）
 	r.authRouter.RegisterAPIRoutes(v1)
 }
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"apihub/internal/auth/jwt"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SigningKeyRouter JWT签名密钥路由
+type SigningKeyRouter struct {
+	signingKeyHandler *handler.SigningKeyHandler
+	jwtService        *jwt.JWTService
+}
+
+// NewSigningKeyRouter 创建JWT签名密钥路由实例
+func NewSigningKeyRouter(store store.Store, jwtService *jwt.JWTService) *SigningKeyRouter {
+	// 创建签名密钥管理服务
+	signingKeyService := service.NewSigningKeyService(store, jwtService.Keys())
+
+	return &SigningKeyRouter{
+		signingKeyHandler: handler.NewSigningKeyHandler(signingKeyService),
+		jwtService:        jwtService,
+	}
+}
+
+// RegisterWellKnownRoutes 注册公开的JWKS路由
+func (r *SigningKeyRouter) RegisterWellKnownRoutes(router gin.IRoutes) {
+	// @Summary      获取JWT公钥集合
+	// @Description  以JWKS格式发布所有可用于验证APIHub令牌的公钥
+	// @Tags         认证
+	// @Produce      json
+	// @Success      200  {object}  model.JWKS
+	// @Router       /.well-known/jwks.json [get]
+	router.GET("/.well-known/jwks.json", r.signingKeyHandler.JWKS)
+}
+
+// RegisterRoutes 注册签名密钥管理相关路由
+func (r *SigningKeyRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 签名密钥路由组，需要JWT认证
+	signingKeyGroup := router.Group("/signing-keys")
+	signingKeyGroup.Use(middleware.JWTOnlyMiddleware(r.jwtService))
+
+	// 添加管理员角色检查中间件
+	signingKeyGroup.Use(jwt.RequireRole(model.RoleAdmin))
+
+	{
+		// @Summary      获取签名密钥列表
+		// @Description  获取所有可用于验证的JWT签名密钥及其状态
+		// @Tags         签名密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=[]model.SigningKeyInfo}
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/signing-keys/list [get]
+		signingKeyGroup.GET("/list", r.signingKeyHandler.ListKeys)
+
+		// @Summary      立即轮换签名密钥
+		// @Description  生成新的签名密钥并立即用于签名，已签发的令牌在重叠期内仍可验证
+		// @Tags         签名密钥
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Success      200  {object}  model.APIResponse{data=[]model.SigningKeyInfo}
+		// @Failure      400  {object}  model.APIResponse
+		// @Failure      401  {object}  model.APIResponse
+		// @Failure      403  {object}  model.APIResponse
+		// @Failure      500  {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/signing-keys/rotate [post]
+		signingKeyGroup.POST("/rotate", r.signingKeyHandler.RotateKey)
+	}
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"apihub/internal/auth"
+	"apihub/internal/auth/apikey"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/auth/permission"
+	"apihub/internal/dashboard/handler"
+	"apihub/internal/dashboard/service"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageRouter 使用量统计路由
+type UsageRouter struct {
+	usageHandler      *handler.UsageHandler
+	jwtService        *jwt.JWTService
+	apiKeyService     *apikey.APIKeyService
+	permissionService *permission.PermissionService
+}
+
+// NewUsageRouter 创建使用量统计路由实例
+func NewUsageRouter(store store.Store, authServices *auth.AuthServices) *UsageRouter {
+	// 创建使用量统计服务
+	usageService := service.NewUsageService(store)
+
+	return &UsageRouter{
+		usageHandler:      handler.NewUsageHandler(usageService),
+		jwtService:        authServices.JWTService,
+		apiKeyService:     authServices.APIKeyService,
+		permissionService: authServices.PermissionService,
+	}
+}
+
+// RegisterRoutes 注册使用量统计相关路由
+func (r *UsageRouter) RegisterRoutes(router *gin.RouterGroup) {
+	// 使用统计路由组，需要JWT认证或具有dashboard:read权限范围的API密钥，普通用户只能查看自己的统计
+	usageGroup := router.Group("/usage")
+	usageGroup.Use(middleware.JWTOrAPIKeyScopeMiddleware(r.jwtService, r.apiKeyService, model.APIKeyScopeDashboardRead))
+	usageGroup.Use(permission.RequirePermissionMiddleware(r.permissionService, permission.PermReadAccessLog))
+
+	{
+		// @Summary      查询使用量统计
+		// @Description  按小时/天/月粒度查询使用量，可按服务、API密钥、状态码分类和用户分组
+		// @Tags         使用统计
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Security     ApiKeyAuth
+		// @Param        granularity   query     string    false  "统计粒度，默认day"  Enums(hour, day, month)
+		// @Param        start_time    query     string    false  "开始时间（RFC3339）"
+		// @Param        end_time      query     string    false  "结束时间（RFC3339）"
+		// @Param        user_id       query     int       false  "用户ID（仅管理员可指定其他用户）"
+		// @Param        api_key_id    query     int       false  "API密钥ID"
+		// @Param        service_name  query     string    false  "服务名称"
+		// @Param        group_by      query     []string  false  "分组维度"  collectionFormat(multi)
+		// @Success      200           {object}  model.APIResponse{data=model.UsageStatsQueryResponse}
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/usage/stats [get]
+		usageGroup.GET("/stats", r.usageHandler.QueryStats)
+
+		// @Summary      获取使用概况
+		// @Description  获取单个用户按天的使用概况，默认最近30天
+		// @Tags         使用统计
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Security     ApiKeyAuth
+		// @Param        user_id       query     int     false  "用户ID（仅管理员可指定其他用户）"
+		// @Param        service_name  query     string  false  "服务名称"
+		// @Param        start_date    query     string  false  "开始日期（YYYY-MM-DD）"
+		// @Param        end_date      query     string  false  "结束日期（YYYY-MM-DD）"
+		// @Success      200           {object}  model.APIResponse{data=model.UsageStatsResponse}
+		// @Failure      400           {object}  model.APIResponse
+		// @Failure      401           {object}  model.APIResponse
+		// @Failure      403           {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/usage/summary [get]
+		usageGroup.GET("/summary", r.usageHandler.GetSummary)
+	}
+}
@@ -13,21 +13,23 @@ import (
 
 // UserRouter 用户路由
 type UserRouter struct {
-	userHandler *handler.UserHandler
-	jwtService  *jwt.JWTService
+	userHandler    *handler.UserHandler
+	sessionHandler *handler.SessionHandler
+	jwtService     *jwt.JWTService
 }
 
 // NewUserRouter 创建用户路由实例
 func NewUserRouter(store store.Store, jwtService *jwt.JWTService) *UserRouter {
 	// 创建用户服务
-	userService := service.NewUserService(store)
+	userService := service.NewUserService(store, jwtService)
 
 	// 创建用户处理器
 	userHandler := handler.NewUserHandler(userService)
 
 	return &UserRouter{
-		userHandler: userHandler,
-		jwtService:  jwtService,
+		userHandler:    userHandler,
+		sessionHandler: handler.NewSessionHandler(service.NewSessionService(store, jwtService)),
+		jwtService:     jwtService,
 	}
 }
 
@@ -116,7 +118,7 @@ func (r *UserRouter) RegisterRoutes(router *gin.RouterGroup) {
 		userGroup.POST("/delete", r.userHandler.DeleteUser)
 
 		// @Summary      重置用户密码
-		// @Description  重置指定用户的密码
+		// @Description  重置指定用户的密码，同时撤销该用户的所有会话
 		// @Tags         用户管理
 		// @Accept       json
 		// @Produce      json
@@ -128,5 +130,34 @@ func (r *UserRouter) RegisterRoutes(router *gin.RouterGroup) {
 		// @Failure      403      {object}  model.APIResponse
 		// @Router       /api/v1/dashboard/user/reset-password [post]
 		userGroup.POST("/reset-password", r.userHandler.ResetPassword)
+
+		// @Summary      查看用户会话
+		// @Description  查看指定用户未撤销且未过期的登录会话
+		// @Tags         用户管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        user_id  query     int  true  "用户ID"
+		// @Success      200      {object}  model.APIResponse{data=[]model.SessionInfo}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/user/sessions [get]
+		userGroup.GET("/sessions", r.sessionHandler.ListUserSessions)
+
+		// @Summary      强制用户下线
+		// @Description  撤销指定用户的所有会话，其访问令牌和刷新令牌立即失效
+		// @Tags         用户管理
+		// @Accept       json
+		// @Produce      json
+		// @Security     BearerAuth
+		// @Param        request  body      model.ForceLogoutRequest  true  "强制下线请求"
+		// @Success      200      {object}  model.APIResponse{data=model.RevokeSessionsResponse}
+		// @Failure      400      {object}  model.APIResponse
+		// @Failure      401      {object}  model.APIResponse
+		// @Failure      403      {object}  model.APIResponse
+		// @Failure      404      {object}  model.APIResponse
+		// @Router       /api/v1/dashboard/user/force-logout [post]
+		userGroup.POST("/force-logout", r.sessionHandler.ForceLogout)
 	}
 }
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 访问日志查询默认每页条数
+const defaultAccessLogPageSize = 50
+
+// ErrAccessLogForbidden 无权查看其他用户的访问日志
+var ErrAccessLogForbidden = errors.New("无权查看其他用户的访问日志")
+
+// AccessLogService 访问日志服务
+type AccessLogService struct {
+	store store.Store
+}
+
+// NewAccessLogService 创建访问日志服务实例
+func NewAccessLogService(store store.Store) *AccessLogService {
+	return &AccessLogService{
+		store: store,
+	}
+}
+
+// QueryLogs 分页查询访问日志
+func (s *AccessLogService) QueryLogs(ctx context.Context, userID int, isAdmin bool, filter model.AccessLogFilter) (*model.AccessLogListResponse, error) {
+	if err := scopeAccessLogFilter(&filter, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAccessLogPageSize
+	}
+
+	// 多取一条用于判断是否还有下一页
+	filter.Limit = limit + 1
+	logs, err := s.store.AccessLogs().Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.AccessLogListResponse{Items: logs}
+	if len(logs) > limit {
+		response.Items = logs[:limit]
+		response.NextCursor = logs[limit-1].ID
+	}
+
+	return response, nil
+}
+
+// ExportLogs 按条件逐条导出访问日志
+func (s *AccessLogService) ExportLogs(ctx context.Context, userID int, isAdmin bool, filter model.AccessLogFilter, fn func(*model.AccessLog) error) error {
+	if err := scopeAccessLogFilter(&filter, userID, isAdmin); err != nil {
+		return err
+	}
+
+	filter.Limit = 0
+	return s.store.AccessLogs().Iterate(ctx, filter, fn)
+}
+
+// scopeAccessLogFilter 限制普通用户只能查询自己的访问日志
+func scopeAccessLogFilter(filter *model.AccessLogFilter, userID int, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	if filter.UserID != 0 && filter.UserID != userID {
+		return ErrAccessLogForbidden
+	}
+	filter.UserID = userID
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 审计日志查询默认每页条数
+const defaultAuditLogPageSize = 50
+
+// errAuditChainBroken 哈希链校验失败，用于提前结束遍历
+var errAuditChainBroken = errors.New("审计日志哈希链校验失败")
+
+// AuditLogService 审计日志服务
+type AuditLogService struct {
+	store store.Store
+}
+
+// NewAuditLogService 创建审计日志服务实例
+func NewAuditLogService(store store.Store) *AuditLogService {
+	return &AuditLogService{
+		store: store,
+	}
+}
+
+// QueryLogs 分页查询审计日志
+func (s *AuditLogService) QueryLogs(ctx context.Context, filter model.AuditLogFilter) (*model.AuditLogListResponse, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLogPageSize
+	}
+
+	// 多取一条用于判断是否还有下一页
+	filter.Limit = limit + 1
+	logs, err := s.store.AuditLogs().Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.AuditLogListResponse{Items: logs}
+	if len(logs) > limit {
+		response.Items = logs[:limit]
+		response.NextCursor = logs[limit-1].ID
+	}
+
+	return response, nil
+}
+
+// ExportLogs 按条件逐条导出审计日志
+func (s *AuditLogService) ExportLogs(ctx context.Context, filter model.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	filter.Limit = 0
+	return s.store.AuditLogs().Iterate(ctx, filter, fn)
+}
+
+// VerifyChain 从第一条记录开始校验哈希链
+// 每条记录的prev_hash必须等于上一条记录的hash，且hash与重新计算的结果一致
+func (s *AuditLogService) VerifyChain(ctx context.Context) (*model.AuditVerifyResponse, error) {
+	result := &model.AuditVerifyResponse{Valid: true}
+
+	err := s.store.AuditLogs().Walk(ctx, func(log *model.AuditLog) error {
+		switch {
+		case log.PrevHash != result.LastHash:
+			result.Reason = "prev_hash与上一条记录的hash不一致，记录可能被删除或插入"
+		case log.Hash != log.ComputeHash():
+			result.Reason = "hash与记录内容不一致，记录可能被修改"
+		default:
+			result.Checked++
+			result.LastID = log.ID
+			result.LastHash = log.Hash
+			return nil
+		}
+
+		result.Valid = false
+		result.BrokenID = log.ID
+		return errAuditChainBroken
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+
+	return result, nil
+}
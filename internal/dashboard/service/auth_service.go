@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"strconv"
 	"time"
 
+	"apihub/internal/audit"
 	"apihub/internal/auth/jwt"
 	"apihub/internal/model"
 	"apihub/internal/store"
@@ -12,6 +15,15 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// 刷新令牌相关错误
+var (
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，该登录会话已被撤销，请重新登录")
+)
+
+// errRefreshTokenRace 并发刷新时令牌已被其他请求使用
+var errRefreshTokenRace = errors.New("refresh token already used")
+
 // AuthService 认证服务
 type AuthService struct {
 	store      store.Store
@@ -27,7 +39,8 @@ func NewAuthService(store store.Store, jwtService *jwt.JWTService) *AuthService
 }
 
 // Login 用户登录
-func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
+// clientIP和userAgent记录在会话中，显示在会话列表里
+func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest, clientIP, userAgent string) (*model.LoginResponse, error) {
 	// 根据用户名查找用户
 	user, err := s.store.Users().GetByUsername(ctx, req.Username)
 	if err != nil {
@@ -45,31 +58,183 @@ func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		return nil, errors.New("用户名或密码错误")
 	}
 
-	// 生成JWT Token
-	tokenResponse, err := s.jwtService.GenerateToken(user)
+	// 创建登录会话，生成访问令牌和刷新令牌
+	sessionID, err := jwt.NewSessionID()
 	if err != nil {
 		return nil, errors.New("生成Token失败")
 	}
 
-	// 构造响应
-	response := &model.LoginResponse{
-		AccessToken: tokenResponse.AccessToken,
-		ExpiresIn:   tokenResponse.ExpiresIn,
-		TokenType:   "Bearer",
-		User:        user.ToUserInfo(),
+	var response *model.LoginResponse
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		err := tx.Sessions().Create(ctx, &model.Session{
+			ID:        sessionID,
+			UserID:    user.ID,
+			Device:    req.Device,
+			ClientIP:  clientIP,
+			UserAgent: userAgent,
+			ExpiresAt: time.Now().Add(s.jwtService.RefreshExpiry()),
+		})
+		if err != nil {
+			return err
+		}
+		response, err = s.issueTokens(ctx, tx, user, sessionID, 0)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New("生成Token失败")
+	}
+
+	return response, nil
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌和刷新令牌
+// 刷新令牌只能使用一次，已使用的令牌再次出现说明可能已泄露，此时撤销令牌所属的会话
+func (s *AuthService) Refresh(ctx context.Context, req *model.RefreshTokenRequest, clientIP, userAgent string) (*model.LoginResponse, error) {
+	token, err := s.store.RefreshTokens().GetByHash(ctx, jwt.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case token.RevokedAt != nil:
+		return nil, ErrInvalidRefreshToken
+	case token.UsedAt != nil:
+		s.revokeReusedSession(ctx, token)
+		return nil, ErrRefreshTokenReused
+	case !now.Before(token.ExpiresAt):
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 用户已删除或已禁用时撤销该会话
+	user, err := s.store.Users().GetByID(ctx, token.UserID)
+	if err != nil || user.Status != model.UserStatusActive {
+		err := store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+			return revokeSession(ctx, tx, token.FamilyID, model.SessionRevokeUserDisabled, now)
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "撤销会话失败", "session_id", token.FamilyID, "error", err)
+		}
+		s.jwtService.InvalidateSessions(token.FamilyID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 标记旧令牌已使用、签发同一会话的新令牌并延长会话有效期，在同一事务中完成
+	var response *model.LoginResponse
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		used, err := tx.RefreshTokens().MarkUsed(ctx, token.ID, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errRefreshTokenRace
+		}
+		response, err = s.issueTokens(ctx, tx, user, token.FamilyID, token.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Sessions().Extend(ctx, token.FamilyID, now.Add(s.jwtService.RefreshExpiry())); err != nil {
+			return err
+		}
+		return tx.Sessions().Touch(ctx, token.FamilyID, now, clientIP, userAgent)
+	})
+	if errors.Is(err, errRefreshTokenRace) {
+		// 并发请求已使用同一令牌，同样视为重复使用
+		s.revokeReusedSession(ctx, token)
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	s.jwtService.InvalidateSessions(token.FamilyID)
 	return response, nil
 }
 
-// Logout 用户登出
+// issueTokens 为会话签发访问令牌和刷新令牌
+func (s *AuthService) issueTokens(ctx context.Context, tx store.Transaction, user *model.User, sessionID string, parentID int) (*model.LoginResponse, error) {
+	refreshToken, tokenHash, err := jwt.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiry := s.jwtService.RefreshExpiry()
+	err = tx.RefreshTokens().Create(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: tokenHash,
+		ParentID:  parentID,
+		ExpiresAt: time.Now().Add(refreshExpiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResponse, err := s.jwtService.GenerateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		AccessToken:      tokenResponse.AccessToken,
+		ExpiresIn:        tokenResponse.ExpiresIn,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int64(refreshExpiry.Seconds()),
+		TokenType:        "Bearer",
+		User:             user.ToUserInfo(),
+	}, nil
+}
+
+// revokeReusedSession 检测到刷新令牌重复使用时撤销令牌所属的会话并记录审计日志
+func (s *AuthService) revokeReusedSession(ctx context.Context, token *model.RefreshToken) {
+	slog.WarnContext(ctx, "检测到刷新令牌重复使用，撤销会话",
+		"user_id", token.UserID, "session_id", token.FamilyID, "token_id", token.ID)
+
+	now := time.Now()
+	err := store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		// 会话可能已被撤销，仍需撤销其余刷新令牌
+		if err := tx.Sessions().Revoke(ctx, token.FamilyID, model.SessionRevokeReuse, now); err != nil {
+			var dbErr *store.DBError
+			if !errors.As(err, &dbErr) || dbErr.Code != store.ErrNotFound {
+				return err
+			}
+		}
+		revoked, err := tx.RefreshTokens().RevokeFamily(ctx, token.FamilyID, model.SessionRevokeReuse, now)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionRefreshTokenReuse,
+			model.AuditTargetUser, strconv.Itoa(token.UserID), nil, map[string]any{
+				"session_id": token.FamilyID,
+				"token_id":   token.ID,
+				"revoked":    revoked,
+			})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "撤销会话失败", "session_id", token.FamilyID, "error", err)
+	}
+	s.jwtService.InvalidateSessions(token.FamilyID)
+}
+
+// Logout 用户登出，撤销令牌所属的会话及其刷新令牌
 func (s *AuthService) Logout(ctx context.Context, tokenString string) (*model.LogoutResponse, error) {
-	// 撤销Token（加入黑名单）
-	err := s.jwtService.RevokeToken(tokenString)
+	claims, err := s.jwtService.ValidateToken(tokenString)
 	if err != nil {
 		return nil, errors.New("登出失败")
 	}
 
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		return revokeSession(ctx, tx, claims.ID, model.SessionRevokeLogout, time.Now())
+	})
+	if err != nil {
+		return nil, errors.New("登出失败")
+	}
+	s.jwtService.InvalidateSessions(claims.ID)
+
 	// 构造响应
 	response := &model.LogoutResponse{
 		Message: "登出成功",
@@ -96,6 +261,8 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID int, req *model.
 		return nil, errors.New("用户不存在")
 	}
 
+	before := *user
+
 	// 更新邮箱（如果提供）
 	if req.Email != "" && req.Email != user.Email {
 		// 检查邮箱是否已被其他用户使用
@@ -109,8 +276,14 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID int, req *model.
 	// 更新时间
 	user.UpdatedAt = time.Now()
 
-	// 保存到数据库
-	err = s.store.Users().Update(ctx, user)
+	// 保存到数据库，同时记录审计日志
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionProfileUpdate,
+			model.AuditTargetUser, strconv.Itoa(userID), &before, user)
+	})
 	if err != nil {
 		return nil, errors.New("更新个人资料失败: " + err.Error())
 	}
@@ -147,11 +320,24 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID int, req *model
 	user.Password = string(hashedPassword)
 	user.UpdatedAt = time.Now()
 
-	// 保存到数据库
-	err = s.store.Users().Update(ctx, user)
+	// 保存到数据库并撤销该用户所有会话，同时记录审计日志（不记录密码内容）
+	var revokedSessions []string
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		ids, err := revokeUserSessions(ctx, tx, userID, "", model.SessionRevokePasswordChange, time.Now())
+		if err != nil {
+			return err
+		}
+		revokedSessions = ids
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionPasswordChange,
+			model.AuditTargetUser, strconv.Itoa(userID), nil, map[string]string{"password": audit.Redacted})
+	})
 	if err != nil {
 		return errors.New("修改密码失败: " + err.Error())
 	}
+	s.jwtService.InvalidateSessions(revokedSessions...)
 
 	return nil
 }
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"apihub/internal/audit"
+	"apihub/internal/capture"
+	"apihub/internal/model"
+	"apihub/internal/provider"
+	"apihub/internal/store"
+)
+
+// 抓取服务错误
+var (
+	ErrCaptureRuleNotFound = errors.New("抓取规则不存在")
+	ErrCaptureNotFound     = errors.New("抓取记录不存在或已过期")
+)
+
+// 抓取记录查询默认每页条数
+const defaultCapturePageSize = 50
+
+// CaptureService 请求/响应抓取服务
+type CaptureService struct {
+	store    store.Store
+	recorder *capture.Recorder
+	replayer *provider.Replayer
+}
+
+// NewCaptureService 创建请求/响应抓取服务实例
+func NewCaptureService(store store.Store, recorder *capture.Recorder, replayer *provider.Replayer) *CaptureService {
+	return &CaptureService{
+		store:    store,
+		recorder: recorder,
+		replayer: replayer,
+	}
+}
+
+// ListRules 获取抓取规则列表
+func (s *CaptureService) ListRules(ctx context.Context, scope, target string) ([]*model.CaptureRule, error) {
+	return s.store.CaptureRules().List(ctx, scope, target)
+}
+
+// CreateRule 创建抓取规则
+func (s *CaptureService) CreateRule(ctx context.Context, userID int, req *model.CreateCaptureRuleRequest) (*model.CaptureRule, error) {
+	target, err := s.validateTarget(ctx, req.Scope, req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &model.CaptureRule{
+		Scope:           req.Scope,
+		Target:          target,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		SampleRate:      req.SampleRate,
+		MaxBodyBytes:    req.MaxBodyBytes,
+		RedactHeaders:   nonNilStrings(req.RedactHeaders),
+		RedactJSONPaths: nonNilStrings(req.RedactJSONPaths),
+		RedactPatterns:  nonNilStrings(req.RedactPatterns),
+		RetentionHours:  req.RetentionHours,
+		Replayable:      req.Replayable,
+		Description:     req.Description,
+		CreatedBy:       userID,
+	}
+	if err := s.recorder.Validate(rule); err != nil {
+		return nil, err
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.CaptureRules().Create(ctx, rule); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionCaptureRuleCreate,
+			model.AuditTargetCaptureRule, strconv.Itoa(rule.ID), nil, rule)
+	})
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrDuplicateKey {
+			return nil, errors.New("该作用目标已存在抓取规则")
+		}
+		return nil, err
+	}
+
+	s.reloadRecorder(ctx)
+	return rule, nil
+}
+
+// UpdateRule 更新抓取规则
+func (s *CaptureService) UpdateRule(ctx context.Context, req *model.UpdateCaptureRuleRequest) (*model.CaptureRule, error) {
+	before, err := s.store.CaptureRules().GetByID(ctx, req.RuleID)
+	if err != nil {
+		return nil, ErrCaptureRuleNotFound
+	}
+
+	rule := *before
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.SampleRate != nil {
+		rule.SampleRate = *req.SampleRate
+	}
+	if req.MaxBodyBytes != nil {
+		rule.MaxBodyBytes = *req.MaxBodyBytes
+	}
+	if req.RedactHeaders != nil {
+		rule.RedactHeaders = nonNilStrings(*req.RedactHeaders)
+	}
+	if req.RedactJSONPaths != nil {
+		rule.RedactJSONPaths = nonNilStrings(*req.RedactJSONPaths)
+	}
+	if req.RedactPatterns != nil {
+		rule.RedactPatterns = nonNilStrings(*req.RedactPatterns)
+	}
+	if req.RetentionHours != nil {
+		rule.RetentionHours = *req.RetentionHours
+	}
+	if req.Replayable != nil {
+		rule.Replayable = *req.Replayable
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if err := s.recorder.Validate(&rule); err != nil {
+		return nil, err
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.CaptureRules().Update(ctx, &rule); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionCaptureRuleUpdate,
+			model.AuditTargetCaptureRule, strconv.Itoa(rule.ID), before, &rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadRecorder(ctx)
+	return &rule, nil
+}
+
+// DeleteRule 删除抓取规则，已抓取的记录保留到各自的过期时间
+func (s *CaptureService) DeleteRule(ctx context.Context, ruleID int) error {
+	rule, err := s.store.CaptureRules().GetByID(ctx, ruleID)
+	if err != nil {
+		return ErrCaptureRuleNotFound
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.CaptureRules().Delete(ctx, ruleID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionCaptureRuleDelete,
+			model.AuditTargetCaptureRule, strconv.Itoa(ruleID), rule, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reloadRecorder(ctx)
+	return nil
+}
+
+// QueryCaptures 分页查询抓取记录，只返回元数据
+func (s *CaptureService) QueryCaptures(ctx context.Context, filter model.CaptureFilter) (*model.CaptureListResponse, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultCapturePageSize
+	}
+
+	// 多取一条用于判断是否还有下一页
+	filter.Limit = limit + 1
+	exchanges, err := s.store.Captures().Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.CaptureListResponse{Items: exchanges}
+	if len(exchanges) > limit {
+		response.Items = exchanges[:limit]
+		response.NextCursor = exchanges[limit-1].ID
+	}
+
+	return response, nil
+}
+
+// GetCapture 获取抓取记录详情并解密内容
+// 查看内容属于敏感操作，记录审计日志，审计日志写入失败时不返回内容
+func (s *CaptureService) GetCapture(ctx context.Context, id int) (*model.CapturedExchangeDetail, error) {
+	exchange, err := s.store.Captures().GetByID(ctx, id)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, ErrCaptureNotFound
+		}
+		return nil, err
+	}
+
+	payload, err := s.recorder.Decrypt(exchange)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit.Record(ctx, s.store.AuditLogs(), model.AuditActionCaptureView,
+		model.AuditTargetCapture, strconv.Itoa(exchange.ID), nil, nil); err != nil {
+		return nil, err
+	}
+
+	return &model.CapturedExchangeDetail{
+		CapturedExchange: exchange,
+		Payload:          payload,
+	}, nil
+}
+
+// ReplayCapture 在当前版本的服务上重放抓取记录，并与原始响应比较
+// 重放会再次执行原调用方的请求，先记录审计日志，审计日志写入失败时不执行重放
+func (s *CaptureService) ReplayCapture(ctx context.Context, req *model.ReplayCaptureRequest) (*model.ReplayResult, error) {
+	comparer, err := capture.NewComparer(req.IgnorePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange, err := s.store.Captures().GetByID(ctx, req.CaptureID)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return nil, ErrCaptureNotFound
+		}
+		return nil, err
+	}
+
+	request, err := s.recorder.DecryptReplay(exchange)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := s.recorder.Decrypt(exchange)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := s.recorder.Redactor(ctx, exchange)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit.Record(ctx, s.store.AuditLogs(), model.AuditActionCaptureReplay,
+		model.AuditTargetCapture, strconv.Itoa(exchange.ID), nil, map[string]any{"dry_run": req.DryRun}); err != nil {
+		return nil, err
+	}
+
+	response, err := s.replayer.Replay(ctx, exchange, request, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重放响应按原规则脱敏后再与同样脱敏过的原始响应比较
+	result := &model.ReplayResult{
+		CaptureID:       exchange.ID,
+		ServiceName:     exchange.ServiceName,
+		DryRun:          req.DryRun,
+		OriginalStatus:  exchange.Status,
+		Status:          response.Status,
+		DurationMs:      response.Duration.Milliseconds(),
+		ResponseHeaders: redactor.Headers(response.Header),
+		ResponseBody:    redactor.Body(response.Body),
+		Differences:     make([]*model.ReplayDifference, 0),
+	}
+	if exchange.ResponseTruncated {
+		result.Note = "原始响应体被截断，未比较响应体"
+	} else {
+		result.Differences = comparer.Diff(payload.ResponseBody, result.ResponseBody)
+	}
+	result.Identical = result.Status == result.OriginalStatus && len(result.Differences) == 0
+
+	return result, nil
+}
+
+// validateTarget 校验规则作用目标并返回规范化后的目标
+func (s *CaptureService) validateTarget(ctx context.Context, scope, target string) (string, error) {
+	switch scope {
+	case model.CaptureScopeService:
+		if _, err := s.store.Services().GetByName(ctx, target); err != nil {
+			return "", errors.New("服务不存在: " + target)
+		}
+		return target, nil
+
+	case model.CaptureScopeAPIKey:
+		keyID, err := strconv.Atoi(target)
+		if err != nil || keyID <= 0 {
+			return "", errors.New("无效的API密钥ID: " + target)
+		}
+		if _, err := s.store.APIKeys().GetByID(ctx, keyID); err != nil {
+			return "", errors.New("API密钥不存在: " + target)
+		}
+		return strconv.Itoa(keyID), nil
+
+	default:
+		return "", errors.New("无效的规则作用范围: " + scope)
+	}
+}
+
+// reloadRecorder 规则变更后刷新内存中的抓取规则
+func (s *CaptureService) reloadRecorder(ctx context.Context) {
+	if err := s.recorder.Reload(ctx); err != nil {
+		slog.ErrorContext(ctx, "刷新抓取规则失败", "error", err)
+	}
+}
+
+// nonNilStrings 将nil切片转换为空切片，保证序列化结果为[]
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return make([]string, 0)
+	}
+	return values
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"apihub/internal/audit"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// IP规则服务错误
+var (
+	ErrIPRuleNotFound  = errors.New("IP规则不存在")
+	ErrIPRuleForbidden = errors.New("无权操作此IP规则")
+)
+
+// IPRuleService IP访问规则服务
+type IPRuleService struct {
+	store    store.Store
+	ipFilter *middleware.IPFilter
+}
+
+// NewIPRuleService 创建IP访问规则服务实例
+func NewIPRuleService(store store.Store, ipFilter *middleware.IPFilter) *IPRuleService {
+	return &IPRuleService{
+		store:    store,
+		ipFilter: ipFilter,
+	}
+}
+
+// ListRules 获取IP规则列表
+// 管理员可查看所有规则，普通用户只能查看自己API密钥上的规则
+func (s *IPRuleService) ListRules(ctx context.Context, userID int, isAdmin bool, scope, target string) ([]*model.IPRule, error) {
+	if isAdmin {
+		return s.store.IPRules().List(ctx, scope, target)
+	}
+
+	if scope != "" && scope != model.IPRuleScopeAPIKey {
+		return nil, ErrIPRuleForbidden
+	}
+
+	apiKeys, err := s.store.APIKeys().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*model.IPRule, 0)
+	for _, apiKey := range apiKeys {
+		keyID := strconv.Itoa(apiKey.ID)
+		if target != "" && target != keyID {
+			continue
+		}
+
+		keyRules, err := s.store.IPRules().List(ctx, model.IPRuleScopeAPIKey, keyID)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, keyRules...)
+	}
+
+	return rules, nil
+}
+
+// CreateRule 创建IP规则
+func (s *IPRuleService) CreateRule(ctx context.Context, userID int, isAdmin bool, req *model.CreateIPRuleRequest) (*model.IPRule, error) {
+	cidr, err := model.NormalizeCIDR(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.validateTarget(ctx, userID, isAdmin, req.Scope, req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &model.IPRule{
+		Scope:       req.Scope,
+		Target:      target,
+		Action:      req.Action,
+		CIDR:        cidr,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.IPRules().Create(ctx, rule); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionIPRuleCreate,
+			model.AuditTargetIPRule, strconv.Itoa(rule.ID), nil, rule)
+	})
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrDuplicateKey {
+			return nil, errors.New("相同的IP规则已存在")
+		}
+		return nil, err
+	}
+
+	s.reloadFilter(ctx)
+	return rule, nil
+}
+
+// DeleteRule 删除IP规则
+func (s *IPRuleService) DeleteRule(ctx context.Context, userID int, isAdmin bool, ruleID int) error {
+	rule, err := s.store.IPRules().GetByID(ctx, ruleID)
+	if err != nil {
+		return ErrIPRuleNotFound
+	}
+
+	if !isAdmin {
+		if rule.Scope != model.IPRuleScopeAPIKey {
+			return ErrIPRuleForbidden
+		}
+		if _, err := s.validateTarget(ctx, userID, isAdmin, rule.Scope, rule.Target); err != nil {
+			return err
+		}
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.IPRules().Delete(ctx, ruleID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionIPRuleDelete,
+			model.AuditTargetIPRule, strconv.Itoa(ruleID), rule, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reloadFilter(ctx)
+	return nil
+}
+
+// validateTarget 校验规则作用目标并返回规范化后的目标
+func (s *IPRuleService) validateTarget(ctx context.Context, userID int, isAdmin bool, scope, target string) (string, error) {
+	switch scope {
+	case model.IPRuleScopeGlobal:
+		if !isAdmin {
+			return "", ErrIPRuleForbidden
+		}
+		return "", nil
+
+	case model.IPRuleScopeService:
+		if !isAdmin {
+			return "", ErrIPRuleForbidden
+		}
+		if _, err := s.store.Services().GetByName(ctx, target); err != nil {
+			return "", errors.New("服务不存在: " + target)
+		}
+		return target, nil
+
+	case model.IPRuleScopeAPIKey:
+		keyID, err := strconv.Atoi(target)
+		if err != nil || keyID <= 0 {
+			return "", errors.New("无效的API密钥ID: " + target)
+		}
+		apiKey, err := s.store.APIKeys().GetByID(ctx, keyID)
+		if err != nil {
+			return "", errors.New("API密钥不存在: " + target)
+		}
+		if !isAdmin && apiKey.UserID != userID {
+			return "", ErrIPRuleForbidden
+		}
+		return strconv.Itoa(keyID), nil
+
+	default:
+		return "", errors.New("无效的规则作用范围: " + scope)
+	}
+}
+
+// reloadFilter 规则变更后刷新内存中的IP过滤器
+func (s *IPRuleService) reloadFilter(ctx context.Context) {
+	if s.ipFilter == nil {
+		return
+	}
+	if err := s.ipFilter.Reload(ctx); err != nil {
+		slog.ErrorContext(ctx, "刷新IP规则失败", "error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 通知列表默认返回条数
+const defaultNotificationLimit = 20
+
+// NotificationService 站内通知服务
+type NotificationService struct {
+	store store.Store
+}
+
+// NewNotificationService 创建站内通知服务实例
+func NewNotificationService(store store.Store) *NotificationService {
+	return &NotificationService{
+		store: store,
+	}
+}
+
+// ListNotifications 获取用户的通知及未读通知总数
+func (s *NotificationService) ListNotifications(ctx context.Context, userID int, req *model.NotificationListRequest) (*model.NotificationListResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+
+	notifications, err := s.store.Notifications().ListByUser(ctx, userID, req.UnreadOnly, req.Offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.store.Notifications().CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.NotificationListResponse{
+		Notifications: notifications,
+		Unread:        unread,
+	}, nil
+}
+
+// MarkRead 将用户的通知标记为已读，ids为空时标记全部，返回本次标记的通知数
+func (s *NotificationService) MarkRead(ctx context.Context, userID int, ids []int) (int64, error) {
+	return s.store.Notifications().MarkRead(ctx, userID, ids, time.Now())
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"apihub/internal/audit"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// 会话管理相关错误
+var (
+	ErrSessionNotFound = errors.New("会话不存在或已失效")
+	ErrUserNotFound    = errors.New("用户不存在")
+)
+
+// SessionService 登录会话服务
+type SessionService struct {
+	store      store.Store
+	jwtService *jwt.JWTService
+}
+
+// NewSessionService 创建登录会话服务实例
+func NewSessionService(store store.Store, jwtService *jwt.JWTService) *SessionService {
+	return &SessionService{
+		store:      store,
+		jwtService: jwtService,
+	}
+}
+
+// ListSessions 获取用户的有效会话，currentID为当前请求所在的会话
+func (s *SessionService) ListSessions(ctx context.Context, userID int, currentID string) ([]*model.SessionInfo, error) {
+	sessions, err := s.store.Sessions().ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*model.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, &model.SessionInfo{
+			Session: session,
+			Current: currentID != "" && session.ID == currentID,
+		})
+	}
+	return infos, nil
+}
+
+// RevokeSession 撤销用户自己的一个会话
+func (s *SessionService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.store.Sessions().GetByID(ctx, sessionID)
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID || !session.Active(time.Now()) {
+		return ErrSessionNotFound
+	}
+
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := revokeSession(ctx, tx, sessionID, model.SessionRevokeUser, time.Now()); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionSessionRevoke,
+			model.AuditTargetSession, sessionID, nil, map[string]any{"user_id": userID})
+	})
+	if err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	s.jwtService.InvalidateSessions(sessionID)
+	return nil
+}
+
+// RevokeAllSessions 撤销用户自己的所有会话，keepCurrent为true时保留当前会话
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID int, currentID string, keepCurrent bool) (int, error) {
+	exceptID := ""
+	if keepCurrent {
+		exceptID = currentID
+	}
+
+	ids, err := s.revokeAll(ctx, userID, exceptID, model.SessionRevokeUser, model.AuditActionSessionRevokeAll)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// ForceLogout 管理员强制用户下线，撤销该用户的所有会话
+func (s *SessionService) ForceLogout(ctx context.Context, userID int) (int, error) {
+	if _, err := s.store.Users().GetByID(ctx, userID); err != nil {
+		var dbErr *store.DBError
+		if errors.As(err, &dbErr) && dbErr.Code == store.ErrNotFound {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+
+	ids, err := s.revokeAll(ctx, userID, "", model.SessionRevokeAdmin, model.AuditActionUserForceLogout)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// revokeAll 撤销用户除exceptID外的所有会话并记录审计日志
+func (s *SessionService) revokeAll(ctx context.Context, userID int, exceptID, reason, action string) ([]string, error) {
+	var ids []string
+	err := store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		var err error
+		ids, err = revokeUserSessions(ctx, tx, userID, exceptID, reason, time.Now())
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), action,
+			model.AuditTargetUser, strconv.Itoa(userID), nil, map[string]any{"revoked_sessions": len(ids)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.jwtService.InvalidateSessions(ids...)
+	return ids, nil
+}
+
+// revokeSession 在事务中撤销会话及其刷新令牌，会话不存在或已撤销时返回ErrNotFound
+// 提交后调用方需清除会话缓存
+func revokeSession(ctx context.Context, tx store.Transaction, sessionID, reason string, now time.Time) error {
+	if err := tx.Sessions().Revoke(ctx, sessionID, reason, now); err != nil {
+		return err
+	}
+	_, err := tx.RefreshTokens().RevokeFamily(ctx, sessionID, reason, now)
+	return err
+}
+
+// revokeUserSessions 在事务中撤销用户的会话及其刷新令牌，exceptID不为空时保留该会话，返回被撤销的会话ID
+// 提交后调用方需清除会话缓存
+func revokeUserSessions(ctx context.Context, tx store.Transaction, userID int, exceptID, reason string, now time.Time) ([]string, error) {
+	ids, err := tx.Sessions().RevokeByUser(ctx, userID, exceptID, reason, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if exceptID == "" {
+		if _, err := tx.RefreshTokens().RevokeByUser(ctx, userID, reason, now); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
+	for _, id := range ids {
+		if _, err := tx.RefreshTokens().RevokeFamily(ctx, id, reason, now); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
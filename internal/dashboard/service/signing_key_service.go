@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"apihub/internal/audit"
+	"apihub/internal/auth/jwt"
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// SigningKeyService JWT签名密钥管理服务
+type SigningKeyService struct {
+	store store.Store
+	keys  *jwt.KeyManager
+}
+
+// NewSigningKeyService 创建JWT签名密钥管理服务实例
+func NewSigningKeyService(store store.Store, keys *jwt.KeyManager) *SigningKeyService {
+	return &SigningKeyService{
+		store: store,
+		keys:  keys,
+	}
+}
+
+// ListKeys 获取所有可用于验证的签名密钥
+func (s *SigningKeyService) ListKeys() []*model.SigningKeyInfo {
+	return s.keys.List()
+}
+
+// JWKS 获取公钥集合
+func (s *SigningKeyService) JWKS() *model.JWKS {
+	return s.keys.JWKS()
+}
+
+// RotateKey 立即轮换签名密钥
+// 新密钥立即用于签名，原有密钥停止签名，已签发的令牌在重叠期内仍可验证
+func (s *SigningKeyService) RotateKey(ctx context.Context) ([]*model.SigningKeyInfo, error) {
+	if err := s.keys.Rotate(ctx, true); err != nil {
+		return nil, err
+	}
+
+	keys := s.keys.List()
+	var current string
+	for _, key := range keys {
+		if key.Current {
+			current = key.KID
+		}
+	}
+
+	if err := audit.Record(ctx, s.store.AuditLogs(), model.AuditActionSigningKeyRotate,
+		model.AuditTargetSigningKey, current, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// ErrUsageStatsForbidden 无权查看其他用户的使用统计
+var ErrUsageStatsForbidden = errors.New("无权查看其他用户的使用统计")
+
+// UsageService 使用量统计服务
+type UsageService struct {
+	store store.Store
+}
+
+// NewUsageService 创建使用量统计服务实例
+func NewUsageService(store store.Store) *UsageService {
+	return &UsageService{
+		store: store,
+	}
+}
+
+// QueryStats 按粒度和分组维度查询使用量统计
+// 普通用户只能查询自己的统计，管理员未指定用户时查询全部
+func (s *UsageService) QueryStats(ctx context.Context, userID int, isAdmin bool, filter model.UsageStatsFilter) (*model.UsageStatsQueryResponse, error) {
+	targetUserID, err := scopeUsageUser(filter.UserID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = targetUserID
+
+	points, err := s.store.UsageRollups().Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 汇总整个时间范围
+	var total model.UsageStatsPoint
+	var totalDurationMs float64
+	for _, point := range points {
+		total.TotalCalls += point.TotalCalls
+		total.ErrorCalls += point.ErrorCalls
+		total.TotalCost += point.TotalCost
+		total.MaxDurationMs = max(total.MaxDurationMs, point.MaxDurationMs)
+		totalDurationMs += point.AvgDurationMs * float64(point.TotalCalls)
+	}
+	if total.TotalCalls > 0 {
+		total.AvgDurationMs = totalDurationMs / float64(total.TotalCalls)
+	}
+
+	groupBy := filter.GroupBy
+	if groupBy == nil {
+		groupBy = []string{}
+	}
+
+	return &model.UsageStatsQueryResponse{
+		Granularity: filter.Granularity,
+		StartBucket: filter.StartBucket,
+		EndBucket:   filter.EndBucket,
+		GroupBy:     groupBy,
+		Points:      points,
+		Total:       total,
+	}, nil
+}
+
+// GetSummary 获取用户按天的使用概况
+func (s *UsageService) GetSummary(ctx context.Context, userID int, isAdmin bool, req *model.UsageStatsRequest) (*model.UsageStatsResponse, error) {
+	targetUserID, err := scopeUsageUser(req.UserID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	// 概况按单个用户统计，未指定时查询当前用户
+	if targetUserID == 0 {
+		targetUserID = userID
+	}
+
+	return s.store.AccessLogs().GetUsageStats(ctx, targetUserID, req.ServiceName, req.StartDate, req.EndDate)
+}
+
+// scopeUsageUser 确定统计查询的目标用户
+func scopeUsageUser(requestedUserID, userID int, isAdmin bool) (int, error) {
+	if isAdmin {
+		return requestedUserID, nil
+	}
+	if requestedUserID != 0 && requestedUserID != userID {
+		return 0, ErrUsageStatsForbidden
+	}
+	return userID, nil
+}
@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"strconv"
 	"time"
 
+	"apihub/internal/audit"
+	"apihub/internal/auth/jwt"
 	"apihub/internal/model"
 	"apihub/internal/store"
 
@@ -19,13 +22,16 @@ const (
 
 // UserService 用户服务
 type UserService struct {
-	store store.Store
+	store      store.Store
+	jwtService *jwt.JWTService
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(store store.Store) *UserService {
+// jwtService用于在撤销用户会话后清除会话缓存
+func NewUserService(store store.Store, jwtService *jwt.JWTService) *UserService {
 	return &UserService{
-		store: store,
+		store:      store,
+		jwtService: jwtService,
 	}
 }
 
@@ -63,8 +69,14 @@ func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserReque
 		UpdatedAt: now,
 	}
 
-	// 保存到数据库
-	err = s.store.Users().Create(ctx, user)
+	// 保存到数据库，同时记录审计日志
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.Users().Create(ctx, user); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionUserCreate,
+			model.AuditTargetUser, strconv.Itoa(user.ID), nil, user)
+	})
 	if err != nil {
 		return nil, errors.New("创建用户失败: " + err.Error())
 	}
@@ -85,6 +97,8 @@ func (s *UserService) UpdateUser(ctx context.Context, userID int, req *model.Upd
 		return nil, errors.New("不能将系统管理员设置为非管理员角色")
 	}
 
+	before := *user
+
 	// 更新邮箱（如果提供）
 	if req.Email != "" && req.Email != user.Email {
 		// 检查邮箱是否已被其他用户使用
@@ -108,11 +122,26 @@ func (s *UserService) UpdateUser(ctx context.Context, userID int, req *model.Upd
 	// 更新时间
 	user.UpdatedAt = time.Now()
 
-	// 保存到数据库
-	err = s.store.Users().Update(ctx, user)
+	// 保存到数据库，禁用用户时撤销其所有会话，同时记录审计日志
+	var revokedSessions []string
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		if user.Status == model.UserStatusDisabled && before.Status != model.UserStatusDisabled {
+			ids, err := revokeUserSessions(ctx, tx, userID, "", model.SessionRevokeUserDisabled, time.Now())
+			if err != nil {
+				return err
+			}
+			revokedSessions = ids
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionUserUpdate,
+			model.AuditTargetUser, strconv.Itoa(user.ID), &before, user)
+	})
 	if err != nil {
 		return nil, errors.New("更新用户失败: " + err.Error())
 	}
+	s.jwtService.InvalidateSessions(revokedSessions...)
 
 	return user, nil
 }
@@ -130,11 +159,27 @@ func (s *UserService) DeleteUser(ctx context.Context, userID int) error {
 		return errors.New("不能删除管理员用户")
 	}
 
-	// 删除用户
-	err = s.store.Users().Delete(ctx, userID)
+	// 删除用户，同时记录审计日志
+	// 会话随用户一并删除，先取出有效会话的ID以便清除会话缓存
+	var revokedSessions []string
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		sessions, err := tx.Sessions().ListActiveByUser(ctx, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			revokedSessions = append(revokedSessions, session.ID)
+		}
+		if err := tx.Users().Delete(ctx, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionUserDelete,
+			model.AuditTargetUser, strconv.Itoa(userID), user, nil)
+	})
 	if err != nil {
 		return errors.New("删除用户失败: " + err.Error())
 	}
+	s.jwtService.InvalidateSessions(revokedSessions...)
 
 	return nil
 }
@@ -157,11 +202,24 @@ func (s *UserService) ResetPassword(ctx context.Context, userID int, newPassword
 	user.Password = string(hashedPassword)
 	user.UpdatedAt = time.Now()
 
-	// 保存到数据库
-	err = s.store.Users().Update(ctx, user)
+	// 保存到数据库并撤销该用户所有会话，同时记录审计日志（不记录密码内容）
+	var revokedSessions []string
+	err = store.WithTx(ctx, s.store, func(tx store.Transaction) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		ids, err := revokeUserSessions(ctx, tx, userID, "", model.SessionRevokePasswordReset, time.Now())
+		if err != nil {
+			return err
+		}
+		revokedSessions = ids
+		return audit.Record(ctx, tx.AuditLogs(), model.AuditActionUserResetPassword,
+			model.AuditTargetUser, strconv.Itoa(userID), nil, map[string]string{"password": audit.Redacted})
+	})
 	if err != nil {
 		return errors.New("重置密码失败: " + err.Error())
 	}
+	s.jwtService.InvalidateSessions(revokedSessions...)
 
 	return nil
 }
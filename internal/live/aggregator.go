@@ -0,0 +1,65 @@
+package live
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"apihub/internal/model"
+)
+
+// Aggregator 将订阅到的访问日志按秒汇总，并对原始事件做蓄水池抽样
+// 不是并发安全的，由单个订阅者协程使用
+type Aggregator struct {
+	sampleLimit int
+	frame       model.LiveFrame
+	samples     []*model.AccessLog
+	seen        int
+}
+
+// NewAggregator 创建汇总器，sampleLimit为每秒最多推送的原始事件数，0表示不推送
+func NewAggregator(sampleLimit int) *Aggregator {
+	a := &Aggregator{sampleLimit: sampleLimit}
+	a.reset()
+	return a
+}
+
+// Add 累加一条访问日志
+func (a *Aggregator) Add(accessLog *model.AccessLog) {
+	a.frame.LiveStats.Add(accessLog)
+
+	stats, ok := a.frame.Services[accessLog.ServiceName]
+	if !ok {
+		stats = &model.LiveStats{}
+		a.frame.Services[accessLog.ServiceName] = stats
+	}
+	stats.Add(accessLog)
+
+	if a.sampleLimit <= 0 {
+		return
+	}
+	// 蓄水池抽样，保证本秒内每条日志被选中的概率相同
+	a.seen++
+	if len(a.samples) < a.sampleLimit {
+		a.samples = append(a.samples, accessLog)
+	} else if i := rand.IntN(a.seen); i < a.sampleLimit {
+		a.samples[i] = accessLog
+	}
+}
+
+// Flush 返回本秒的汇总帧和抽样事件并开始新的一秒
+func (a *Aggregator) Flush(now time.Time, dropped int64) (model.LiveFrame, []*model.AccessLog) {
+	frame, samples := a.frame, a.samples
+	frame.Time = now
+	frame.Sampled = len(samples)
+	frame.Dropped = dropped
+
+	a.reset()
+	return frame, samples
+}
+
+// reset 清空当前统计
+func (a *Aggregator) reset() {
+	a.frame = model.LiveFrame{Services: make(map[string]*model.LiveStats)}
+	a.samples = nil
+	a.seen = 0
+}
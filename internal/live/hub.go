@@ -0,0 +1,152 @@
+package live
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"apihub/internal/model"
+)
+
+// defaultBufferSize 每个订阅者的事件缓冲区大小
+const defaultBufferSize = 1024
+
+// Filter 订阅过滤条件，零值字段表示不过滤
+type Filter struct {
+	ServiceName string
+	UserID      int
+	StatusClass string // 2xx/3xx/4xx/5xx
+}
+
+// Match 判断访问日志是否满足过滤条件
+func (f Filter) Match(accessLog *model.AccessLog) bool {
+	if f.ServiceName != "" && accessLog.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.UserID > 0 && accessLog.UserID != f.UserID {
+		return false
+	}
+	if f.StatusClass != "" && model.StatusClass(accessLog.Status) != f.StatusClass {
+		return false
+	}
+	return true
+}
+
+// Subscription 实时访问日志订阅
+type Subscription struct {
+	hub     *Hub
+	filter  Filter
+	events  chan *model.AccessLog
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// Events 返回事件通道，订阅结束后不再有新事件，通道不会被关闭
+func (s *Subscription) Events() <-chan *model.AccessLog {
+	return s.events
+}
+
+// Done 订阅被关闭时（客户端取消或Hub关闭）关闭的通道
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// TakeDropped 返回自上次调用以来因缓冲区满而丢弃的事件数并清零
+func (s *Subscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// close 标记订阅结束
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// Hub 实时访问日志分发中心
+// Publish在请求处理路径上调用，只做过滤和非阻塞投递，
+// 订阅者消费过慢时丢弃其事件，不会阻塞请求处理或影响其他订阅者
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	bufferSize  int
+}
+
+// NewHub 创建实时访问日志分发中心
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  defaultBufferSize,
+	}
+}
+
+// Subscribe 按过滤条件订阅访问日志，Hub已关闭时返回的订阅立即结束
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan *model.AccessLog, h.bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.close()
+		return sub
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Publish 向满足条件的订阅者投递访问日志
+func (h *Hub) Publish(accessLog *model.AccessLog) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Match(accessLog) {
+			continue
+		}
+		select {
+		case sub.events <- accessLog:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// SubscriberCount 当前订阅者数量
+func (h *Hub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Close 结束所有订阅，之后的订阅立即结束
+// 应在HTTP服务器关闭时调用，避免长连接阻塞优雅退出
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		sub.close()
+		delete(h.subscribers, sub)
+	}
+}
+
+// unsubscribe 移除订阅者
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+	sub.close()
+}
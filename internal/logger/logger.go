@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config 日志配置
+type Config struct {
+	Level   string // debug, info, warn, error
+	Format  string // json 或 text
+	Path    string // 日志目录，为空时只输出到标准输出
+	Console bool   // 写入文件的同时是否输出到标准输出
+
+	MaxSize        int64         // 单个日志文件最大字节数
+	RotateInterval time.Duration // 按时间轮转的间隔
+	MaxAge         time.Duration // 轮转文件保留时长
+	MaxBackups     int           // 轮转文件最多保留个数
+}
+
+// Setup 按配置创建结构化日志记录器并设为默认记录器
+// 标准库log包的输出也会经由该记录器以info级别输出
+// 返回的Closer用于在退出时关闭日志文件
+func Setup(config Config) (io.Closer, error) {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var writer io.Writer = os.Stdout
+	var closer io.Closer = nopCloser{}
+	if config.Path != "" {
+		fileWriter, err := NewRotatingWriter(RotateConfig{
+			Dir:        config.Path,
+			FileName:   "apihub.log",
+			MaxSize:    config.MaxSize,
+			Interval:   config.RotateInterval,
+			MaxAge:     config.MaxAge,
+			MaxBackups: config.MaxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+		writer, closer = fileWriter, fileWriter
+		if config.Console {
+			writer = io.MultiWriter(os.Stdout, fileWriter)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "json", "":
+		handler = slog.NewJSONHandler(writer, options)
+	case "text":
+		handler = slog.NewTextHandler(writer, options)
+	default:
+		closer.Close()
+		return nil, fmt.Errorf("不支持的日志格式: %s", config.Format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return closer, nil
+}
+
+// ParseLevel 解析日志级别
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
+	}
+}
+
+// requestIDKey 请求ID在context中的键
+type requestIDKey struct{}
+
+// WithRequestID 将请求ID存入context，使用该context记录的日志会附带request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 从context获取请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler 从context中提取请求ID和追踪ID附加到日志记录
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle 处理日志记录
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			record.AddAttrs(slog.String("request_id", requestID))
+		}
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			record.AddAttrs(
+				slog.String("trace_id", spanContext.TraceID().String()),
+				slog.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 返回附加属性后的处理器
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 返回带分组的处理器
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// nopCloser 无需关闭的输出
+type nopCloser struct{}
+
+// Close 关闭
+func (nopCloser) Close() error { return nil }
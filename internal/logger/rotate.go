@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 轮转后文件名中的时间格式
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateConfig 日志文件轮转配置
+type RotateConfig struct {
+	Dir        string        // 日志目录
+	FileName   string        // 当前日志文件名
+	MaxSize    int64         // 单个文件最大字节数，<=0表示不按大小轮转
+	Interval   time.Duration // 按时间轮转的间隔，<=0表示不按时间轮转
+	MaxAge     time.Duration // 轮转文件保留时长，<=0表示不按时间清理
+	MaxBackups int           // 轮转文件最多保留个数，<=0表示不按个数清理
+}
+
+// RotatingWriter 支持按大小和时间轮转的日志文件写入器
+// 轮转时将当前文件重命名为 <名称>-<时间>.<扩展名>，并清理超出保留策略的旧文件
+type RotatingWriter struct {
+	config RotateConfig
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingWriter 创建轮转写入器并打开日志文件
+func NewRotatingWriter(config RotateConfig) (*RotatingWriter, error) {
+	if config.FileName == "" {
+		config.FileName = "apihub.log"
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	w := &RotatingWriter{config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入日志，写入前检查是否需要轮转
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			// 轮转失败时继续写入当前文件，避免丢失日志
+			fmt.Fprintf(os.Stderr, "日志文件轮转失败: %v\n", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close 关闭日志文件
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// path 当前日志文件路径
+func (w *RotatingWriter) path() string {
+	return filepath.Join(w.config.Dir, w.config.FileName)
+}
+
+// open 打开当前日志文件，沿用已有文件的大小和最后修改时间
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	if info.Size() > 0 {
+		w.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// shouldRotate 判断写入n字节前是否需要轮转
+func (w *RotatingWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.config.MaxSize > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	// 按间隔对齐的时间段轮转（如间隔为24小时时每天UTC零点），重启后沿用文件的最后修改时间判断
+	if w.config.Interval > 0 && !time.Now().Truncate(w.config.Interval).Equal(w.openedAt.Truncate(w.config.Interval)) {
+		return true
+	}
+	return false
+}
+
+// rotate 重命名当前文件并打开新文件，然后清理旧文件
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	ext := filepath.Ext(w.config.FileName)
+	base := strings.TrimSuffix(w.config.FileName, ext)
+	backup := filepath.Join(w.config.Dir, base+"-"+time.Now().Format(backupTimeFormat)+ext)
+	renameErr := os.Rename(w.path(), backup)
+
+	// 无论重命名是否成功都需要重新打开文件
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+
+	w.cleanup()
+	return nil
+}
+
+// cleanup 按保留时长和保留个数删除旧的轮转文件
+func (w *RotatingWriter) cleanup() {
+	if w.config.MaxAge <= 0 && w.config.MaxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(w.config.FileName)
+	base := strings.TrimSuffix(w.config.FileName, ext)
+	matches, err := filepath.Glob(filepath.Join(w.config.Dir, base+"-*"+ext))
+	if err != nil {
+		return
+	}
+
+	type backupFile struct {
+		path string
+		time time.Time
+	}
+	backups := make([]backupFile, 0, len(matches))
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), base+"-"), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: match, time: t})
+	}
+
+	// 最新的在前
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	cutoff := time.Now().Add(-w.config.MaxAge)
+	for i, backup := range backups {
+		expired := w.config.MaxAge > 0 && backup.time.Before(cutoff)
+		overflow := w.config.MaxBackups > 0 && i >= w.config.MaxBackups
+		if expired || overflow {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "删除旧日志文件失败: %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "apihub"
+
+// 认证方式标签值
+const (
+	AuthMethodJWT       = "jwt"
+	AuthMethodAPIKey    = "apikey"
+	AuthMethodSignature = "signature"
+	AuthMethodOAuth     = "oauth"
+	AuthMethodPassword  = "password"
+	AuthMethodRefresh   = "refresh_token"
+	AuthMethodClient    = "client_credentials" // OAuth2令牌端点的客户端认证
+	AuthMethodNone      = "none"               // 未携带任何认证信息
+)
+
+// Config 指标接口配置
+type Config struct {
+	Enabled bool   // 是否启用/metrics接口
+	Listen  string // 独立监听地址，为空时挂载在主服务上
+	Token   string // 访问令牌，非空时要求 Authorization: Bearer <token>
+}
+
+// registry 指标注册表，不使用默认注册表以免引入第三方库注册的指标
+var registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "功能API请求总数",
+	}, []string{"service", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "功能API请求处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "status"})
+
+	rateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ratelimit_rejections_total",
+		Help:      "被限流拒绝的请求数",
+	}, []string{"service", "subject_type"})
+
+	quotaRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_rejections_total",
+		Help:      "因配额超限被拒绝的请求数",
+	}, []string{"service"})
+
+	admissionRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "admission_rejections_total",
+		Help:      "因服务过载被准入控制拒绝的请求数",
+	}, []string{"priority"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "认证失败次数",
+	}, []string{"method"})
+)
+
+func init() {
+	registry.MustRegister(
+		requestsTotal,
+		requestDuration,
+		rateLimitRejections,
+		quotaRejections,
+		admissionRejections,
+		authFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveRequest 记录一次功能API请求
+func ObserveRequest(service string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	requestsTotal.WithLabelValues(service, statusLabel).Inc()
+	requestDuration.WithLabelValues(service, statusLabel).Observe(duration.Seconds())
+}
+
+// RateLimitRejected 记录一次限流拒绝
+func RateLimitRejected(service, subjectType string) {
+	rateLimitRejections.WithLabelValues(service, subjectType).Inc()
+}
+
+// QuotaRejected 记录一次配额超限拒绝
+func QuotaRejected(service string) {
+	quotaRejections.WithLabelValues(service).Inc()
+}
+
+// AdmissionRejected 记录一次准入控制拒绝
+func AdmissionRejected(priority string) {
+	admissionRejections.WithLabelValues(priority).Inc()
+}
+
+// AuthFailed 记录一次认证失败
+func AuthFailed(method string) {
+	authFailures.WithLabelValues(method).Inc()
+}
+
+// RegisterGaugeFunc 注册在采集时计算的仪表盘指标
+func RegisterGaugeFunc(name, help string, fn func() float64) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// RegisterCounterFunc 注册在采集时计算的计数器指标
+func RegisterCounterFunc(name, help string, fn func() float64) {
+	register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// RegisterDBStats 注册数据库连接池指标
+func RegisterDBStats(db *sql.DB, dbName string) {
+	register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// register 注册指标，重复注册时忽略
+func register(collector prometheus.Collector) {
+	if err := registry.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			slog.Error("注册指标失败", "error", err)
+		}
+	}
+}
+
+// Handler 创建指标输出处理器
+// token非空时要求请求携带 Authorization: Bearer <token>
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
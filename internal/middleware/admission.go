@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apihub/internal/metrics"
+	"apihub/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 请求优先级常量
+const (
+	PriorityLow  = 0 // 匿名的公开API调用
+	PriorityHigh = 1 // 已携带认证信息的调用
+)
+
+// 负载等级常量
+const (
+	loadLevelNormal  = 0 // 正常，全部放行
+	loadLevelShedLow = 1 // 过载，拒绝低优先级请求
+	loadLevelShedAll = 2 // 严重过载，拒绝所有请求
+)
+
+// AdmissionConfig 全局准入控制配置
+type AdmissionConfig struct {
+	Enabled bool // 是否启用
+
+	MaxInFlight         int           // 并发请求数阈值，<=0表示不检查
+	LatencyP95Threshold time.Duration // 处理延迟P95阈值，<=0表示不检查
+	MaxLogQueueDepth    int           // 待写入访问日志数量阈值，<=0表示不检查
+
+	// CriticalFactor 严重过载系数
+	// 任一指标超过 阈值*系数 时拒绝所有请求，否则只拒绝低优先级请求
+	CriticalFactor float64
+
+	RetryAfter    time.Duration // 拒绝时返回的Retry-After
+	LatencyWindow int           // 计算延迟分位数的样本窗口大小
+
+	// LatencyMaxAge 延迟样本有效期，超过有效期的样本不参与分位数计算
+	// 过载时请求被拒绝不会产生新样本，样本过期后P95回落，准入控制随之恢复
+	LatencyMaxAge time.Duration
+}
+
+// AdmissionStats 准入控制统计信息
+type AdmissionStats struct {
+	Enabled       bool  `json:"enabled"`
+	InFlight      int64 `json:"in_flight"`
+	LatencyP95Ms  int64 `json:"latency_p95_ms"`
+	LatencyP99Ms  int64 `json:"latency_p99_ms"`
+	LogQueueDepth int   `json:"log_queue_depth"`
+	LoadLevel     int   `json:"load_level"`
+	ShedLow       int64 `json:"shed_low"`  // 被拒绝的低优先级请求数
+	ShedHigh      int64 `json:"shed_high"` // 被拒绝的高优先级请求数
+}
+
+// AdmissionController 全局准入控制器
+// 根据服务器自身健康状况（并发数、处理延迟、日志写入积压）决定是否接收请求
+type AdmissionController struct {
+	config AdmissionConfig
+
+	inFlight   atomic.Int64
+	shedLow    atomic.Int64
+	shedHigh   atomic.Int64
+	queueDepth func() int
+
+	mu         sync.Mutex
+	samples    []latencySample // 延迟样本环形缓冲区
+	next       int             // 下一个写入位置
+	filled     bool            // 缓冲区是否已写满
+	p95        time.Duration
+	p99        time.Duration
+	computedAt time.Time
+
+	now func() time.Time // 当前时间，便于测试替换
+}
+
+// latencySample 单个延迟样本
+type latencySample struct {
+	duration   time.Duration
+	recordedAt time.Time
+}
+
+// NewAdmissionController 创建准入控制器
+func NewAdmissionController(config AdmissionConfig) *AdmissionController {
+	if config.CriticalFactor < 1 {
+		config.CriticalFactor = 1.5
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = 5 * time.Second
+	}
+	if config.LatencyWindow <= 0 {
+		config.LatencyWindow = 1000
+	}
+	if config.LatencyMaxAge <= 0 {
+		config.LatencyMaxAge = 30 * time.Second
+	}
+
+	return &AdmissionController{
+		config:  config,
+		samples: make([]latencySample, config.LatencyWindow),
+		now:     time.Now,
+	}
+}
+
+// SetQueueDepthFunc 设置获取待写入访问日志数量的函数
+func (a *AdmissionController) SetQueueDepthFunc(fn func() int) {
+	a.queueDepth = fn
+}
+
+// Middleware 创建准入控制中间件
+// 应放在认证之前，以便在过载时尽早拒绝请求
+func (a *AdmissionController) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.config.Enabled {
+			c.Next()
+			return
+		}
+
+		priority := requestPriority(c)
+		level := a.loadLevel()
+
+		if level == loadLevelShedAll || (level == loadLevelShedLow && priority == PriorityLow) {
+			if priority == PriorityLow {
+				a.shedLow.Add(1)
+				metrics.AdmissionRejected("low")
+			} else {
+				a.shedHigh.Add(1)
+				metrics.AdmissionRejected("high")
+			}
+
+			c.Header("Retry-After", strconv.Itoa(int(a.config.RetryAfter.Seconds())))
+			c.JSON(http.StatusServiceUnavailable, model.NewErrorResponse(
+				model.CodeServiceOverloaded,
+				model.MsgServiceOverloaded,
+			))
+			c.Abort()
+			return
+		}
+
+		a.inFlight.Add(1)
+		start := a.now()
+		defer func() {
+			a.inFlight.Add(-1)
+			a.recordLatency(a.now().Sub(start))
+		}()
+
+		c.Next()
+	}
+}
+
+// Stats 获取准入控制统计信息
+func (a *AdmissionController) Stats() AdmissionStats {
+	p95, p99 := a.percentiles()
+	return AdmissionStats{
+		Enabled:       a.config.Enabled,
+		InFlight:      a.inFlight.Load(),
+		LatencyP95Ms:  p95.Milliseconds(),
+		LatencyP99Ms:  p99.Milliseconds(),
+		LogQueueDepth: a.currentQueueDepth(),
+		LoadLevel:     a.loadLevel(),
+		ShedLow:       a.shedLow.Load(),
+		ShedHigh:      a.shedHigh.Load(),
+	}
+}
+
+// requestPriority 判断请求优先级
+// 未携带任何认证信息的公开API调用为低优先级
+func requestPriority(c *gin.Context) int {
+	if !strings.HasSuffix(c.FullPath(), "/public") {
+		return PriorityHigh
+	}
+
+	if c.GetHeader("Authorization") != "" || getAPIKeyFromRequest(c) != "" {
+		return PriorityHigh
+	}
+
+	return PriorityLow
+}
+
+// loadLevel 根据各项指标计算当前负载等级
+func (a *AdmissionController) loadLevel() int {
+	level := loadLevelNormal
+	p95, _ := a.percentiles()
+
+	if a.config.MaxInFlight > 0 {
+		level = max(level, a.levelFor(float64(a.inFlight.Load()), float64(a.config.MaxInFlight)))
+	}
+	if a.config.LatencyP95Threshold > 0 {
+		level = max(level, a.levelFor(float64(p95), float64(a.config.LatencyP95Threshold)))
+	}
+	if a.config.MaxLogQueueDepth > 0 {
+		level = max(level, a.levelFor(float64(a.currentQueueDepth()), float64(a.config.MaxLogQueueDepth)))
+	}
+
+	return level
+}
+
+// levelFor 计算单个指标对应的负载等级
+func (a *AdmissionController) levelFor(value, threshold float64) int {
+	switch {
+	case value >= threshold*a.config.CriticalFactor:
+		return loadLevelShedAll
+	case value >= threshold:
+		return loadLevelShedLow
+	default:
+		return loadLevelNormal
+	}
+}
+
+// currentQueueDepth 获取当前待写入访问日志数量
+func (a *AdmissionController) currentQueueDepth() int {
+	if a.queueDepth == nil {
+		return 0
+	}
+	return a.queueDepth()
+}
+
+// recordLatency 记录一次请求处理延迟
+func (a *AdmissionController) recordLatency(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.samples[a.next] = latencySample{duration: d, recordedAt: a.now()}
+	a.next++
+	if a.next == len(a.samples) {
+		a.next = 0
+		a.filled = true
+	}
+}
+
+// percentiles 获取延迟P95和P99
+// 只统计有效期内的样本；分位数计算结果缓存1秒，避免每个请求都排序样本
+func (a *AdmissionController) percentiles() (time.Duration, time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.computedAt) < time.Second {
+		return a.p95, a.p99
+	}
+
+	count := a.next
+	if a.filled {
+		count = len(a.samples)
+	}
+
+	cutoff := now.Add(-a.config.LatencyMaxAge)
+	sorted := make([]time.Duration, 0, count)
+	for _, sample := range a.samples[:count] {
+		if sample.recordedAt.After(cutoff) {
+			sorted = append(sorted, sample.duration)
+		}
+	}
+
+	if len(sorted) == 0 {
+		a.p95, a.p99 = 0, 0
+	} else {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		a.p95 = sorted[(len(sorted)-1)*95/100]
+		a.p99 = sorted[(len(sorted)-1)*99/100]
+	}
+
+	a.computedAt = now
+	return a.p95, a.p99
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+// newAdmissionTestRouter 创建挂载准入控制的测试路由
+// 处理函数按 delay 推进时钟，模拟请求处理耗时
+func newAdmissionTestRouter(a *AdmissionController, clock *fakeClock, delay *time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(a.Middleware())
+	r.POST("/api/v1/provider/:service/public", func(c *gin.Context) {
+		clock.Advance(*delay)
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func doPublicRequest(r *gin.Engine) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/provider/echo/public", nil)
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAdmissionRecoversAfterLatencyDrops(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	a := NewAdmissionController(AdmissionConfig{
+		Enabled:             true,
+		LatencyP95Threshold: 100 * time.Millisecond,
+		CriticalFactor:      10,
+		LatencyWindow:       100,
+		LatencyMaxAge:       10 * time.Second,
+	})
+	a.now = clock.Now
+
+	delay := 200 * time.Millisecond
+	r := newAdmissionTestRouter(a, clock, &delay)
+
+	// 慢请求推高P95
+	if code := doPublicRequest(r); code != http.StatusOK {
+		t.Fatalf("slow request: got status %d, want %d", code, http.StatusOK)
+	}
+	for i := 0; i < 19; i++ {
+		a.recordLatency(delay)
+	}
+
+	// 越过分位数缓存后，低优先级请求应被拒绝
+	clock.Advance(time.Second)
+	if code := doPublicRequest(r); code != http.StatusServiceUnavailable {
+		t.Fatalf("overloaded: got status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	// 延迟恢复正常，但拒绝期间没有新样本，旧样本过期前仍然拒绝
+	delay = time.Millisecond
+	clock.Advance(5 * time.Second)
+	if code := doPublicRequest(r); code != http.StatusServiceUnavailable {
+		t.Fatalf("before samples expire: got status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	// 旧样本过期后P95回落，请求重新放行
+	clock.Advance(10 * time.Second)
+	if code := doPublicRequest(r); code != http.StatusOK {
+		t.Fatalf("after samples expire: got status %d, want %d", code, http.StatusOK)
+	}
+
+	stats := a.Stats()
+	if stats.LoadLevel != loadLevelNormal {
+		t.Errorf("load level = %d, want %d", stats.LoadLevel, loadLevelNormal)
+	}
+	if stats.ShedLow != 2 {
+		t.Errorf("shed low = %d, want 2", stats.ShedLow)
+	}
+}
+
+func TestAdmissionKeepsRecentSlowSamples(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	a := NewAdmissionController(AdmissionConfig{
+		Enabled:             true,
+		LatencyP95Threshold: 100 * time.Millisecond,
+		LatencyMaxAge:       10 * time.Second,
+	})
+	a.now = clock.Now
+
+	for i := 0; i < 10; i++ {
+		a.recordLatency(500 * time.Millisecond)
+	}
+	clock.Advance(2 * time.Second)
+
+	if p95, _ := a.percentiles(); p95 != 500*time.Millisecond {
+		t.Fatalf("p95 = %v, want %v", p95, 500*time.Millisecond)
+	}
+	if level := a.loadLevel(); level != loadLevelShedAll {
+		t.Fatalf("load level = %d, want %d", level, loadLevelShedAll)
+	}
+}
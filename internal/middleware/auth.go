@@ -1,17 +1,22 @@
 package middleware
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"apihub/internal/auth/apikey"
 	"apihub/internal/auth/jwt"
+	"apihub/internal/metrics"
 	"apihub/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AuthMiddleware 统一认证中间件
-// 支持JWT和APIKey两种认证方式
+// 支持JWT、OAuth2客户端访问令牌、APIKey和请求签名认证
+// APIKey的使用限制和所属用户状态在这里检查，使用次数由调用方在请求通过全部检查后通过apikey.ConsumeUseMiddleware消耗
 func AuthMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 首先尝试JWT认证
@@ -22,6 +27,8 @@ func AuthMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.APIKeyServ
 			// 验证JWT Token
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err == nil {
+				jwtService.TouchSession(c.Request.Context(), claims.ID, c.ClientIP(), c.Request.UserAgent())
+
 				// JWT认证成功，设置用户信息到上下文
 				c.Set(string(jwt.UserClaimsKey), claims)
 				c.Set(string(jwt.UserIDKey), claims.UserID)
@@ -30,20 +37,60 @@ func AuthMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.APIKeyServ
 				c.Next()
 				return
 			}
+
+			// OAuth2客户端访问令牌，以客户端所属用户的身份调用
+			if errors.Is(err, jwt.ErrClientToken) {
+				if setClientToken(c, jwtService, tokenString) {
+					c.Next()
+					return
+				}
+				metrics.AuthFailed(metrics.AuthMethodOAuth)
+			} else {
+				metrics.AuthFailed(metrics.AuthMethodJWT)
+			}
+		}
+
+		// 携带签名认证信息时只使用签名认证，验证失败直接拒绝
+		if apikey.IsSignedRequest(c) {
+			apiKeyModel, err := apikey.AuthenticateSignedRequest(c, apiKeyService)
+			if err != nil {
+				metrics.AuthFailed(metrics.AuthMethodSignature)
+				c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "签名认证失败: "+err.Error()))
+				c.Abort()
+				return
+			}
+			if !apikey.CheckRequestRestrictions(c, apiKeyService, apiKeyModel) ||
+				!checkAPIKeyOwner(c, apiKeyService, apiKeyModel, metrics.AuthMethodSignature) {
+				return
+			}
+
+			setSignedAPIKey(c, apiKeyModel)
+			c.Next()
+			return
 		}
 
 		// JWT认证失败，尝试APIKey认证
 		apiKeyString := getAPIKeyFromRequest(c)
 		if apiKeyString != "" {
 			// 验证APIKey
-			apiKeyModel, err := apiKeyService.ValidateAPIKey(apiKeyString)
+			apiKeyModel, err := apiKeyService.ValidateAPIKey(c.Request.Context(), apiKeyString)
 			if err == nil {
+				// 检查使用限制，不满足时返回对应的错误码；所属用户已禁用时拒绝请求
+				if !apikey.CheckRequestRestrictions(c, apiKeyService, apiKeyModel) ||
+					!checkAPIKeyOwner(c, apiKeyService, apiKeyModel, metrics.AuthMethodAPIKey) {
+					return
+				}
+
 				// APIKey认证成功，设置APIKey信息到上下文
 				c.Set(string(apikey.APIKeyKey), apiKeyModel)
 				c.Set(string(apikey.APIKeyUserIDKey), apiKeyModel.UserID)
 				c.Next()
 				return
 			}
+			metrics.AuthFailed(metrics.AuthMethodAPIKey)
+		} else if authHeader == "" {
+			// 未携带任何认证信息
+			metrics.AuthFailed(metrics.AuthMethodNone)
 		}
 
 		// 两种认证方式都失败
@@ -64,26 +111,58 @@ func OptionalAuthMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.AP
 			// 验证JWT Token
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err == nil {
+				jwtService.TouchSession(c.Request.Context(), claims.ID, c.ClientIP(), c.Request.UserAgent())
+
 				// JWT认证成功，设置用户信息到上下文
 				c.Set(string(jwt.UserClaimsKey), claims)
 				c.Set(string(jwt.UserIDKey), claims.UserID)
 				c.Set(string(jwt.UsernameKey), claims.Username)
 				c.Set(string(jwt.UserRoleKey), claims.Role)
 				// 不要立即返回，继续执行后续中间件
+			} else if errors.Is(err, jwt.ErrClientToken) {
+				if !setClientToken(c, jwtService, tokenString) {
+					metrics.AuthFailed(metrics.AuthMethodOAuth)
+				}
+			} else {
+				metrics.AuthFailed(metrics.AuthMethodJWT)
 			}
 		}
 
+		// 携带签名认证信息但验证失败时拒绝请求，不降级为匿名访问，避免被重放的请求以匿名身份执行
+		if _, exists := c.Get(string(jwt.UserIDKey)); !exists && apikey.IsSignedRequest(c) {
+			apiKeyModel, err := apikey.AuthenticateSignedRequest(c, apiKeyService)
+			if err != nil {
+				metrics.AuthFailed(metrics.AuthMethodSignature)
+				c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "签名认证失败: "+err.Error()))
+				c.Abort()
+				return
+			}
+			if !apikey.CheckRequestRestrictions(c, apiKeyService, apiKeyModel) ||
+				!checkAPIKeyOwner(c, apiKeyService, apiKeyModel, metrics.AuthMethodSignature) {
+				return
+			}
+			setSignedAPIKey(c, apiKeyModel)
+		}
+
 		// 如果JWT认证失败或没有JWT，尝试APIKey认证
-		if _, exists := c.Get(string(jwt.UserIDKey)); !exists {
+		if _, exists := c.Get(string(jwt.UserIDKey)); !exists && !IsAPIKeyAuth(c) {
 			apiKeyString := getAPIKeyFromRequest(c)
 			if apiKeyString != "" {
 				// 验证APIKey
-				apiKeyModel, err := apiKeyService.ValidateAPIKey(apiKeyString)
+				apiKeyModel, err := apiKeyService.ValidateAPIKey(c.Request.Context(), apiKeyString)
 				if err == nil {
+					// 有效的APIKey不满足使用限制或所属用户已禁用时拒绝请求，不降级为匿名访问
+					if !apikey.CheckRequestRestrictions(c, apiKeyService, apiKeyModel) ||
+						!checkAPIKeyOwner(c, apiKeyService, apiKeyModel, metrics.AuthMethodAPIKey) {
+						return
+					}
+
 					// APIKey认证成功，设置APIKey信息到上下文
 					c.Set(string(apikey.APIKeyKey), apiKeyModel)
 					c.Set(string(apikey.APIKeyUserIDKey), apiKeyModel.UserID)
 					// 不要立即返回，继续执行后续中间件
+				} else {
+					metrics.AuthFailed(metrics.AuthMethodAPIKey)
 				}
 			}
 		}
@@ -93,6 +172,17 @@ func OptionalAuthMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.AP
 	}
 }
 
+// checkAPIKeyOwner 检查APIKey所属用户是否存在且未被禁用，否则返回401并中止请求，返回false
+func checkAPIKeyOwner(c *gin.Context, apiKeyService *apikey.APIKeyService, apiKeyModel *model.APIKey, method string) bool {
+	if _, err := apiKeyService.GetOwner(c.Request.Context(), apiKeyModel); err != nil {
+		metrics.AuthFailed(method)
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, err.Error()))
+		c.Abort()
+		return false
+	}
+	return true
+}
+
 // JWTOnlyMiddleware 仅JWT认证中间件
 func JWTOnlyMiddleware(jwtService *jwt.JWTService) gin.HandlerFunc {
 	return jwt.JWTAuthMiddleware(jwtService)
@@ -103,6 +193,87 @@ func APIKeyOnlyMiddleware(apiKeyService *apikey.APIKeyService) gin.HandlerFunc {
 	return apikey.APIKeyAuthMiddleware(apiKeyService)
 }
 
+// JWTOrAPIKeyScopeMiddleware JWT或具有指定权限范围的APIKey认证中间件
+// 请求携带APIKey（X-API-Key头或api_key参数）或签名认证信息时使用APIKey认证，只允许GET请求，
+// 并以密钥所属用户的身份设置用户信息，使后续的权限中间件和处理器按该用户处理；否则使用JWT认证
+func JWTOrAPIKeyScopeMiddleware(jwtService *jwt.JWTService, apiKeyService *apikey.APIKeyService, requiredScope string) gin.HandlerFunc {
+	jwtMiddleware := jwt.JWTAuthMiddleware(jwtService)
+
+	return func(c *gin.Context) {
+		signed := apikey.IsSignedRequest(c)
+		apiKeyString := getAPIKeyFromRequest(c)
+		if !signed && apiKeyString == "" {
+			jwtMiddleware(c)
+			return
+		}
+
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(model.CodeForbidden, "API密钥只能访问只读接口"))
+			c.Abort()
+			return
+		}
+
+		var apiKeyModel *model.APIKey
+		var err error
+		if signed {
+			apiKeyModel, err = apikey.AuthenticateSignedRequest(c, apiKeyService)
+			if err != nil {
+				metrics.AuthFailed(metrics.AuthMethodSignature)
+				c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "签名认证失败: "+err.Error()))
+				c.Abort()
+				return
+			}
+		} else {
+			apiKeyModel, err = apiKeyService.ValidateAPIKey(c.Request.Context(), apiKeyString)
+			if err != nil {
+				metrics.AuthFailed(metrics.AuthMethodAPIKey)
+				c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, "API密钥无效: "+err.Error()))
+				c.Abort()
+				return
+			}
+		}
+
+		if !apiKeyModel.Allows(requiredScope) {
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(model.CodeForbidden, "API密钥缺少权限范围: "+requiredScope))
+			c.Abort()
+			return
+		}
+
+		if !apikey.CheckRequestRestrictions(c, apiKeyService, apiKeyModel) {
+			return
+		}
+
+		owner, err := apiKeyService.GetOwner(c.Request.Context(), apiKeyModel)
+		if err != nil {
+			metrics.AuthFailed(metrics.AuthMethodAPIKey)
+			c.JSON(http.StatusUnauthorized, model.NewErrorResponse(model.CodeUnauthorized, err.Error()))
+			c.Abort()
+			return
+		}
+
+		// 全部检查通过后再消耗使用次数
+		if !apikey.ConsumeRequestUse(c, apiKeyService, apiKeyModel) {
+			return
+		}
+
+		// Dashboard请求不写访问日志，在此记录最后使用时间，失败不影响本次请求
+		if err := apiKeyService.TouchLastUsed(c.Request.Context(), apiKeyModel, c.ClientIP(), time.Now()); err != nil {
+			slog.WarnContext(c.Request.Context(), "记录API密钥最后使用时间失败", "api_key_id", apiKeyModel.ID, "error", err)
+		}
+
+		// 设置APIKey及其所属用户信息到上下文
+		if signed {
+			c.Set(string(apikey.SignedRequestKey), true)
+		}
+		c.Set(string(apikey.APIKeyKey), apiKeyModel)
+		c.Set(string(apikey.APIKeyUserIDKey), owner.ID)
+		c.Set(string(jwt.UserIDKey), owner.ID)
+		c.Set(string(jwt.UsernameKey), owner.Username)
+		c.Set(string(jwt.UserRoleKey), owner.Role)
+		c.Next()
+	}
+}
+
 // GetCurrentUserID 获取当前用户ID（支持JWT和APIKey）
 func GetCurrentUserID(c *gin.Context) (int, bool) {
 	// 首先尝试从JWT获取
@@ -150,12 +321,56 @@ func IsJWTAuth(c *gin.Context) bool {
 	return exists
 }
 
+// IsOAuthAuth 检查是否为OAuth2客户端访问令牌认证
+func IsOAuthAuth(c *gin.Context) bool {
+	_, exists := c.Get(string(jwt.ClientClaimsKey))
+	return exists
+}
+
+// setClientToken 验证OAuth2客户端访问令牌，成功时以客户端所属用户的身份设置用户信息到上下文，
+// 使用量、配额和限流都计入该用户
+func setClientToken(c *gin.Context, jwtService *jwt.JWTService, tokenString string) bool {
+	claims, owner, err := jwtService.ValidateClientToken(tokenString)
+	if err != nil {
+		return false
+	}
+
+	c.Set(string(jwt.ClientClaimsKey), claims)
+	c.Set(string(jwt.UserIDKey), owner.ID)
+	c.Set(string(jwt.UsernameKey), owner.Username)
+	c.Set(string(jwt.UserRoleKey), owner.Role)
+	return true
+}
+
+// setSignedAPIKey 设置签名认证使用的APIKey信息到上下文
+func setSignedAPIKey(c *gin.Context, apiKeyModel *model.APIKey) {
+	c.Set(string(apikey.APIKeyKey), apiKeyModel)
+	c.Set(string(apikey.APIKeyUserIDKey), apiKeyModel.UserID)
+	c.Set(string(apikey.SignedRequestKey), true)
+}
+
 // IsAPIKeyAuth 检查是否为APIKey认证
 func IsAPIKeyAuth(c *gin.Context) bool {
 	_, exists := c.Get(string(apikey.APIKeyKey))
 	return exists
 }
 
+// GetAuthMethod 获取当前请求的认证方式
+func GetAuthMethod(c *gin.Context) string {
+	switch {
+	case IsJWTAuth(c):
+		return model.AuthMethodJWT
+	case IsOAuthAuth(c):
+		return model.AuthMethodOAuth
+	case apikey.IsSignedAuth(c):
+		return model.AuthMethodSignature
+	case IsAPIKeyAuth(c):
+		return model.AuthMethodAPIKey
+	default:
+		return model.AuthMethodAnonymous
+	}
+}
+
 // getAPIKeyFromRequest 从请求中获取APIKey
 func getAPIKeyFromRequest(c *gin.Context) string {
 	// 1. 从X-API-Key头获取
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"apihub/internal/auth/apikey"
+	"apihub/internal/model"
+	"apihub/internal/provider/registry"
+	"apihub/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ipRuleSet 编译后的单个作用目标的IP规则集合
+type ipRuleSet struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// check 检查IP是否被规则集合允许
+// 命中拒绝列表则拒绝；存在允许列表但未命中也拒绝
+func (s *ipRuleSet) check(ip net.IP) (bool, string) {
+	for _, ipNet := range s.deny {
+		if ipNet.Contains(ip) {
+			return false, "命中拒绝规则 " + ipNet.String()
+		}
+	}
+
+	if len(s.allow) == 0 {
+		return true, ""
+	}
+
+	for _, ipNet := range s.allow {
+		if ipNet.Contains(ip) {
+			return true, ""
+		}
+	}
+
+	return false, "不在允许列表中"
+}
+
+// IPFilter IP访问控制过滤器
+// 规则保存在数据库中，内存中缓存编译后的CIDR，规则变更后需调用Reload
+type IPFilter struct {
+	store store.Store
+
+	mu    sync.RWMutex
+	rules map[string]*ipRuleSet // 作用范围:目标 -> 规则集合
+}
+
+// NewIPFilter 创建IP访问控制过滤器
+func NewIPFilter(store store.Store) *IPFilter {
+	return &IPFilter{
+		store: store,
+		rules: make(map[string]*ipRuleSet),
+	}
+}
+
+// ipRuleKey 生成规则集合的索引键
+func ipRuleKey(scope, target string) string {
+	return scope + ":" + target
+}
+
+// Reload 从数据库重新加载IP规则
+func (f *IPFilter) Reload(ctx context.Context) error {
+	rules, err := f.store.IPRules().List(ctx, "", "")
+	if err != nil {
+		return fmt.Errorf("加载IP规则失败: %w", err)
+	}
+
+	compiled := make(map[string]*ipRuleSet)
+	for _, rule := range rules {
+		_, ipNet, err := net.ParseCIDR(rule.CIDR)
+		if err != nil {
+			slog.WarnContext(ctx, "忽略无效的IP规则", "rule_id", rule.ID, "cidr", rule.CIDR)
+			continue
+		}
+
+		key := ipRuleKey(rule.Scope, rule.Target)
+		set, exists := compiled[key]
+		if !exists {
+			set = &ipRuleSet{}
+			compiled[key] = set
+		}
+
+		if rule.Action == model.IPRuleActionAllow {
+			set.allow = append(set.allow, ipNet)
+		} else {
+			set.deny = append(set.deny, ipNet)
+		}
+	}
+
+	f.mu.Lock()
+	f.rules = compiled
+	f.mu.Unlock()
+
+	return nil
+}
+
+// StartReloadTask 启动定期重新加载规则的任务
+// 用于同步其他实例对规则的修改
+func (f *IPFilter) StartReloadTask(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := f.Reload(ctx); err != nil {
+				slog.Error("定期加载IP规则失败", "error", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Check 依次按全局、服务、API密钥三个层级检查IP是否允许访问
+// 任一层级拒绝即拒绝，serviceName或apiKeyID为空时跳过对应层级
+func (f *IPFilter) Check(clientIP, serviceName string, apiKeyID int) (bool, string) {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false, "无法识别客户端IP地址"
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := []string{ipRuleKey(model.IPRuleScopeGlobal, "")}
+	if serviceName != "" {
+		keys = append(keys, ipRuleKey(model.IPRuleScopeService, serviceName))
+	}
+	if apiKeyID > 0 {
+		keys = append(keys, ipRuleKey(model.IPRuleScopeAPIKey, strconv.Itoa(apiKeyID)))
+	}
+
+	for _, key := range keys {
+		set, exists := f.rules[key]
+		if !exists {
+			continue
+		}
+		if allowed, reason := set.check(ip); !allowed {
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
+
+// Middleware 创建IP访问控制中间件
+// 应放在服务验证和认证之后，以便获取服务名称和API密钥
+func (f *IPFilter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var serviceName string
+		if serviceInfo, exists := c.Get("service_info"); exists {
+			if service, ok := serviceInfo.(*registry.ServiceInfo); ok {
+				serviceName = service.Definition.ServiceName
+			}
+		}
+
+		var apiKeyID int
+		if apiKey, exists := apikey.GetAPIKey(c); exists {
+			apiKeyID = apiKey.ID
+		}
+
+		if allowed, reason := f.Check(c.ClientIP(), serviceName, apiKeyID); !allowed {
+			slog.InfoContext(c.Request.Context(), "拒绝IP访问", "client_ip", c.ClientIP(), "service", serviceName, "reason", reason)
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(
+				model.CodeIPNotAllowed,
+				model.MsgIPNotAllowed,
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
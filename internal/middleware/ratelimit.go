@@ -1,118 +1,332 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"apihub/internal/auth/apikey"
+	"apihub/internal/metrics"
 	"apihub/internal/model"
 	"apihub/internal/provider/registry"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 限流主体类型常量
+const (
+	RateLimitSubjectIP     = "ip"      // 按IP地址限流（匿名访问）
+	RateLimitSubjectUser   = "user"    // 按用户ID限流（认证访问）
+	RateLimitSubjectAPIKey = "api_key" // 按API密钥ID限流（API密钥认证，同时计入所属用户）
+)
+
 // 限流器结构体，用于存储不同类型的限流器
 type RateLimiter struct {
 	mu            sync.RWMutex
-	ipLimiters    map[string]*rateLimiterEntry // IP地址 -> 限流器条目
-	userLimiters  map[int]*rateLimiterEntry    // 用户ID -> 限流器条目
-	serviceLimits map[string]int               // 服务名称 -> 限流值(每分钟)
-	defaultLimit  int                          // 默认限流值(每分钟)
+	entries       map[string]*rateLimiterEntry  // 主体类型:主体 -> 限流器条目
+	serviceLimits map[string]int                // 服务名称 -> 限流值(每分钟)
+	overrides     map[string]*RateLimitOverride // 主体类型:主体 -> 临时覆盖
+	defaultLimit  int                           // 默认限流值(每分钟)
 }
 
 // 限流器条目，包含限流器和最后访问时间
 type rateLimiterEntry struct {
+	subjectType string    // 主体类型
+	subject     string    // IP地址、用户ID或API密钥ID
 	count       int       // 当前时间窗口内的请求计数
 	windowStart time.Time // 当前时间窗口的开始时间
 	limit       int       // 限流值(每分钟)
 	lastAccess  time.Time // 最后访问时间
+	serviceName string    // 当前时间窗口对应的服务名称
+}
+
+// rateLimitSubject 一次请求需要检查的限流主体
+type rateLimitSubject struct {
+	subjectType string
+	subject     string
+}
+
+// RateLimitEntry 限流器条目快照，用于管理接口展示
+type RateLimitEntry struct {
+	SubjectType string             `json:"subject_type"` // ip、user 或 api_key
+	Subject     string             `json:"subject"`      // IP地址、用户ID或API密钥ID
+	ServiceName string             `json:"service_name"` // 当前时间窗口对应的服务
+	Count       int                `json:"count"`        // 当前时间窗口内的请求计数
+	WindowStart time.Time          `json:"window_start"` // 当前时间窗口的开始时间
+	Limit       int                `json:"limit"`        // 生效的限流值，-1表示不限制
+	LastAccess  time.Time          `json:"last_access"`  // 最后访问时间
+	Override    *RateLimitOverride `json:"override,omitempty"`
+}
+
+// RateLimitOverrideScopeInstance 临时覆盖只在设置它的实例上生效
+const RateLimitOverrideScopeInstance = "instance"
+
+// RateLimitOverride 临时限流覆盖，到期后自动失效
+// 覆盖与限流器条目一样只保存在当前进程的内存中，进程重启后丢失，多实例部署时不在实例之间共享
+type RateLimitOverride struct {
+	SubjectType string    `json:"subject_type"`
+	Subject     string    `json:"subject"`
+	Limit       int       `json:"limit"` // 覆盖后的限流值(每分钟)，-1表示不限制
+	Reason      string    `json:"reason"`
+	Scope       string    `json:"scope"` // 生效范围，目前固定为instance，即只在当前实例生效且重启后丢失
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
 
 // 创建新的限流器
 func NewRateLimiter(defaultLimit int) *RateLimiter {
 	return &RateLimiter{
-		ipLimiters:    make(map[string]*rateLimiterEntry),
-		userLimiters:  make(map[int]*rateLimiterEntry),
+		entries:       make(map[string]*rateLimiterEntry),
 		serviceLimits: make(map[string]int),
+		overrides:     make(map[string]*RateLimitOverride),
 		defaultLimit:  defaultLimit, // 每分钟请求数
 	}
 }
 
-// 检查并更新IP限流器
-func (r *RateLimiter) checkIPLimit(ip string, serviceLimit int) bool {
+// check 检查并更新一次请求涉及的所有限流主体
+// 所有主体都未超出限制时才允许请求并增加各自的计数，被拒绝的请求不计数；
+// 返回是否允许，拒绝时同时返回超出限制的主体
+func (r *RateLimiter) check(serviceName string, serviceLimit int, subjects ...rateLimitSubject) (bool, rateLimitSubject) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	now := time.Now()
+	limit := serviceLimit
+	if limit <= 0 {
+		limit = r.defaultLimit
+	}
+
+	for _, subject := range subjects {
+		override := r.activeOverride(subject.subjectType, subject.subject, now)
+
+		entry, exists := r.entries[subjectKey(subject.subjectType, subject.subject)]
+		if !exists || now.Sub(entry.windowStart) > time.Minute {
+			// 新的时间窗口，临时覆盖禁止访问时直接拒绝
+			if override != nil && override.Limit == 0 {
+				return false, subject
+			}
+			continue
+		}
+
+		// 更新最后访问时间
+		entry.lastAccess = now
 
-	// 获取或创建限流器条目
-	entry, exists := r.ipLimiters[ip]
-	if !exists || now.Sub(entry.windowStart) > time.Minute {
-		// 创建新条目或重置时间窗口
-		limit := serviceLimit
-		if limit <= 0 {
-			limit = r.defaultLimit
+		// 检查是否超出限制
+		if entry.count >= effectiveLimit(entry.limit, override) {
+			return false, subject
 		}
+	}
 
-		r.ipLimiters[ip] = &rateLimiterEntry{
-			count:       1,
-			windowStart: now,
-			limit:       limit,
-			lastAccess:  now,
+	// 全部通过后再增加计数，创建新条目或重置时间窗口
+	for _, subject := range subjects {
+		key := subjectKey(subject.subjectType, subject.subject)
+		entry, exists := r.entries[key]
+		if !exists || now.Sub(entry.windowStart) > time.Minute {
+			r.entries[key] = &rateLimiterEntry{
+				subjectType: subject.subjectType,
+				subject:     subject.subject,
+				count:       1,
+				windowStart: now,
+				limit:       limit,
+				lastAccess:  now,
+				serviceName: serviceName,
+			}
+			continue
 		}
-		return true // 允许请求
+		entry.count++
+	}
+	return true, rateLimitSubject{}
+}
+
+// requestSubjects 获取请求需要检查的限流主体
+// 认证用户按用户ID限流，使用API密钥时同时按密钥ID限流；匿名访问按IP地址限流
+func requestSubjects(c *gin.Context) []rateLimitSubject {
+	userID, exists := GetCurrentUserID(c)
+	if !exists || userID <= 0 {
+		return []rateLimitSubject{{subjectType: RateLimitSubjectIP, subject: c.ClientIP()}}
+	}
+
+	subjects := []rateLimitSubject{{subjectType: RateLimitSubjectUser, subject: strconv.Itoa(userID)}}
+	if apiKey, ok := apikey.GetAPIKey(c); ok {
+		subjects = append(subjects, rateLimitSubject{subjectType: RateLimitSubjectAPIKey, subject: strconv.Itoa(apiKey.ID)})
+	}
+	return subjects
+}
+
+// effectiveLimit 计算考虑临时覆盖后的限流值
+// 覆盖值为-1时表示不限制，返回int最大值
+func effectiveLimit(limit int, override *RateLimitOverride) int {
+	if override == nil {
+		return limit
+	}
+	if override.Limit < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return override.Limit
+}
+
+// subjectKey 生成限流主体的映射键，限流器条目和临时覆盖共用
+func subjectKey(subjectType, subject string) string {
+	return subjectType + ":" + subject
+}
+
+// activeOverride 获取未过期的临时覆盖，调用方需持有锁
+func (r *RateLimiter) activeOverride(subjectType, subject string, now time.Time) *RateLimitOverride {
+	override, exists := r.overrides[subjectKey(subjectType, subject)]
+	if !exists || !now.Before(override.ExpiresAt) {
+		return nil
+	}
+	return override
+}
+
+// validateSubject 校验限流主体类型和主体标识
+func validateSubject(subjectType, subject string) error {
+	switch subjectType {
+	case RateLimitSubjectIP:
+		if subject == "" {
+			return errors.New("IP地址不能为空")
+		}
+	case RateLimitSubjectUser:
+		if id, err := strconv.Atoi(subject); err != nil || id <= 0 {
+			return errors.New("无效的用户ID")
+		}
+	case RateLimitSubjectAPIKey:
+		if id, err := strconv.Atoi(subject); err != nil || id <= 0 {
+			return errors.New("无效的API密钥ID")
+		}
+	default:
+		return fmt.Errorf("不支持的限流主体类型: %s", subjectType)
+	}
+	return nil
+}
+
+// ListEntries 列出当前所有限流器条目
+// 结果按最后访问时间倒序排列
+func (r *RateLimiter) ListEntries() []RateLimitEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	entries := make([]RateLimitEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, r.snapshotEntry(entry, now))
 	}
 
-	// 更新最后访问时间
-	entry.lastAccess = now
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastAccess.After(entries[j].LastAccess)
+	})
+
+	return entries
+}
+
+// snapshotEntry 生成限流器条目快照，调用方需持有锁
+func (r *RateLimiter) snapshotEntry(entry *rateLimiterEntry, now time.Time) RateLimitEntry {
+	snapshot := RateLimitEntry{
+		SubjectType: entry.subjectType,
+		Subject:     entry.subject,
+		ServiceName: entry.serviceName,
+		Count:       entry.count,
+		WindowStart: entry.windowStart,
+		Limit:       entry.limit,
+		LastAccess:  entry.lastAccess,
+	}
 
-	// 检查是否超出限制
-	if entry.count >= entry.limit {
-		return false // 拒绝请求
+	if override := r.activeOverride(entry.subjectType, entry.subject, now); override != nil {
+		copied := *override
+		snapshot.Override = &copied
+		snapshot.Limit = override.Limit
 	}
 
-	// 增加计数
-	entry.count++
-	return true // 允许请求
+	return snapshot
 }
 
-// 检查并更新用户限流器
-func (r *RateLimiter) checkUserLimit(userID int, serviceLimit int) bool {
+// ResetEntry 重置指定主体的限流器条目
+// 返回是否存在该条目
+func (r *RateLimiter) ResetEntry(subjectType, subject string) (bool, error) {
+	if err := validateSubject(subjectType, subject); err != nil {
+		return false, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	key := subjectKey(subjectType, subject)
+	if _, exists := r.entries[key]; !exists {
+		return false, nil
+	}
+	delete(r.entries, key)
+	return true, nil
+}
+
+// SetOverride 设置临时限流覆盖
+// limit为-1表示在有效期内解除限流，ttl到期后覆盖自动失效；覆盖不持久化，只对当前实例生效
+func (r *RateLimiter) SetOverride(subjectType, subject string, limit int, ttl time.Duration, reason string) (*RateLimitOverride, error) {
+	if err := validateSubject(subjectType, subject); err != nil {
+		return nil, err
+	}
+	if limit < -1 {
+		return nil, errors.New("限流值必须大于等于-1")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("覆盖有效期必须大于0")
+	}
+
 	now := time.Now()
+	override := &RateLimitOverride{
+		SubjectType: subjectType,
+		Subject:     subject,
+		Limit:       limit,
+		Reason:      reason,
+		Scope:       RateLimitOverrideScopeInstance,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
 
-	// 获取或创建限流器条目
-	entry, exists := r.userLimiters[userID]
-	if !exists || now.Sub(entry.windowStart) > time.Minute {
-		// 创建新条目或重置时间窗口
-		limit := serviceLimit
-		if limit <= 0 {
-			limit = r.defaultLimit
-		}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[subjectKey(subjectType, subject)] = override
 
-		r.userLimiters[userID] = &rateLimiterEntry{
-			count:       1,
-			windowStart: now,
-			limit:       limit,
-			lastAccess:  now,
-		}
-		return true // 允许请求
+	copied := *override
+	return &copied, nil
+}
+
+// RemoveOverride 移除临时限流覆盖
+// 返回是否存在该覆盖
+func (r *RateLimiter) RemoveOverride(subjectType, subject string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := subjectKey(subjectType, subject)
+	if _, exists := r.overrides[key]; !exists {
+		return false
 	}
+	delete(r.overrides, key)
+	return true
+}
 
-	// 更新最后访问时间
-	entry.lastAccess = now
+// ListOverrides 列出所有未过期的临时限流覆盖
+func (r *RateLimiter) ListOverrides() []RateLimitOverride {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// 检查是否超出限制
-	if entry.count >= entry.limit {
-		return false // 拒绝请求
+	now := time.Now()
+	overrides := make([]RateLimitOverride, 0, len(r.overrides))
+	for _, override := range r.overrides {
+		if now.Before(override.ExpiresAt) {
+			overrides = append(overrides, *override)
+		}
 	}
 
-	// 增加计数
-	entry.count++
-	return true // 允许请求
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].ExpiresAt.Before(overrides[j].ExpiresAt)
+	})
+
+	return overrides
 }
 
 // 设置服务限流值
@@ -140,17 +354,17 @@ func (r *RateLimiter) CleanupExpired(maxAge time.Duration) {
 
 	now := time.Now()
 
-	// 清理IP限流器
-	for ip, entry := range r.ipLimiters {
+	// 清理长时间未访问的限流器条目
+	for key, entry := range r.entries {
 		if now.Sub(entry.lastAccess) > maxAge {
-			delete(r.ipLimiters, ip)
+			delete(r.entries, key)
 		}
 	}
 
-	// 清理用户限流器
-	for userID, entry := range r.userLimiters {
-		if now.Sub(entry.lastAccess) > maxAge {
-			delete(r.userLimiters, userID)
+	// 清理已过期的临时覆盖
+	for key, override := range r.overrides {
+		if !now.Before(override.ExpiresAt) {
+			delete(r.overrides, key)
 		}
 	}
 }
@@ -185,20 +399,11 @@ func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
 		// 如果无法确定服务名称，使用默认限流
 		serviceLimit := limiter.GetServiceLimit(serviceName)
 
-		// 检查是否为认证用户
-		userID, exists := GetCurrentUserID(c)
-
-		var allowed bool
-
-		if exists && userID > 0 {
-			// 认证用户 - 使用用户级限流
-			allowed = limiter.checkUserLimit(userID, serviceLimit)
-		} else {
-			// 匿名用户 - 使用IP级限流
-			allowed = limiter.checkIPLimit(c.ClientIP(), serviceLimit)
-		}
+		// 认证用户使用用户级限流，匿名用户使用IP级限流
+		allowed, rejected := limiter.check(serviceName, serviceLimit, requestSubjects(c)...)
 
 		if !allowed {
+			metrics.RateLimitRejected(serviceName, rejected.subjectType)
 			c.JSON(http.StatusTooManyRequests, model.NewErrorResponse(
 				model.CodeRateLimitExceeded,
 				"请求过于频繁，请稍后再试",
@@ -242,29 +447,14 @@ func ServiceRateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
 			limiter.SetServiceLimit(serviceName, rateLimit)
 		}
 
-		// 获取用户ID或IP地址
-		userID, userExists := GetCurrentUserID(c)
-
-		var allowed bool
-
-		if userExists && userID > 0 {
-			// 认证用户 - 使用用户级限流
-			allowed = limiter.checkUserLimit(userID, rateLimit)
-
-			if !allowed {
-				fmt.Printf("用户 %d 访问服务 %s 被限流\n", userID, serviceName)
-			}
-		} else {
-			// 匿名用户 - 使用IP级限流
-			ip := c.ClientIP()
-			allowed = limiter.checkIPLimit(ip, rateLimit)
-
-			if !allowed {
-				fmt.Printf("IP %s 访问服务 %s 被限流\n", ip, serviceName)
-			}
+		// 认证用户使用用户级限流，匿名用户使用IP级限流
+		allowed, rejected := limiter.check(serviceName, rateLimit, requestSubjects(c)...)
+		if !allowed {
+			slog.InfoContext(c.Request.Context(), "请求被限流", "subject_type", rejected.subjectType, "subject", rejected.subject, "service", serviceName)
 		}
 
 		if !allowed {
+			metrics.RateLimitRejected(serviceName, rejected.subjectType)
 			c.JSON(http.StatusTooManyRequests, model.NewErrorResponse(
 				model.CodeRateLimitExceeded,
 				"请求过于频繁，请稍后再试",
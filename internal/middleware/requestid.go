@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"apihub/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader 请求ID请求头/响应头
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey 请求ID在上下文中的键
+	RequestIDKey = "request_id"
+
+	// maxRequestIDLength 允许透传的请求ID最大长度
+	maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware 请求ID中间件
+// 优先沿用上游传入的X-Request-ID，否则生成新的请求ID，并写回响应头
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		// 同时存入请求context，使用该context记录的日志会附带请求ID
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// GetRequestID 从上下文获取请求ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// isValidRequestID 检查外部传入的请求ID是否可以安全使用
+// 只接受长度合理的可见ASCII字符，避免日志注入
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
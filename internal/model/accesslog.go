@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// 状态码分类常量
+const (
+	StatusClass2xx = "2xx"
+	StatusClass3xx = "3xx"
+	StatusClass4xx = "4xx"
+	StatusClass5xx = "5xx"
+)
+
+// AccessLogFilter 访问日志查询条件
+// 零值字段表示不过滤
+type AccessLogFilter struct {
+	UserID      int
+	APIKeyID    int
+	ServiceName string
+	Endpoint    string    // 按前缀匹配
+	StatusClass string    // 2xx/3xx/4xx/5xx
+	StartTime   time.Time // 包含
+	EndTime     time.Time // 不包含
+	Cursor      int       // 只返回ID小于该值的日志，用于翻页
+	Limit       int       // 最大返回条数，<=0表示不限制
+}
+
+// AccessLogQueryRequest 访问日志查询请求
+type AccessLogQueryRequest struct {
+	StartTime   time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime     time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	UserID      int       `form:"user_id" binding:"min=0"`
+	APIKeyID    int       `form:"api_key_id" binding:"min=0"`
+	ServiceName string    `form:"service_name"`
+	Endpoint    string    `form:"endpoint"`
+	StatusClass string    `form:"status_class" binding:"omitempty,oneof=2xx 3xx 4xx 5xx"`
+	Cursor      int       `form:"cursor" binding:"min=0"`
+	Limit       int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// ToFilter 转换为查询条件
+func (r *AccessLogQueryRequest) ToFilter() (AccessLogFilter, error) {
+	if !r.StartTime.IsZero() && !r.EndTime.IsZero() && !r.EndTime.After(r.StartTime) {
+		return AccessLogFilter{}, errors.New("结束时间必须晚于开始时间")
+	}
+
+	return AccessLogFilter{
+		UserID:      r.UserID,
+		APIKeyID:    r.APIKeyID,
+		ServiceName: r.ServiceName,
+		Endpoint:    r.Endpoint,
+		StatusClass: r.StatusClass,
+		StartTime:   r.StartTime,
+		EndTime:     r.EndTime,
+		Cursor:      r.Cursor,
+		Limit:       r.Limit,
+	}, nil
+}
+
+// AccessLogListResponse 访问日志分页响应
+type AccessLogListResponse struct {
+	Items      []*AccessLog `json:"items"`
+	NextCursor int          `json:"next_cursor,omitempty"` // 下一页游标，为空表示没有更多数据
+}
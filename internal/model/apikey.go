@@ -1,20 +1,71 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
 // APIKey API密钥模型
 type APIKey struct {
-	ID        int        `json:"id" db:"id"`
-	UserID    int        `json:"user_id" db:"user_id"`
-	KeyName   string     `json:"key_name" db:"key_name"`
-	APIKey    string     `json:"api_key" db:"api_key"`
-	Status    int        `json:"status" db:"status"` // 0: disabled, 1: active
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	ID           int                `json:"id" db:"id"`
+	UserID       int                `json:"user_id" db:"user_id"`
+	KeyName      string             `json:"key_name" db:"key_name"`
+	KeyPrefix    string             `json:"key_prefix" db:"key_prefix"`     // 密钥前缀，用于查找和展示
+	KeyHash      string             `json:"-" db:"key_hash"`                // 完整密钥的HMAC-SHA256
+	LegacyKey    string             `json:"-" db:"legacy_key"`              // 旧版本AES加密保存的密钥，重新计算哈希后清空
+	APIKey       string             `json:"api_key,omitempty" db:"-"`       // 明文密钥，仅在创建和轮换时返回一次
+	Scopes       []string           `json:"scopes" db:"scopes"`             // 权限范围，见APIKeyScope常量
+	Restrictions APIKeyRestrictions `json:"restrictions" db:"restrictions"` // 使用限制
+	UseCount     int64              `json:"use_count" db:"use_count"`       // 设置了最大使用次数时累计的使用次数
+	Status       int                `json:"status" db:"status"`             // 0: disabled, 1: active
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+	ExpiresAt    *time.Time         `json:"expires_at" db:"expires_at"`
+
+	SuccessorID        int        `json:"successor_id,omitempty" db:"successor_id"`     // 轮换后的继任密钥ID
+	PredecessorID      int        `json:"predecessor_id,omitempty" db:"predecessor_id"` // 轮换前的旧密钥ID
+	RotatedAt          *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`         // 轮换时间，宽限期结束时间即ExpiresAt
+	RotationNotifiedAt *time.Time `json:"-" db:"rotation_notified_at"`                  // 宽限期即将结束的通知发送时间
+
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"` // 最后一次调用的时间，由访问日志写入器批量更新
+	LastUsedIP string     `json:"last_used_ip" db:"last_used_ip"` // 最后一次调用的客户端IP
+
+	SigningSecret string `json:"-" db:"signing_secret"` // 加密保存的请求签名密钥，为空表示未启用签名认证
+}
+
+// APIKeyScope API密钥权限范围
+// service:<服务名称> 允许调用指定服务，service:* 允许调用所有服务；
+// dashboard:read 允许以密钥所属用户的身份访问只读的Dashboard接口（使用统计、访问日志）
+const (
+	APIKeyScopeServicePrefix = "service:"
+	APIKeyScopeServiceAll    = APIKeyScopeServicePrefix + "*"
+	APIKeyScopeDashboardRead = "dashboard:read"
+)
+
+// APIKeyRestrictions API密钥使用限制，未设置的条件不做限制
+type APIKeyRestrictions struct {
+	// AllowedCIDRs 已停用，允许的网段通过apikey作用范围的IP访问规则设置，请求中携带时拒绝
+	AllowedCIDRs     []string           `json:"allowed_cidrs,omitempty" swaggerignore:"true"`
+	AllowedReferrers []string           `json:"allowed_referrers,omitempty"` // 允许的请求来源，匹配Origin或Referer头，*为通配符；不含://时只匹配主机名
+	TimeWindows      []APIKeyTimeWindow `json:"time_windows,omitempty"`      // 允许使用的时间段，满足任意一个即可
+	Timezone         string             `json:"timezone,omitempty"`          // 时间段所用的时区，如Asia/Shanghai，默认服务器本地时区
+	MaxUses          int64              `json:"max_uses,omitempty"`          // 最大使用次数，0表示不限制
+}
+
+// APIKeyTimeWindow API密钥允许使用的时间段
+type APIKeyTimeWindow struct {
+	Days  []int  `json:"days,omitempty"` // 星期几，0为周日，为空表示每天
+	Start string `json:"start"`          // 开始时间，HH:MM
+	End   string `json:"end"`            // 结束时间（不包含），HH:MM，可为24:00；早于开始时间表示跨越午夜
 }
 
+// IsEmpty 检查是否未设置任何使用限制
+func (r *APIKeyRestrictions) IsEmpty() bool {
+	return len(r.AllowedReferrers) == 0 && len(r.TimeWindows) == 0 && r.MaxUses == 0
+}
+
+// DefaultAPIKeyScopes 未指定权限范围时的默认值，允许调用所有服务
+var DefaultAPIKeyScopes = []string{APIKeyScopeServiceAll}
+
 // APIKeyStatus API密钥状态常量
 const (
 	APIKeyStatusDisabled = 0
@@ -27,6 +78,75 @@ type CreateAPIKeyRequest struct {
 	ExpiresAt *time.Time `json:"expires_at"`
 }
 
+// UpdateAPIKeyScopesRequest 更新API密钥权限范围请求
+type UpdateAPIKeyScopesRequest struct {
+	APIKeyID int      `json:"api_key_id" binding:"required"`
+	Scopes   []string `json:"scopes" binding:"required,min=1,max=50"`
+}
+
+// UpdateAPIKeyRestrictionsRequest 更新API密钥使用限制请求，restrictions为空对象时清除所有限制
+type UpdateAPIKeyRestrictionsRequest struct {
+	APIKeyID     int                `json:"api_key_id" binding:"required"`
+	Restrictions APIKeyRestrictions `json:"restrictions"`
+}
+
+// RotateAPIKeyRequest 轮换API密钥请求
+type RotateAPIKeyRequest struct {
+	APIKeyID     int  `json:"api_key_id" binding:"required"`
+	GraceMinutes *int `json:"grace_minutes" binding:"omitempty,min=0"` // 旧密钥继续有效的分钟数，为空时使用默认宽限期，0表示立即失效
+}
+
+// RotateAPIKeyResponse 轮换API密钥响应
+type RotateAPIKeyResponse struct {
+	NewKey *APIKey         `json:"new_key"` // 继任密钥，包含仅此一次可见的完整密钥
+	OldKey *APIKeyResponse `json:"old_key"` // 旧密钥，expires_at为宽限期结束时间
+}
+
+// APIKeyRotationStatus API密钥轮换状态，统计轮换以来新旧密钥各自的调用情况
+type APIKeyRotationStatus struct {
+	OldKey        *APIKeyResponse    `json:"old_key"`
+	NewKey        *APIKeyResponse    `json:"new_key"`
+	RotatedAt     time.Time          `json:"rotated_at"`
+	GraceEndsAt   *time.Time         `json:"grace_ends_at"`   // 旧密钥失效时间
+	InGracePeriod bool               `json:"in_grace_period"` // 旧密钥是否仍然有效
+	Usage         []*APIKeyCallStats `json:"usage"`           // 依次为旧密钥和新密钥自轮换以来的调用情况
+}
+
+// APIKeyCallStats API密钥在一段时间内的调用情况
+type APIKeyCallStats struct {
+	APIKeyID     int        `json:"api_key_id"`
+	Calls        int64      `json:"calls"`
+	LastCalledAt *time.Time `json:"last_called_at"`
+	ClientIPs    []string   `json:"client_ips"` // 调用过的客户端IP，最多返回20个
+}
+
+// APIKeyUsage API密钥使用情况
+// 调用次数来自使用量汇总：24小时和7天按小时汇总滚动计算，30天按天汇总（UTC自然日，含当天）
+type APIKeyUsage struct {
+	APIKeyID      int               `json:"api_key_id"`
+	LastUsedAt    *time.Time        `json:"last_used_at"`
+	LastUsedIP    string            `json:"last_used_ip"`
+	Calls24h      int64             `json:"calls_24h"`
+	Calls7d       int64             `json:"calls_7d"`
+	Calls30d      int64             `json:"calls_30d"`
+	ErrorCalls30d int64             `json:"error_calls_30d"`
+	Daily         []UsageStatsPoint `json:"daily"`                     // 最近30天每天的调用情况，没有调用的日期不返回
+	AutoDisableAt *time.Time        `json:"auto_disable_at,omitempty"` // 启用了自动禁用时，继续不使用将被禁用的时间
+}
+
+// APIKeySigningRequest 生成或删除API密钥签名密钥请求
+type APIKeySigningRequest struct {
+	APIKeyID int `json:"api_key_id" binding:"required"`
+}
+
+// APIKeySigningSecretResponse 生成签名密钥响应
+// 访问密钥即API密钥的前缀，签名密钥只在本次响应中返回
+type APIKeySigningSecretResponse struct {
+	APIKeyID  int    `json:"api_key_id"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
 // UpdateAPIKeyRequest 更新API密钥请求
 type UpdateAPIKeyRequest struct {
 	KeyName   string     `json:"key_name" binding:"omitempty,min=1,max=100"`
@@ -34,14 +154,26 @@ type UpdateAPIKeyRequest struct {
 	ExpiresAt *time.Time `json:"expires_at"`
 }
 
-// APIKeyResponse API密钥响应（隐藏完整密钥）
+// APIKeyResponse API密钥响应（只包含密钥前缀）
 type APIKeyResponse struct {
-	ID        int        `json:"id"`
-	KeyName   string     `json:"key_name"`
-	KeyPrefix string     `json:"key_prefix"` // 只显示前几位
-	Status    int        `json:"status"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at"`
+	ID           int                `json:"id"`
+	KeyName      string             `json:"key_name"`
+	KeyPrefix    string             `json:"key_prefix"` // 密钥前缀，完整密钥只在创建时返回
+	Scopes       []string           `json:"scopes"`
+	Restrictions APIKeyRestrictions `json:"restrictions"`
+	UseCount     int64              `json:"use_count"`
+	Status       int                `json:"status"`
+	CreatedAt    time.Time          `json:"created_at"`
+	ExpiresAt    *time.Time         `json:"expires_at"`
+
+	SuccessorID   int        `json:"successor_id,omitempty"`
+	PredecessorID int        `json:"predecessor_id,omitempty"`
+	RotatedAt     *time.Time `json:"rotated_at,omitempty"`
+
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+
+	SigningEnabled bool `json:"signing_enabled"` // 是否已启用请求签名认证
 }
 
 // IsActive 检查API密钥是否激活
@@ -58,6 +190,51 @@ func (ak *APIKey) IsActive() bool {
 	return true
 }
 
+// LastActiveAt 获取判断密钥是否长期未使用的起点，从未使用过的密钥为创建时间
+func (ak *APIKey) LastActiveAt() time.Time {
+	if ak.LastUsedAt != nil {
+		return *ak.LastUsedAt
+	}
+	return ak.CreatedAt
+}
+
+// HasScope 检查API密钥是否具有指定的权限范围
+func (ak *APIKey) HasScope(scope string) bool {
+	for _, s := range ak.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsService 检查API密钥是否允许调用指定服务
+func (ak *APIKey) AllowsService(serviceName string) bool {
+	return ScopesAllowService(ak.Scopes, serviceName)
+}
+
+// ScopesAllowService 检查权限范围列表是否允许调用指定服务，service:* 允许所有服务
+// API密钥和OAuth2客户端访问令牌使用相同的服务权限范围
+func ScopesAllowService(scopes []string, serviceName string) bool {
+	for _, s := range scopes {
+		if s == APIKeyScopeServiceAll {
+			return true
+		}
+		if name, ok := strings.CutPrefix(s, APIKeyScopeServicePrefix); ok && name == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows 检查API密钥是否允许指定的权限范围，service:<服务名称> 同时接受 service:*
+func (ak *APIKey) Allows(scope string) bool {
+	if serviceName, ok := strings.CutPrefix(scope, APIKeyScopeServicePrefix); ok {
+		return ak.AllowsService(serviceName)
+	}
+	return ak.HasScope(scope)
+}
+
 // IsExpired 检查API密钥是否过期
 func (ak *APIKey) IsExpired() bool {
 	if ak.ExpiresAt == nil {
@@ -68,19 +245,24 @@ func (ak *APIKey) IsExpired() bool {
 
 // ToResponse 转换为响应格式
 func (ak *APIKey) ToResponse() *APIKeyResponse {
-	keyPrefix := ""
-	if len(ak.APIKey) > 8 {
-		keyPrefix = ak.APIKey[:8] + "..."
-	} else {
-		keyPrefix = ak.APIKey
-	}
-
 	return &APIKeyResponse{
-		ID:        ak.ID,
-		KeyName:   ak.KeyName,
-		KeyPrefix: keyPrefix,
-		Status:    ak.Status,
-		CreatedAt: ak.CreatedAt,
-		ExpiresAt: ak.ExpiresAt,
+		ID:           ak.ID,
+		KeyName:      ak.KeyName,
+		KeyPrefix:    ak.KeyPrefix,
+		Scopes:       ak.Scopes,
+		Restrictions: ak.Restrictions,
+		UseCount:     ak.UseCount,
+		Status:       ak.Status,
+		CreatedAt:    ak.CreatedAt,
+		ExpiresAt:    ak.ExpiresAt,
+
+		SuccessorID:   ak.SuccessorID,
+		PredecessorID: ak.PredecessorID,
+		RotatedAt:     ak.RotatedAt,
+
+		LastUsedAt: ak.LastUsedAt,
+		LastUsedIP: ak.LastUsedIP,
+
+		SigningEnabled: ak.SigningSecret != "",
 	}
 }
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditTimeFormat 审计日志时间格式，固定宽度的UTC时间，用于哈希计算和按字符串比较
+const AuditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// 审计操作类型
+const (
+	AuditActionUserCreate        = "user.create"
+	AuditActionUserUpdate        = "user.update"
+	AuditActionUserDelete        = "user.delete"
+	AuditActionUserResetPassword = "user.reset_password"
+
+	AuditActionProfileUpdate  = "profile.update"
+	AuditActionPasswordChange = "profile.change_password"
+
+	AuditActionRefreshTokenReuse = "auth.refresh_token_reuse"
+
+	AuditActionSessionRevoke    = "session.revoke"
+	AuditActionSessionRevokeAll = "session.revoke_all"
+	AuditActionUserForceLogout  = "user.force_logout"
+
+	AuditActionAPIKeyCreate              = "apikey.create"
+	AuditActionAPIKeyUpdate              = "apikey.update"
+	AuditActionAPIKeyDelete              = "apikey.delete"
+	AuditActionAPIKeyRotate              = "apikey.rotate"
+	AuditActionAPIKeyAutoDisable         = "apikey.auto_disable"
+	AuditActionAPIKeySigningSecretSet    = "apikey.signing_secret_set"
+	AuditActionAPIKeySigningSecretDelete = "apikey.signing_secret_delete"
+
+	AuditActionIPRuleCreate = "iprule.create"
+	AuditActionIPRuleDelete = "iprule.delete"
+
+	AuditActionRateLimitOverrideCreate = "ratelimit.override_create"
+	AuditActionRateLimitOverrideDelete = "ratelimit.override_delete"
+	AuditActionRateLimitReset          = "ratelimit.reset"
+
+	AuditActionCaptureRuleCreate = "capture.rule_create"
+	AuditActionCaptureRuleUpdate = "capture.rule_update"
+	AuditActionCaptureRuleDelete = "capture.rule_delete"
+	AuditActionCaptureView       = "capture.view"
+	AuditActionCaptureReplay     = "capture.replay"
+
+	AuditActionSigningKeyRotate = "signing_key.rotate"
+
+	AuditActionOAuthClientCreate      = "oauth_client.create"
+	AuditActionOAuthClientUpdate      = "oauth_client.update"
+	AuditActionOAuthClientDelete      = "oauth_client.delete"
+	AuditActionOAuthClientResetSecret = "oauth_client.reset_secret"
+)
+
+// 审计操作对象类型
+const (
+	AuditTargetUser        = "user"
+	AuditTargetAPIKey      = "apikey"
+	AuditTargetIPRule      = "iprule"
+	AuditTargetRateLimit   = "ratelimit"
+	AuditTargetCaptureRule = "capture_rule"
+	AuditTargetCapture     = "capture"
+	AuditTargetSigningKey  = "signing_key"
+	AuditTargetSession     = "session"
+	AuditTargetOAuthClient = "oauth_client"
+)
+
+// AuditLog 审计日志模型
+type AuditLog struct {
+	ID         int             `json:"id" db:"id"`
+	ActorID    int             `json:"actor_id" db:"actor_id"`     // 操作人用户ID，0表示系统
+	ActorName  string          `json:"actor_name" db:"actor_name"` // 操作人用户名
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   string          `json:"target_id" db:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"before_data"` // 变更前的字段，只包含发生变化的字段
+	After      json.RawMessage `json:"after,omitempty" db:"after_data"`   // 变更后的字段，只包含发生变化的字段
+	ClientIP   string          `json:"client_ip" db:"client_ip"`
+	RequestID  string          `json:"request_id" db:"request_id"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	PrevHash   string          `json:"prev_hash" db:"prev_hash"`
+	Hash       string          `json:"hash" db:"hash"`
+}
+
+// auditHashPayload 参与哈希计算的字段，字段顺序固定
+type auditHashPayload struct {
+	PrevHash   string `json:"prev_hash"`
+	ID         int    `json:"id"`
+	ActorID    int    `json:"actor_id"`
+	ActorName  string `json:"actor_name"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	ClientIP   string `json:"client_ip"`
+	RequestID  string `json:"request_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ComputeHash 根据记录内容和PrevHash计算哈希
+func (l *AuditLog) ComputeHash() string {
+	payload, _ := json.Marshal(auditHashPayload{
+		PrevHash:   l.PrevHash,
+		ID:         l.ID,
+		ActorID:    l.ActorID,
+		ActorName:  l.ActorName,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		Before:     string(l.Before),
+		After:      string(l.After),
+		ClientIP:   l.ClientIP,
+		RequestID:  l.RequestID,
+		CreatedAt:  l.CreatedAt.UTC().Format(AuditTimeFormat),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLogFilter 审计日志查询条件
+// 零值字段表示不过滤
+type AuditLogFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   string
+	StartTime  time.Time // 包含
+	EndTime    time.Time // 不包含
+	Cursor     int       // 只返回ID小于该值的日志，用于翻页
+	Limit      int       // 最大返回条数，<=0表示不限制
+}
+
+// AuditLogQueryRequest 审计日志查询请求
+type AuditLogQueryRequest struct {
+	StartTime  time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime    time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	ActorID    int       `form:"actor_id" binding:"min=0"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	Cursor     int       `form:"cursor" binding:"min=0"`
+	Limit      int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// ToFilter 转换为查询条件
+func (r *AuditLogQueryRequest) ToFilter() (AuditLogFilter, error) {
+	if !r.StartTime.IsZero() && !r.EndTime.IsZero() && !r.EndTime.After(r.StartTime) {
+		return AuditLogFilter{}, errors.New("结束时间必须晚于开始时间")
+	}
+
+	return AuditLogFilter{
+		ActorID:    r.ActorID,
+		Action:     r.Action,
+		TargetType: r.TargetType,
+		TargetID:   r.TargetID,
+		StartTime:  r.StartTime,
+		EndTime:    r.EndTime,
+		Cursor:     r.Cursor,
+		Limit:      r.Limit,
+	}, nil
+}
+
+// AuditLogListResponse 审计日志分页响应
+type AuditLogListResponse struct {
+	Items      []*AuditLog `json:"items"`
+	NextCursor int         `json:"next_cursor,omitempty"` // 下一页游标，为空表示没有更多数据
+}
+
+// AuditVerifyResponse 审计日志哈希链校验结果
+type AuditVerifyResponse struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`             // 已校验的记录数
+	LastID   int    `json:"last_id"`             // 最后一条通过校验的记录ID
+	LastHash string `json:"last_hash"`           // 最后一条通过校验的记录哈希，可留存用于后续比对
+	BrokenID int    `json:"broken_id,omitempty"` // 第一条校验失败的记录ID
+	Reason   string `json:"reason,omitempty"`
+}
@@ -8,14 +8,18 @@ import (
 type LoginRequest struct {
 	Username string `json:"username" binding:"required,min=1,max=50"`
 	Password string `json:"password" binding:"required,min=6,max=100"`
+	Device   string `json:"device" binding:"max=100"` // 可选的设备名称，显示在会话列表中
 }
 
 // LoginResponse 登录响应
+// 刷新令牌时返回相同结构，refresh_token为轮换后的新令牌
 type LoginResponse struct {
-	AccessToken string    `json:"access_token"`
-	ExpiresIn   int64     `json:"expires_in"` // 访问令牌过期时间(秒)
-	TokenType   string    `json:"token_type"`
-	User        *UserInfo `json:"user"`
+	AccessToken      string    `json:"access_token"`
+	ExpiresIn        int64     `json:"expires_in"` // 访问令牌过期时间(秒)
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresIn int64     `json:"refresh_expires_in"` // 刷新令牌过期时间(秒)
+	TokenType        string    `json:"token_type"`
+	User             *UserInfo `json:"user"`
 }
 
 // LogoutRequest 登出请求
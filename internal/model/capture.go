@@ -0,0 +1,194 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// CaptureRuleScope 抓取规则作用范围常量
+const (
+	CaptureScopeService = "service"
+	CaptureScopeAPIKey  = "apikey"
+)
+
+// CaptureRule 请求/响应内容抓取规则模型
+// 默认不抓取任何内容，只有命中启用的规则时才按采样率抓取
+type CaptureRule struct {
+	ID              int       `json:"id" db:"id"`
+	Scope           string    `json:"scope" db:"scope"`   // service/apikey
+	Target          string    `json:"target" db:"target"` // 服务名称或API密钥ID
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	SampleRate      float64   `json:"sample_rate" db:"sample_rate"`             // 采样率，取值0~1
+	MaxBodyBytes    int       `json:"max_body_bytes" db:"max_body_bytes"`       // 请求体和响应体各自最多保存的字节数，0表示使用系统默认值
+	RedactHeaders   []string  `json:"redact_headers" db:"redact_headers"`       // 额外需要脱敏的请求头和响应头
+	RedactJSONPaths []string  `json:"redact_json_paths" db:"redact_json_paths"` // JSON请求体和响应体中需要脱敏的字段路径，如user.password、items.*.token
+	RedactPatterns  []string  `json:"redact_patterns" db:"redact_patterns"`     // 需要脱敏的内容正则表达式，匹配部分替换为[REDACTED]
+	RetentionHours  int       `json:"retention_hours" db:"retention_hours"`     // 抓取记录保留小时数，0表示使用系统默认值
+	Replayable      bool      `json:"replayable" db:"replayable"`               // 是否额外保存未脱敏的原始请求用于重放
+	Description     string    `json:"description" db:"description"`
+	CreatedBy       int       `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateCaptureRuleRequest 创建抓取规则请求
+type CreateCaptureRuleRequest struct {
+	Scope           string   `json:"scope" binding:"required,oneof=service apikey"`
+	Target          string   `json:"target" binding:"required"`
+	Enabled         *bool    `json:"enabled"` // 默认启用
+	SampleRate      float64  `json:"sample_rate" binding:"gt=0,lte=1"`
+	MaxBodyBytes    int      `json:"max_body_bytes" binding:"min=0"`
+	RedactHeaders   []string `json:"redact_headers" binding:"max=50,dive,required,max=100"`
+	RedactJSONPaths []string `json:"redact_json_paths" binding:"max=50,dive,required,max=200"`
+	RedactPatterns  []string `json:"redact_patterns" binding:"max=20,dive,required,max=500"`
+	RetentionHours  int      `json:"retention_hours" binding:"min=0"`
+	Replayable      bool     `json:"replayable"`
+	Description     string   `json:"description" binding:"max=200"`
+}
+
+// UpdateCaptureRuleRequest 更新抓取规则请求，未设置的字段保持不变
+type UpdateCaptureRuleRequest struct {
+	RuleID          int       `json:"rule_id" binding:"required"`
+	Enabled         *bool     `json:"enabled"`
+	SampleRate      *float64  `json:"sample_rate" binding:"omitempty,gt=0,lte=1"`
+	MaxBodyBytes    *int      `json:"max_body_bytes" binding:"omitempty,min=0"`
+	RedactHeaders   *[]string `json:"redact_headers" binding:"omitempty,max=50,dive,required,max=100"`
+	RedactJSONPaths *[]string `json:"redact_json_paths" binding:"omitempty,max=50,dive,required,max=200"`
+	RedactPatterns  *[]string `json:"redact_patterns" binding:"omitempty,max=20,dive,required,max=500"`
+	RetentionHours  *int      `json:"retention_hours" binding:"omitempty,min=0"`
+	Replayable      *bool     `json:"replayable"`
+	Description     *string   `json:"description" binding:"omitempty,max=200"`
+}
+
+// CapturedExchange 抓取到的请求/响应记录
+// 内容加密保存在Payload中，列表只返回明文元数据
+type CapturedExchange struct {
+	ID                int       `json:"id" db:"id"`
+	RuleID            int       `json:"rule_id" db:"rule_id"`
+	RequestID         string    `json:"request_id" db:"request_id"`
+	ServiceName       string    `json:"service_name" db:"service_name"`
+	UserID            int       `json:"user_id" db:"user_id"`
+	APIKeyID          int       `json:"api_key_id" db:"api_key_id"`
+	Method            string    `json:"method" db:"method"`
+	Endpoint          string    `json:"endpoint" db:"endpoint"`
+	Status            int       `json:"status" db:"status"`
+	DurationMs        int64     `json:"duration_ms" db:"duration_ms"`
+	RequestTruncated  bool      `json:"request_truncated" db:"request_truncated"`   // 请求体超过大小限制被截断
+	ResponseTruncated bool      `json:"response_truncated" db:"response_truncated"` // 响应体超过大小限制被截断
+	Replayable        bool      `json:"replayable"`                                 // 是否保存了可用于重放的原始请求
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at" db:"expires_at"`
+	Payload           string    `json:"-" db:"payload"`        // 加密后的CapturePayload
+	ReplayPayload     string    `json:"-" db:"replay_payload"` // 加密后的ReplayRequest，不可重放时为空
+}
+
+// CapturePayload 抓取到的请求/响应内容，已脱敏
+type CapturePayload struct {
+	Query           string      `json:"query,omitempty"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body"`
+	ResponseHeaders http.Header `json:"response_headers"`
+	ResponseBody    string      `json:"response_body"`
+}
+
+// CapturedExchangeDetail 抓取记录详情，包含解密后的内容
+type CapturedExchangeDetail struct {
+	*CapturedExchange
+	Payload *CapturePayload `json:"payload"`
+}
+
+// CaptureFilter 抓取记录查询条件
+// 零值字段表示不过滤，已过期的记录不会返回
+type CaptureFilter struct {
+	ServiceName string
+	APIKeyID    int
+	UserID      int
+	RequestID   string
+	StartTime   time.Time // 包含
+	EndTime     time.Time // 不包含
+	Cursor      int       // 只返回ID小于该值的记录，用于翻页
+	Limit       int       // 最大返回条数，<=0表示不限制
+}
+
+// CaptureQueryRequest 抓取记录查询请求
+type CaptureQueryRequest struct {
+	ServiceName string    `form:"service_name"`
+	APIKeyID    int       `form:"api_key_id" binding:"min=0"`
+	UserID      int       `form:"user_id" binding:"min=0"`
+	RequestID   string    `form:"request_id"`
+	StartTime   time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime     time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor      int       `form:"cursor" binding:"min=0"`
+	Limit       int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// ToFilter 转换为查询条件
+func (r *CaptureQueryRequest) ToFilter() (CaptureFilter, error) {
+	if !r.StartTime.IsZero() && !r.EndTime.IsZero() && !r.EndTime.After(r.StartTime) {
+		return CaptureFilter{}, errors.New("结束时间必须晚于开始时间")
+	}
+
+	return CaptureFilter{
+		ServiceName: r.ServiceName,
+		APIKeyID:    r.APIKeyID,
+		UserID:      r.UserID,
+		RequestID:   r.RequestID,
+		StartTime:   r.StartTime,
+		EndTime:     r.EndTime,
+		Cursor:      r.Cursor,
+		Limit:       r.Limit,
+	}, nil
+}
+
+// CaptureListResponse 抓取记录分页响应
+type CaptureListResponse struct {
+	Items      []*CapturedExchange `json:"items"`
+	NextCursor int                 `json:"next_cursor,omitempty"` // 下一页游标，为空表示没有更多数据
+}
+
+// ReplayRequest 用于重放的原始请求，未脱敏，加密保存且不通过接口返回
+// 认证相关请求头和规则中配置的脱敏请求头不会保存
+type ReplayRequest struct {
+	Method  string      `json:"method"`
+	Query   string      `json:"query,omitempty"`
+	Headers http.Header `json:"headers"`
+	Body    []byte      `json:"body"`
+}
+
+// ReplayCaptureRequest 重放抓取记录请求
+type ReplayCaptureRequest struct {
+	CaptureID   int      `json:"capture_id" binding:"required,min=1"`
+	DryRun      bool     `json:"dry_run"`                                             // 为true时不记录访问日志、不消耗配额
+	IgnorePaths []string `json:"ignore_paths" binding:"max=50,dive,required,max=200"` // 比较响应时忽略的JSON路径，如data.timestamp
+}
+
+// ReplayDifference 重放响应与原始响应的差异项
+type ReplayDifference struct {
+	Path     string `json:"path"` // JSON路径，响应体不是JSON时为空
+	Kind     string `json:"kind"` // changed/added/removed
+	Original any    `json:"original,omitempty"`
+	Replayed any    `json:"replayed,omitempty"`
+}
+
+// 重放差异类型常量
+const (
+	ReplayDiffChanged = "changed"
+	ReplayDiffAdded   = "added"
+	ReplayDiffRemoved = "removed"
+)
+
+// ReplayResult 重放结果
+type ReplayResult struct {
+	CaptureID       int                 `json:"capture_id"`
+	ServiceName     string              `json:"service_name"`
+	DryRun          bool                `json:"dry_run"`
+	OriginalStatus  int                 `json:"original_status"`
+	Status          int                 `json:"status"`
+	DurationMs      int64               `json:"duration_ms"`
+	ResponseHeaders http.Header         `json:"response_headers"`
+	ResponseBody    string              `json:"response_body"`
+	Identical       bool                `json:"identical"`      // 状态码和响应体均无差异
+	Differences     []*ReplayDifference `json:"differences"`    // 响应差异，原始响应中已脱敏的字段不参与比较
+	Note            string              `json:"note,omitempty"` // 无法完整比较时的说明，如原始响应体被截断
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// IPRule IP访问规则模型
+type IPRule struct {
+	ID          int       `json:"id" db:"id"`
+	Scope       string    `json:"scope" db:"scope"`   // global/service/apikey
+	Target      string    `json:"target" db:"target"` // 服务名称或API密钥ID，全局规则为空
+	Action      string    `json:"action" db:"action"` // allow/deny
+	CIDR        string    `json:"cidr" db:"cidr"`
+	Description string    `json:"description" db:"description"`
+	CreatedBy   int       `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// IPRuleScope IP规则作用范围常量
+const (
+	IPRuleScopeGlobal  = "global"
+	IPRuleScopeService = "service"
+	IPRuleScopeAPIKey  = "apikey"
+)
+
+// IPRuleAction IP规则动作常量
+const (
+	IPRuleActionAllow = "allow"
+	IPRuleActionDeny  = "deny"
+)
+
+// CreateIPRuleRequest 创建IP规则请求
+type CreateIPRuleRequest struct {
+	Scope       string `json:"scope" binding:"required,oneof=global service apikey"`
+	Target      string `json:"target"`
+	Action      string `json:"action" binding:"required,oneof=allow deny"`
+	CIDR        string `json:"cidr" binding:"required"`
+	Description string `json:"description" binding:"max=200"`
+}
+
+// NormalizeCIDR 规范化CIDR
+// 支持单个IP地址（自动补全为/32或/128）
+func NormalizeCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("CIDR不能为空")
+	}
+
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", errors.New("无效的IP地址: " + value)
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", errors.New("无效的CIDR: " + value)
+	}
+	return ipNet.String(), nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// 实时流事件类型
+const (
+	LiveEventFrame  = "frame"  // 每秒汇总帧
+	LiveEventSample = "sample" // 抽样的原始访问日志
+)
+
+// LiveStats 实时流汇总统计
+type LiveStats struct {
+	Calls         int64 `json:"calls"`
+	Errors        int64 `json:"errors"`    // 状态码>=400的请求数，不含限流
+	Throttled     int64 `json:"throttled"` // 被限流（429）的请求数
+	AvgDurationMs int64 `json:"avg_duration_ms"`
+	MaxDurationMs int64 `json:"max_duration_ms"`
+
+	totalDurationMs int64
+}
+
+// Add 累加一条访问日志
+func (s *LiveStats) Add(accessLog *AccessLog) {
+	s.Calls++
+	switch {
+	case accessLog.Status == 429:
+		s.Throttled++
+	case accessLog.Status >= 400:
+		s.Errors++
+	}
+	s.totalDurationMs += accessLog.DurationMs
+	s.AvgDurationMs = s.totalDurationMs / s.Calls
+	s.MaxDurationMs = max(s.MaxDurationMs, accessLog.DurationMs)
+}
+
+// LiveFrame 实时流每秒汇总帧
+type LiveFrame struct {
+	Time      time.Time             `json:"time"`
+	LiveStats                       // 全部服务合计
+	Services  map[string]*LiveStats `json:"services"` // 按服务统计
+	Sampled   int                   `json:"sampled"`  // 本秒推送的原始事件数
+	Dropped   int64                 `json:"dropped"`  // 因客户端消费过慢被丢弃的事件数
+}
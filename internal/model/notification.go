@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// 通知类型
+const (
+	NotificationAPIKeyRotationEnding = "apikey.rotation_ending" // API密钥轮换宽限期即将结束
+	NotificationAPIKeyDisabledUnused = "apikey.disabled_unused" // API密钥长期未使用已被自动禁用
+)
+
+// Notification 站内通知模型
+type Notification struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Type       string     `json:"type" db:"type"` // 见Notification*常量
+	Title      string     `json:"title" db:"title"`
+	Message    string     `json:"message" db:"message"`
+	TargetType string     `json:"target_type,omitempty" db:"target_type"` // 关联对象类型，与审计日志的目标类型一致
+	TargetID   string     `json:"target_id,omitempty" db:"target_id"`
+	ReadAt     *time.Time `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NotificationListRequest 通知列表请求
+type NotificationListRequest struct {
+	UnreadOnly bool `form:"unread_only"`
+	Offset     int  `form:"offset" binding:"min=0"`
+	Limit      int  `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	Notifications []*Notification `json:"notifications"`
+	Unread        int             `json:"unread"` // 未读通知总数
+}
+
+// MarkNotificationsReadRequest 标记通知已读请求，ids为空时标记全部通知
+type MarkNotificationsReadRequest struct {
+	IDs []int `json:"ids" binding:"max=500"`
+}
+
+// MarkNotificationsReadResponse 标记通知已读响应
+type MarkNotificationsReadResponse struct {
+	Marked int64 `json:"marked"` // 本次标记为已读的通知数
+}
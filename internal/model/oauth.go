@@ -0,0 +1,117 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuth2授权类型和令牌类型
+const (
+	OAuthGrantClientCredentials = "client_credentials"
+	OAuthTokenTypeBearer        = "Bearer"
+)
+
+// OAuth2令牌端点错误码（RFC 6749 5.2）
+const (
+	OAuthErrInvalidRequest       = "invalid_request"
+	OAuthErrInvalidClient        = "invalid_client"
+	OAuthErrUnauthorizedClient   = "unauthorized_client"
+	OAuthErrUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrInvalidScope         = "invalid_scope"
+	OAuthErrServerError          = "server_error"
+)
+
+// OAuthClientStatus OAuth2客户端状态常量
+const (
+	OAuthClientStatusDisabled = 0
+	OAuthClientStatusActive   = 1
+)
+
+// OAuthClient OAuth2客户端模型
+// 客户端凭据模式签发的访问令牌以所属用户的身份调用服务，权限范围与API密钥的服务权限范围相同
+type OAuthClient struct {
+	ID           int        `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`
+	ClientID     string     `json:"client_id" db:"client_id"`
+	SecretHash   string     `json:"-" db:"secret_hash"`             // 客户端密钥的HMAC-SHA256
+	ClientSecret string     `json:"client_secret,omitempty" db:"-"` // 明文客户端密钥，仅在创建和重置时返回一次
+	Scopes       []string   `json:"scopes" db:"scopes"`             // 允许申请的权限范围，service:<服务名称> 或 service:*
+	Status       int        `json:"status" db:"status"`             // 0: disabled, 1: active
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastTokenAt  *time.Time `json:"last_token_at" db:"last_token_at"` // 最后一次获取访问令牌的时间
+}
+
+// IsActive 检查OAuth2客户端是否启用
+func (c *OAuthClient) IsActive() bool {
+	return c.Status == OAuthClientStatusActive
+}
+
+// HasScope 检查OAuth2客户端是否具有指定的权限范围
+func (c *OAuthClient) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsService 检查OAuth2客户端是否允许调用指定服务
+func (c *OAuthClient) AllowsService(serviceName string) bool {
+	return ScopesAllowService(c.Scopes, serviceName)
+}
+
+// AllowsScope 检查OAuth2客户端是否可以获得指定的权限范围
+// service:<服务名称> 同时接受 service:*，service:* 只有客户端本身具有时才允许
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	if scope == APIKeyScopeServiceAll {
+		return c.HasScope(scope)
+	}
+	if serviceName, ok := strings.CutPrefix(scope, APIKeyScopeServicePrefix); ok && serviceName != "" {
+		return c.AllowsService(serviceName)
+	}
+	return false
+}
+
+// CreateOAuthClientRequest 创建OAuth2客户端请求
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"max=50"` // 为空时允许申请所有服务的权限范围
+}
+
+// UpdateOAuthClientRequest 更新OAuth2客户端请求，未设置的字段保持不变
+type UpdateOAuthClientRequest struct {
+	ID     int      `json:"id" binding:"required"`
+	Name   string   `json:"name" binding:"omitempty,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"omitempty,max=50"`
+	Status *int     `json:"status" binding:"omitempty,oneof=0 1"`
+}
+
+// OAuthClientIDRequest 按ID操作OAuth2客户端的请求
+type OAuthClientIDRequest struct {
+	ID int `json:"id" binding:"required"`
+}
+
+// OAuthTokenRequest OAuth2令牌请求，application/x-www-form-urlencoded格式
+// 客户端凭据可以通过HTTP Basic认证或client_id、client_secret参数提供
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"` // 以空格分隔的权限范围，为空时授予客户端的全部权限范围
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// OAuthTokenResponse OAuth2令牌响应（RFC 6749 5.1）
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"` // 访问令牌过期时间(秒)
+	Scope       string `json:"scope"`      // 实际授予的权限范围，以空格分隔
+}
+
+// OAuthErrorResponse OAuth2令牌端点错误响应（RFC 6749 5.2）
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
@@ -27,8 +27,28 @@ type AccessLog struct {
 	Status      int       `json:"status" db:"status"`
 	Cost        int       `json:"cost" db:"cost"` // API调用计费单位
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	DurationMs   int64  `json:"duration_ms" db:"duration_ms"`     // 处理耗时（毫秒）
+	ClientIP     string `json:"client_ip" db:"client_ip"`         // 客户端IP
+	Method       string `json:"method" db:"method"`               // HTTP方法
+	UserAgent    string `json:"user_agent" db:"user_agent"`       // 客户端User-Agent
+	RequestSize  int64  `json:"request_size" db:"request_size"`   // 请求体字节数
+	ResponseSize int64  `json:"response_size" db:"response_size"` // 响应体字节数
+	RequestID    string `json:"request_id" db:"request_id"`       // 请求ID
+	AuthMethod   string `json:"auth_method" db:"auth_method"`     // 认证方式：jwt/apikey/signature/oauth/anonymous/replay
+	ErrorCode    int    `json:"error_code" db:"error_code"`       // 业务错误码，成功为0
 }
 
+// 认证方式常量
+const (
+	AuthMethodJWT       = "jwt"
+	AuthMethodAPIKey    = "apikey"
+	AuthMethodSignature = "signature" // 使用API密钥的签名密钥对请求签名
+	AuthMethodOAuth     = "oauth"     // OAuth2客户端凭据模式签发的访问令牌
+	AuthMethodAnonymous = "anonymous"
+	AuthMethodReplay    = "replay" // 管理员重放抓取的请求
+)
+
 // QuotaRequest 配额设置请求
 type QuotaRequest struct {
 	UserID      int    `json:"user_id" binding:"required"`
@@ -49,10 +69,10 @@ type QuotaResponse struct {
 
 // UsageStatsRequest 使用统计请求
 type UsageStatsRequest struct {
-	UserID      int    `json:"user_id"`
-	ServiceName string `json:"service_name"`
-	StartDate   string `json:"start_date"` // YYYY-MM-DD
-	EndDate     string `json:"end_date"`   // YYYY-MM-DD
+	UserID      int    `json:"user_id" form:"user_id" binding:"min=0"`
+	ServiceName string `json:"service_name" form:"service_name"`
+	StartDate   string `json:"start_date" form:"start_date" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD
+	EndDate     string `json:"end_date" form:"end_date" binding:"omitempty,datetime=2006-01-02"`     // YYYY-MM-DD
 }
 
 // UsageStatsResponse 使用统计响应
@@ -62,6 +82,9 @@ type UsageStatsResponse struct {
 	TotalUsage  int                `json:"total_usage"`
 	DailyUsage  map[string]int     `json:"daily_usage"` // 日期 -> 使用量
 	Details     []AccessLogSummary `json:"details"`
+
+	AvgDurationMs float64 `json:"avg_duration_ms"` // 统计区间内平均处理耗时
+	MaxDurationMs int64   `json:"max_duration_ms"` // 统计区间内最大处理耗时
 }
 
 // AccessLogSummary 访问日志摘要
@@ -71,6 +94,9 @@ type AccessLogSummary struct {
 	SuccessCalls int    `json:"success_calls"`
 	ErrorCalls   int    `json:"error_calls"`
 	TotalCost    int    `json:"total_cost"`
+
+	AvgDurationMs float64 `json:"avg_duration_ms"` // 当日平均处理耗时
+	MaxDurationMs int64   `json:"max_duration_ms"` // 当日最大处理耗时
 }
 
 // IsExceeded 检查配额是否超限
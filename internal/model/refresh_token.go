@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RefreshToken 刷新令牌模型
+// 令牌本身只在签发时返回给客户端，数据库中只保存哈希；
+// 每次刷新都会使用旧令牌并签发同一家族的新令牌
+type RefreshToken struct {
+	ID           int        `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	FamilyID     string     `json:"family_id" db:"family_id"` // 同一次登录轮换产生的令牌共用的家族ID，即会话ID
+	TokenHash    string     `json:"-" db:"token_hash"`
+	ParentID     int        `json:"parent_id" db:"parent_id"` // 轮换前的令牌ID，登录时签发的令牌为0
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason,omitempty" db:"revoke_reason"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
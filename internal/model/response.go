@@ -22,6 +22,15 @@ const (
 	CodeTokenInvalid       = 1009 // Token无效
 	CodeRateLimitExceeded  = 1010 // 请求频率超限
 	CodeQuotaExceeded      = 1011 // 配额超限
+	CodeServiceOverloaded  = 1012 // 服务过载
+	CodeIPNotAllowed       = 1013 // IP地址不允许访问
+	CodeTaskRunning        = 1014 // 任务正在执行
+
+	// API密钥使用限制，分别对应被拒绝的限制条件
+	// 1015已停用：API密钥允许的网段改由apikey作用范围的IP访问规则检查，返回CodeIPNotAllowed
+	CodeAPIKeyReferrerNotAllowed = 1016 // 请求来源不在API密钥允许的来源内
+	CodeAPIKeyOutsideTimeWindow  = 1017 // 当前时间不在API密钥允许的时间段内
+	CodeAPIKeyUsageExhausted     = 1018 // API密钥已达到最大使用次数
 )
 
 // 响应消息常量
@@ -38,8 +47,27 @@ const (
 	MsgTokenInvalid       = "Token无效"
 	MsgRateLimitExceeded  = "请求频率超限"
 	MsgQuotaExceeded      = "配额超限"
+	MsgServiceOverloaded  = "服务繁忙，请稍后再试"
+	MsgIPNotAllowed       = "当前IP地址不允许访问"
+	MsgTaskRunning        = "任务正在执行，请稍后再试"
+
+	MsgAPIKeyReferrerNotAllowed = "请求来源不在API密钥允许的来源内"
+	MsgAPIKeyOutsideTimeWindow  = "当前时间不在API密钥允许的使用时间段内"
+	MsgAPIKeyUsageExhausted     = "API密钥已达到最大使用次数"
 )
 
+// IsRejectedBeforeCall 检查服务调用的错误码是否表示请求在调用服务之前被拒绝
+// 包括权限范围检查（服务处理函数出错时统一返回CodeInvalidParams，不会返回CodeForbidden）、IP规则、限流和API密钥使用限制
+func IsRejectedBeforeCall(errorCode int) bool {
+	switch errorCode {
+	case CodeForbidden, CodeIPNotAllowed, CodeRateLimitExceeded,
+		CodeAPIKeyReferrerNotAllowed, CodeAPIKeyOutsideTimeWindow, CodeAPIKeyUsageExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
 // NewSuccessResponse 创建成功响应
 func NewSuccessResponse(data interface{}) *APIResponse {
 	return &APIResponse{
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// 数据保留清理的日志类型
+const (
+	RetentionTypeAccessLogs      = "access_logs"      // 访问日志明细
+	RetentionTypeUsageHourly     = "usage_hourly"     // 小时粒度使用量汇总
+	RetentionTypeUsageDaily      = "usage_daily"      // 天粒度使用量汇总
+	RetentionTypeCaptures        = "captures"         // 请求/响应抓取记录，按各自的过期时间清理
+	RetentionTypeRefreshTokens   = "refresh_tokens"   // 已过期的刷新令牌
+	RetentionTypeSessions        = "sessions"         // 已过期的登录会话
+	RetentionTypeSignatureNonces = "signature_nonces" // 已过期的签名请求随机数
+)
+
+// 清理任务触发方式
+const (
+	RetentionTriggerScheduled = "scheduled"
+	RetentionTriggerManual    = "manual"
+)
+
+// RetentionResult 单个日志类型的清理结果
+type RetentionResult struct {
+	Type        string    `json:"type"`
+	Cutoff      time.Time `json:"cutoff"`                 // 早于该时间的记录被清理
+	Deleted     int64     `json:"deleted"`                // 已删除条数
+	Archived    int64     `json:"archived"`               // 已归档条数
+	ArchiveFile string    `json:"archive_file,omitempty"` // 归档文件路径
+	Batches     int       `json:"batches"`                // 执行的批次数
+	Error       string    `json:"error,omitempty"`
+}
+
+// RetentionReport 一次清理任务的执行报告
+type RetentionReport struct {
+	Trigger    string            `json:"trigger"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	DurationMs int64             `json:"duration_ms"`
+	Results    []RetentionResult `json:"results"`
+}
+
+// RetentionPolicyStatus 日志类型的保留策略
+type RetentionPolicyStatus struct {
+	Type          string `json:"type"`
+	RetentionDays int    `json:"retention_days"` // 0表示不清理
+	Archive       bool   `json:"archive"`
+}
+
+// RetentionStatusResponse 清理任务状态响应
+type RetentionStatusResponse struct {
+	Enabled    bool                    `json:"enabled"`
+	Running    bool                    `json:"running"`
+	Interval   string                  `json:"interval"`
+	ArchiveDir string                  `json:"archive_dir"`
+	Policies   []RetentionPolicyStatus `json:"policies"`
+	NextRunAt  *time.Time              `json:"next_run_at,omitempty"`
+	LastReport *RetentionReport        `json:"last_report,omitempty"`
+}
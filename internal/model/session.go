@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// 会话撤销原因，同时用于撤销会话对应的刷新令牌
+const (
+	SessionRevokeLogout         = "logout"          // 用户登出
+	SessionRevokeUser           = "user_revoked"    // 用户在会话列表中撤销
+	SessionRevokeAdmin          = "admin_logout"    // 管理员强制下线
+	SessionRevokeReuse          = "reuse"           // 已使用的刷新令牌被再次使用
+	SessionRevokePasswordChange = "password_change" // 用户修改密码
+	SessionRevokePasswordReset  = "password_reset"  // 管理员重置密码
+	SessionRevokeUserDisabled   = "user_disabled"   // 用户已禁用
+)
+
+// Session 登录会话模型
+// ID即访问令牌中的jti，同一会话刷新后签发的访问令牌沿用该ID，同时作为刷新令牌的家族ID
+type Session struct {
+	ID           string     `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	Device       string     `json:"device" db:"device"` // 客户端登录时提供的设备名称
+	ClientIP     string     `json:"client_ip" db:"client_ip"`
+	UserAgent    string     `json:"user_agent" db:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"` // 与最新刷新令牌的过期时间一致
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason,omitempty" db:"revoke_reason"`
+}
+
+// Active 检查会话在指定时间是否有效
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionInfo 会话信息，标记是否为当前请求所在的会话
+type SessionInfo struct {
+	*Session
+	Current bool `json:"current"`
+}
+
+// RevokeSessionRequest 撤销会话请求
+type RevokeSessionRequest struct {
+	SessionID string `json:"session_id" binding:"required"`
+}
+
+// RevokeAllSessionsRequest 撤销全部会话请求
+type RevokeAllSessionsRequest struct {
+	KeepCurrent bool `json:"keep_current"` // 是否保留当前会话
+}
+
+// RevokeSessionsResponse 撤销会话响应
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"` // 撤销的会话数
+}
+
+// ForceLogoutRequest 管理员强制用户下线请求
+type ForceLogoutRequest struct {
+	UserID int `json:"user_id" binding:"required,min=1"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// 签名密钥状态常量
+const (
+	SigningKeyStatusPending = "pending" // 已发布公钥，尚未开始签名
+	SigningKeyStatusActive  = "active"  // 用于签发新令牌
+	SigningKeyStatusRetired = "retired" // 不再签发新令牌，仍可验证已签发的令牌
+)
+
+// SigningKey JWT签名密钥模型
+// 私钥加密保存，公钥通过JWKS接口发布，供其他服务验证APIHub签发的令牌
+type SigningKey struct {
+	ID          int       `json:"id" db:"id"`
+	KID         string    `json:"kid" db:"kid"`
+	Algorithm   string    `json:"algorithm" db:"algorithm"`
+	PrivateKey  string    `json:"-" db:"private_key"` // 加密后的私钥PEM
+	PublicKey   string    `json:"public_key" db:"public_key"`
+	ActivatesAt time.Time `json:"activates_at" db:"activates_at"` // 开始用于签名的时间
+	SignUntil   time.Time `json:"sign_until" db:"sign_until"`     // 停止签发新令牌的时间
+	VerifyUntil time.Time `json:"verify_until" db:"verify_until"` // 停止验证令牌并从JWKS中移除的时间
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Status 获取密钥在指定时间的状态
+func (k *SigningKey) Status(now time.Time) string {
+	switch {
+	case now.Before(k.ActivatesAt):
+		return SigningKeyStatusPending
+	case now.Before(k.SignUntil):
+		return SigningKeyStatusActive
+	default:
+		return SigningKeyStatusRetired
+	}
+}
+
+// SigningKeyInfo 签名密钥信息，包含当前状态
+type SigningKeyInfo struct {
+	*SigningKey
+	Status  string `json:"status"`
+	Current bool   `json:"current"` // 是否为当前用于签名的密钥
+}
+
+// JWK JSON Web Key，只包含RSA公钥参数
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 统计粒度常量
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityMonth = "month"
+)
+
+// 统计分组维度常量
+const (
+	UsageGroupByService = "service"
+	UsageGroupByAPIKey  = "api_key"
+	UsageGroupByStatus  = "status"
+	UsageGroupByUser    = "user"
+)
+
+// UsageGranularities 所有统计粒度，访问日志写入时同时累加
+var UsageGranularities = []string{GranularityHour, GranularityDay, GranularityMonth}
+
+// UsageBucket 计算时间所在的统计时间桶（UTC）
+func UsageBucket(t time.Time, granularity string) string {
+	t = t.UTC()
+	switch granularity {
+	case GranularityHour:
+		return t.Format("2006-01-02 15:00")
+	case GranularityMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// StatusClass 获取HTTP状态码分类，如200返回2xx
+func StatusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// UsageRollup 使用量汇总记录
+type UsageRollup struct {
+	Granularity     string `json:"granularity" db:"granularity"`
+	Bucket          string `json:"bucket" db:"bucket"`
+	UserID          int    `json:"user_id" db:"user_id"`
+	APIKeyID        int    `json:"api_key_id" db:"api_key_id"`
+	ServiceName     string `json:"service_name" db:"service_name"`
+	StatusClass     string `json:"status_class" db:"status_class"`
+	TotalCalls      int64  `json:"total_calls" db:"total_calls"`
+	TotalCost       int64  `json:"total_cost" db:"total_cost"`
+	TotalDurationMs int64  `json:"total_duration_ms" db:"total_duration_ms"`
+	MaxDurationMs   int64  `json:"max_duration_ms" db:"max_duration_ms"`
+}
+
+// UsageStatsFilter 使用量统计查询条件
+type UsageStatsFilter struct {
+	Granularity string
+	UserID      int // 0表示不过滤
+	APIKeyID    int // 0表示不过滤
+	ServiceName string
+	StartBucket string   // 包含
+	EndBucket   string   // 包含
+	GroupBy     []string // 除时间桶外的分组维度
+}
+
+// UsageStatsPoint 使用量统计数据点
+// 未参与分组的维度字段为零值
+type UsageStatsPoint struct {
+	Bucket        string  `json:"bucket"`
+	UserID        int     `json:"user_id,omitempty"`
+	APIKeyID      int     `json:"api_key_id,omitempty"`
+	ServiceName   string  `json:"service_name,omitempty"`
+	StatusClass   string  `json:"status_class,omitempty"`
+	TotalCalls    int64   `json:"total_calls"`
+	ErrorCalls    int64   `json:"error_calls"` // 4xx和5xx调用数
+	TotalCost     int64   `json:"total_cost"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	MaxDurationMs int64   `json:"max_duration_ms"`
+}
+
+// UsageStatsQueryRequest 使用量统计查询请求
+type UsageStatsQueryRequest struct {
+	Granularity string    `form:"granularity" binding:"omitempty,oneof=hour day month"`
+	StartTime   time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime     time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	UserID      int       `form:"user_id" binding:"min=0"`
+	APIKeyID    int       `form:"api_key_id" binding:"min=0"`
+	ServiceName string    `form:"service_name"`
+	GroupBy     []string  `form:"group_by"` // 可重复传参或逗号分隔
+}
+
+// ToFilter 转换为查询条件，未指定时间范围时按粒度使用默认范围
+func (r *UsageStatsQueryRequest) ToFilter(now time.Time) (UsageStatsFilter, error) {
+	granularity := r.Granularity
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+
+	endTime := r.EndTime
+	if endTime.IsZero() {
+		endTime = now
+	}
+
+	startTime := r.StartTime
+	if startTime.IsZero() {
+		switch granularity {
+		case GranularityHour:
+			startTime = endTime.Add(-24 * time.Hour)
+		case GranularityMonth:
+			startTime = endTime.AddDate(-1, 0, 0)
+		default:
+			startTime = endTime.AddDate(0, 0, -30)
+		}
+	}
+
+	if endTime.Before(startTime) {
+		return UsageStatsFilter{}, errors.New("结束时间不能早于开始时间")
+	}
+
+	var groupBy []string
+	seen := make(map[string]bool)
+	for _, value := range r.GroupBy {
+		for _, dimension := range strings.Split(value, ",") {
+			dimension = strings.TrimSpace(dimension)
+			if dimension == "" || seen[dimension] {
+				continue
+			}
+			switch dimension {
+			case UsageGroupByService, UsageGroupByAPIKey, UsageGroupByStatus, UsageGroupByUser:
+			default:
+				return UsageStatsFilter{}, errors.New("不支持的分组维度: " + dimension)
+			}
+			seen[dimension] = true
+			groupBy = append(groupBy, dimension)
+		}
+	}
+
+	return UsageStatsFilter{
+		Granularity: granularity,
+		UserID:      r.UserID,
+		APIKeyID:    r.APIKeyID,
+		ServiceName: r.ServiceName,
+		StartBucket: UsageBucket(startTime, granularity),
+		EndBucket:   UsageBucket(endTime, granularity),
+		GroupBy:     groupBy,
+	}, nil
+}
+
+// UsageStatsQueryResponse 使用量统计查询响应
+type UsageStatsQueryResponse struct {
+	Granularity string            `json:"granularity"`
+	StartBucket string            `json:"start_bucket"`
+	EndBucket   string            `json:"end_bucket"`
+	GroupBy     []string          `json:"group_by"`
+	Points      []UsageStatsPoint `json:"points"`
+	Total       UsageStatsPoint   `json:"total"` // 整个时间范围的汇总，bucket为空
+}
@@ -3,6 +3,7 @@ package registry
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 
 	"apihub/internal/model"
@@ -70,8 +71,13 @@ func (r *ServiceRegistry) RegisterService(name string, handler ServiceHandler, c
 		}
 	}
 	// 如果服务已存在于数据库，则使用数据库中的配置，不更新数据库
-	// 打印服务定义
-	fmt.Println("服务定义:", definition)
+	slog.Info("注册服务",
+		"service", definition.ServiceName,
+		"status", definition.Status,
+		"allow_anonymous", definition.AllowAnonymous,
+		"rate_limit", definition.RateLimit,
+		"quota_cost", definition.QuotaCost,
+	)
 
 	// 注册服务到内存
 	r.services[name] = &ServiceInfo{
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"apihub/internal/accesslog"
+	"apihub/internal/logger"
+	"apihub/internal/middleware"
+	"apihub/internal/model"
+	"apihub/internal/provider/registry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// replayContextKey 重放信息在请求context中的键
+type replayContextKey struct{}
+
+// replayTarget 重放请求对应的原始调用
+type replayTarget struct {
+	exchange *model.CapturedExchange
+	dryRun   bool
+}
+
+// getReplayTarget 获取当前请求对应的重放信息，非重放请求返回false
+func getReplayTarget(c *gin.Context) (*replayTarget, bool) {
+	target, ok := c.Request.Context().Value(replayContextKey{}).(*replayTarget)
+	return target, ok
+}
+
+// ReplayResponse 重放得到的响应
+type ReplayResponse struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	Duration time.Duration
+}
+
+// Replayer 在当前版本的服务上重放抓取的请求
+// 重放请求只经过日志中间件和服务处理函数，不经过认证、IP规则、限流和抓取中间件；
+// 非试运行时按原调用方记录访问日志并消耗其配额，试运行时两者都不做
+type Replayer struct {
+	router *ProviderRouter
+	engine *gin.Engine
+}
+
+// NewReplayer 创建请求重放器
+func NewReplayer(registry *registry.ServiceRegistry, logWriter *accesslog.Writer) *Replayer {
+	p := &Replayer{
+		router: &ProviderRouter{
+			registry:  registry,
+			logWriter: logWriter,
+		},
+	}
+
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.Any("/*path", p.prepareMiddleware(), p.logMiddleware(), p.router.executeServiceHandler)
+	p.engine = engine
+
+	return p
+}
+
+// Replay 重放一条抓取记录
+// 服务不存在或已禁用时与线上请求一样返回404或403响应
+func (p *Replayer) Replay(ctx context.Context, exchange *model.CapturedExchange, request *model.ReplayRequest, dryRun bool) (*ReplayResponse, error) {
+	target := exchange.Endpoint
+	if request.Query != "" {
+		target += "?" + request.Query
+	}
+
+	ctx = context.WithValue(ctx, replayContextKey{}, &replayTarget{exchange: exchange, dryRun: dryRun})
+	req, err := http.NewRequestWithContext(ctx, request.Method, target, bytes.NewReader(request.Body))
+	if err != nil {
+		return nil, fmt.Errorf("构造重放请求失败: %w", err)
+	}
+	if request.Headers != nil {
+		req.Header = request.Headers.Clone()
+	}
+
+	recorder := httptest.NewRecorder()
+	start := time.Now()
+	p.engine.ServeHTTP(recorder, req)
+
+	return &ReplayResponse{
+		Status:   recorder.Code,
+		Header:   recorder.Header(),
+		Body:     recorder.Body.Bytes(),
+		Duration: time.Since(start),
+	}, nil
+}
+
+// prepareMiddleware 按抓取记录设置服务信息和请求ID
+func (p *Replayer) prepareMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		target, _ := getReplayTarget(c)
+		serviceName := target.exchange.ServiceName
+
+		service, exists := p.router.registry.GetService(serviceName)
+		if !exists {
+			c.JSON(http.StatusNotFound, model.NewErrorResponse(
+				model.CodeNotFound,
+				"服务不存在",
+			))
+			c.Abort()
+			return
+		}
+		if !service.Definition.IsEnabled() {
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(
+				model.CodeForbidden,
+				"服务已禁用",
+			))
+			c.Abort()
+			return
+		}
+
+		c.Params = append(c.Params, gin.Param{Key: "service", Value: serviceName})
+		c.Set("service_info", service)
+
+		// 沿用发起重放的管理请求的请求ID，便于关联访问日志
+		c.Set(middleware.RequestIDKey, logger.RequestIDFromContext(c.Request.Context()))
+
+		c.Next()
+	}
+}
+
+// logMiddleware 非试运行时记录访问日志
+func (p *Replayer) logMiddleware() gin.HandlerFunc {
+	logMiddleware := p.router.logMiddleware()
+
+	return func(c *gin.Context) {
+		if target, _ := getReplayTarget(c); target.dryRun {
+			c.Next()
+			return
+		}
+		logMiddleware(c)
+	}
+}
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxErrorBodySize 为提取错误码而缓存的响应体最大字节数
+const maxErrorBodySize = 4096
+
+// errorCodeWriter 记录错误响应体的ResponseWriter
+// 仅在状态码表示失败时缓存响应体前若干字节，用于提取APIResponse中的业务错误码
+type errorCodeWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写入响应体，失败响应同时写入缓存
+func (w *errorCodeWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应体，失败响应同时写入缓存
+func (w *errorCodeWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture 缓存失败响应的响应体
+func (w *errorCodeWriter) capture(data []byte) {
+	if w.Status() < http.StatusBadRequest {
+		return
+	}
+	if remaining := maxErrorBodySize - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
+
+// ErrorCode 从缓存的响应体中提取业务错误码
+// 无法解析时返回0
+func (w *errorCodeWriter) ErrorCode() int {
+	if w.body.Len() == 0 {
+		return 0
+	}
+
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err != nil {
+		return 0
+	}
+	return resp.Code
+}
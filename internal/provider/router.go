@@ -1,43 +1,52 @@
 package provider
 
 import (
-	"context"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"apihub/internal/accesslog"
 	"apihub/internal/auth"
 	"apihub/internal/auth/apikey"
 	"apihub/internal/auth/jwt"
+	"apihub/internal/capture"
+	"apihub/internal/metrics"
 	"apihub/internal/middleware"
 	"apihub/internal/model"
 	"apihub/internal/provider/registry"
 	"apihub/internal/store"
+	"apihub/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
+// accessLoggedKey 日志中间件已接管访问日志记录时在上下文中的键
+const accessLoggedKey = "access_logged"
+
 // ProviderRouter 功能API路由器
 type ProviderRouter struct {
 	registry     *registry.ServiceRegistry
 	authServices *auth.AuthServices
 	store        store.Store
 	rateLimiter  *middleware.RateLimiter
+	admission    *middleware.AdmissionController
+	ipFilter     *middleware.IPFilter
+	logWriter    *accesslog.Writer
+	recorder     *capture.Recorder
 }
 
 // NewProviderRouter 创建功能API路由器
-func NewProviderRouter(registry *registry.ServiceRegistry, authServices *auth.AuthServices, store store.Store) *ProviderRouter {
-	// 创建限流器，默认限制为60次/分钟
-	rateLimiter := middleware.NewRateLimiter(60)
-
-	// 启动定期清理任务，每小时清理一次，清理超过6小时未访问的限流器
-	rateLimiter.StartCleanupTask(1*time.Hour, 6*time.Hour)
-
+// rateLimiter 由上层创建并与Dashboard限流管理接口共享
+func NewProviderRouter(registry *registry.ServiceRegistry, authServices *auth.AuthServices, store store.Store, rateLimiter *middleware.RateLimiter, admission *middleware.AdmissionController, ipFilter *middleware.IPFilter, logWriter *accesslog.Writer, recorder *capture.Recorder) *ProviderRouter {
 	return &ProviderRouter{
 		registry:     registry,
 		authServices: authServices,
 		store:        store,
 		rateLimiter:  rateLimiter,
+		admission:    admission,
+		ipFilter:     ipFilter,
+		logWriter:    logWriter,
+		recorder:     recorder,
 	}
 }
 
@@ -55,17 +64,30 @@ func (r *ProviderRouter) RegisterRoutes(router *gin.RouterGroup) {
 	apiGroup.GET("/:service/info", r.serviceInfoHandler)
 
 	// 服务执行端点（带认证）
+	// 各中间件单独创建span，便于定位耗时所在环节
 	authenticatedGroup := apiGroup.Group("/:service/execute")
-	authenticatedGroup.Use(r.serviceAuthMiddleware())                            // 先进行服务验证和用户认证
-	authenticatedGroup.Use(middleware.ServiceRateLimitMiddleware(r.rateLimiter)) // 然后进行限流控制
-	authenticatedGroup.Use(r.logMiddleware())                                    // 最后记录日志
+	authenticatedGroup.Use(r.metricsMiddleware())                                                                           // 统计请求数和耗时，包括被拒绝的请求
+	authenticatedGroup.Use(tracing.Middleware("admission", r.admission.Middleware())...)                                    // 先进行全局准入控制
+	authenticatedGroup.Use(tracing.Middleware("auth", r.serviceAuthMiddleware())...)                                        // 再进行服务验证和用户认证
+	authenticatedGroup.Use(r.logMiddleware())                                                                               // 记录日志，包括被权限范围、IP规则和限流拒绝的请求
+	authenticatedGroup.Use(r.recorder.Middleware())                                                                         // 按抓取规则保存请求和响应内容
+	authenticatedGroup.Use(tracing.Middleware("scope", r.apiKeyScopeMiddleware())...)                                       // 检查API密钥或访问令牌是否允许调用该服务
+	authenticatedGroup.Use(tracing.Middleware("ip_filter", r.ipFilter.Middleware())...)                                     // 然后检查IP访问规则
+	authenticatedGroup.Use(tracing.Middleware("rate_limit", middleware.ServiceRateLimitMiddleware(r.rateLimiter))...)       // 然后进行限流控制
+	authenticatedGroup.Use(tracing.Middleware("api_key_use", apikey.ConsumeUseMiddleware(r.authServices.APIKeyService))...) // 最后消耗API密钥使用次数
 	authenticatedGroup.POST("", r.executeServiceHandler)
 
 	// 公开API端点（可选认证）
 	publicGroup := apiGroup.Group("/:service/public")
-	publicGroup.Use(r.optionalAuthMiddleware())                           // 先进行服务验证和可选用户认证
-	publicGroup.Use(middleware.ServiceRateLimitMiddleware(r.rateLimiter)) // 然后进行限流控制
-	publicGroup.Use(r.logMiddleware())                                    // 最后记录日志
+	publicGroup.Use(r.metricsMiddleware())                                                                           // 统计请求数和耗时，包括被拒绝的请求
+	publicGroup.Use(tracing.Middleware("admission", r.admission.Middleware())...)                                    // 先进行全局准入控制，过载时优先拒绝匿名请求
+	publicGroup.Use(tracing.Middleware("auth", r.optionalAuthMiddleware())...)                                       // 再进行服务验证和可选用户认证
+	publicGroup.Use(r.logMiddleware())                                                                               // 记录日志，包括被权限范围、IP规则和限流拒绝的请求
+	publicGroup.Use(r.recorder.Middleware())                                                                         // 按抓取规则保存请求和响应内容
+	publicGroup.Use(tracing.Middleware("scope", r.apiKeyScopeMiddleware())...)                                       // 检查API密钥或访问令牌是否允许调用该服务
+	publicGroup.Use(tracing.Middleware("ip_filter", r.ipFilter.Middleware())...)                                     // 然后检查IP访问规则
+	publicGroup.Use(tracing.Middleware("rate_limit", middleware.ServiceRateLimitMiddleware(r.rateLimiter))...)       // 然后进行限流控制
+	publicGroup.Use(tracing.Middleware("api_key_use", apikey.ConsumeUseMiddleware(r.authServices.APIKeyService))...) // 最后消耗API密钥使用次数
 	publicGroup.POST("", r.executePublicServiceHandler)
 }
 
@@ -75,6 +97,8 @@ func (r *ProviderRouter) statusHandler(c *gin.Context) {
 		"status":        "ok",
 		"service_count": r.registry.ServiceCount(),
 		"service_names": r.registry.GetServiceNames(),
+		"admission":     r.admission.Stats(),
+		"access_log":    r.logWriter.Stats(),
 		"timestamp":     time.Now().Unix(),
 	}))
 }
@@ -156,16 +180,67 @@ func (r *ProviderRouter) serviceAuthMiddleware() gin.HandlerFunc {
 		c.Set("service_info", service)
 
 		// 检查是否允许匿名访问
+		start := time.Now()
 		if !service.Definition.AllowAnonymous {
 			// 使用现有的认证中间件
 			middleware.AuthMiddleware(r.authServices.JWTService, r.authServices.APIKeyService)(c)
-			if c.IsAborted() {
-				return
-			}
 		} else {
 			// 允许匿名访问，但也可以选择性地提供认证
 			middleware.OptionalAuthMiddleware(r.authServices.JWTService, r.authServices.APIKeyService)(c)
 		}
+		r.logRestrictionDenied(c, service, start)
+	}
+}
+
+// logRestrictionDenied 记录在认证阶段被API密钥使用限制拒绝的请求
+// 认证中间件拒绝请求时日志中间件尚未执行，使用限制的拒绝需要单独记录，其他认证失败不记录；
+// 认证之后被拒绝的请求已由日志中间件记录
+func (r *ProviderRouter) logRestrictionDenied(c *gin.Context, service *registry.ServiceInfo, start time.Time) {
+	restrictionErr, ok := apikey.GetRestrictionError(c)
+	if !ok || c.GetBool(accessLoggedKey) {
+		return
+	}
+	r.enqueueAccessLog(c, service, restrictionErr.Code, time.Since(start))
+}
+
+// apiKeyScopeMiddleware API密钥权限范围检查中间件
+// 认证中间件在认证成功后直接调用c.Next()，因此权限范围在认证之后单独检查；
+// 使用API密钥或OAuth2客户端访问令牌认证时，必须具有 service:<服务名称> 或 service:* 权限范围，JWT认证和匿名访问不受影响
+func (r *ProviderRouter) apiKeyScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceName := c.Param("service")
+
+		if clientClaims, ok := jwt.GetClientClaims(c); ok {
+			if !clientClaims.AllowsService(serviceName) {
+				slog.InfoContext(c.Request.Context(), "OAuth2客户端无权调用服务", "client_id", clientClaims.ClientID, "service", serviceName)
+				c.JSON(http.StatusForbidden, model.NewErrorResponse(
+					model.CodeForbidden,
+					"访问令牌无权调用该服务",
+				))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		apiKey, ok := apikey.GetAPIKey(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if !apiKey.AllowsService(serviceName) {
+			slog.InfoContext(c.Request.Context(), "API密钥无权调用服务", "api_key_id", apiKey.ID, "service", serviceName)
+			c.JSON(http.StatusForbidden, model.NewErrorResponse(
+				model.CodeForbidden,
+				"API密钥无权调用该服务",
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
 	}
 }
 
@@ -200,101 +275,119 @@ func (r *ProviderRouter) optionalAuthMiddleware() gin.HandlerFunc {
 		c.Set("service_info", service)
 
 		// 使用现有的可选认证中间件，它会自动调用c.Next()
+		start := time.Now()
 		middleware.OptionalAuthMiddleware(r.authServices.JWTService, r.authServices.APIKeyService)(c)
+		r.logRestrictionDenied(c, service, start)
 
 		// 不需要再次调用c.Next()，因为OptionalAuthMiddleware已经调用过了
 	}
 }
 
+// metricsMiddleware 指标统计中间件
+// 未注册的服务统一记为unknown，避免任意路径参数产生大量指标序列
+func (r *ProviderRouter) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		serviceName := c.Param("service")
+		if _, exists := r.registry.GetService(serviceName); !exists {
+			serviceName = "unknown"
+		}
+		metrics.ObserveRequest(serviceName, c.Writer.Status(), time.Since(start))
+	}
+}
+
 // logMiddleware 日志中间件
 func (r *ProviderRouter) logMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 在请求开始时获取服务信息
 		serviceInfo, exists := c.Get("service_info")
 		if !exists {
-			fmt.Printf("日志中间件：未找到服务信息\n")
+			slog.WarnContext(c.Request.Context(), "日志中间件未找到服务信息", "path", c.Request.URL.Path)
 			c.Next()
 			return
 		}
 		service := serviceInfo.(*registry.ServiceInfo)
 
+		// 包装ResponseWriter以便提取错误码
+		writer := &errorCodeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Set(accessLoggedKey, true)
+		start := time.Now()
+
 		// 处理请求
 		c.Next()
 
-		// 获取用户ID和APIKey ID
-		var userID int
-		var apiKeyID int
-
-		// 使用middleware包中的函数获取用户ID
-		userIDFromAuth, exists := middleware.GetCurrentUserID(c)
-		if exists {
-			userID = userIDFromAuth
-		} else {
-			// 尝试从JWT获取用户ID（兼容旧代码）
-			userIDFromJWT, exists := jwt.GetUserID(c)
-			if exists {
-				userID = userIDFromJWT
-			}
-		}
+		r.enqueueAccessLog(c, service, writer.ErrorCode(), time.Since(start))
+	}
+}
 
-		// 尝试从APIKey获取用户ID和APIKey ID
-		apiKey, exists := apikey.GetAPIKey(c)
+// enqueueAccessLog 生成访问日志并放入写入队列
+func (r *ProviderRouter) enqueueAccessLog(c *gin.Context, service *registry.ServiceInfo, errorCode int, duration time.Duration) {
+	// 获取用户ID和APIKey ID
+	var userID int
+	var apiKeyID int
+
+	// 使用middleware包中的函数获取用户ID
+	userIDFromAuth, exists := middleware.GetCurrentUserID(c)
+	if exists {
+		userID = userIDFromAuth
+	} else {
+		// 尝试从JWT获取用户ID（兼容旧代码）
+		userIDFromJWT, exists := jwt.GetUserID(c)
 		if exists {
-			apiKeyID = apiKey.ID
-			if userID == 0 {
-				userID = apiKey.UserID
-			}
+			userID = userIDFromJWT
 		}
+	}
 
-		// 创建访问日志
-		accessLog := &model.AccessLog{
-			APIKeyID:    apiKeyID, // 即使为0也允许，不强制外键约束
-			UserID:      userID,   // 即使为0也允许，不强制外键约束
-			ServiceName: service.Definition.ServiceName,
-			Endpoint:    c.Request.URL.Path,
-			Status:      c.Writer.Status(),
-			Cost:        service.Definition.QuotaCost,
-			CreatedAt:   time.Now(),
+	// 尝试从APIKey获取用户ID和APIKey ID
+	apiKey, exists := apikey.GetAPIKey(c)
+	if exists {
+		apiKeyID = apiKey.ID
+		if userID == 0 {
+			userID = apiKey.UserID
 		}
+	}
 
-		// 异步保存访问日志
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+	// 被使用限制、权限范围、IP规则或限流拒绝的请求没有实际调用服务，不计入配额
+	cost := service.Definition.QuotaCost
+	if model.IsRejectedBeforeCall(errorCode) {
+		cost = 0
+	}
 
-			if err := r.store.AccessLogs().Create(ctx, accessLog); err != nil {
-				fmt.Printf("保存访问日志失败: %v\n", err)
-			} else {
-				fmt.Printf("成功记录访问日志: 用户ID=%d, 服务=%s, 状态=%d\n",
-					userID, service.Definition.ServiceName, c.Writer.Status())
-			}
+	// 创建访问日志
+	accessLog := &model.AccessLog{
+		APIKeyID:    apiKeyID, // 即使为0也允许，不强制外键约束
+		UserID:      userID,   // 即使为0也允许，不强制外键约束
+		ServiceName: service.Definition.ServiceName,
+		Endpoint:    c.Request.URL.Path,
+		Status:      c.Writer.Status(),
+		Cost:        cost,
+		CreatedAt:   time.Now(),
+
+		DurationMs:   duration.Milliseconds(),
+		ClientIP:     c.ClientIP(),
+		Method:       c.Request.Method,
+		UserAgent:    c.Request.UserAgent(),
+		RequestSize:  max(c.Request.ContentLength, 0),
+		ResponseSize: int64(max(c.Writer.Size(), 0)),
+		RequestID:    middleware.GetRequestID(c),
+		AuthMethod:   middleware.GetAuthMethod(c),
+		ErrorCode:    errorCode,
+	}
 
-			// 如果有用户ID和配额成本，增加使用量
-			if userID > 0 && service.Definition.QuotaCost > 0 {
-				// 检查配额
-				quota, err := r.store.Quotas().GetByUserAndService(ctx, userID, service.Definition.ServiceName, "daily")
-				if err != nil {
-					// 配额不存在，创建默认配额
-					quota = &model.ServiceQuota{
-						UserID:      userID,
-						ServiceName: service.Definition.ServiceName,
-						TimeWindow:  "daily",
-						Usage:       0,
-						LimitValue:  service.Definition.DefaultLimit,
-						ResetTime:   time.Now().Add(24 * time.Hour),
-					}
-					if err := r.store.Quotas().Create(ctx, quota); err != nil {
-						fmt.Printf("创建配额失败: %v\n", err)
-					}
-				}
-
-				// 增加使用量
-				if err := r.store.Quotas().IncrementUsage(ctx, userID, service.Definition.ServiceName, "daily", service.Definition.QuotaCost); err != nil {
-					fmt.Printf("增加使用量失败: %v\n", err)
-				}
-			}
-		}()
+	// 重放请求记为原调用方的调用，认证方式标记为replay以便区分
+	if target, ok := getReplayTarget(c); ok {
+		accessLog.UserID = target.exchange.UserID
+		accessLog.APIKeyID = target.exchange.APIKeyID
+		accessLog.AuthMethod = model.AuthMethodReplay
 	}
+
+	// 放入访问日志写入队列，由后台批量写入并累加配额使用量
+	// 队列已满时丢弃，丢弃数量计入写入器统计信息
+	r.logWriter.Enqueue(accesslog.Entry{Log: accessLog, DefaultLimit: service.Definition.DefaultLimit})
 }
 
 // executeServiceHandler 执行服务处理函数
@@ -312,7 +405,7 @@ func (r *ProviderRouter) executeServiceHandler(c *gin.Context) {
 	service := serviceInfo.(*registry.ServiceInfo)
 
 	// 执行服务处理函数
-	result, err := service.Handler(c)
+	result, err := r.callService(c, service)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -325,6 +418,20 @@ func (r *ProviderRouter) executeServiceHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
 }
 
+// callService 调用服务处理函数，并为其创建span
+func (r *ProviderRouter) callService(c *gin.Context, service *registry.ServiceInfo) (interface{}, error) {
+	parent := c.Request.Context()
+	ctx, span := tracing.Start(parent, "service."+service.Definition.ServiceName)
+	c.Request = c.Request.WithContext(ctx)
+	defer func() {
+		c.Request = c.Request.WithContext(parent)
+	}()
+
+	result, err := service.Handler(c)
+	tracing.End(span, err)
+	return result, err
+}
+
 // executePublicServiceHandler 执行公开服务处理函数
 func (r *ProviderRouter) executePublicServiceHandler(c *gin.Context) {
 	// 获取服务信息
@@ -340,7 +447,7 @@ func (r *ProviderRouter) executePublicServiceHandler(c *gin.Context) {
 	service := serviceInfo.(*registry.ServiceInfo)
 
 	// 执行服务处理函数
-	result, err := service.Handler(c)
+	result, err := r.callService(c, service)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
 			model.CodeInvalidParams,
@@ -0,0 +1,75 @@
+package retention
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"apihub/internal/model"
+)
+
+// archiveWriter gzip压缩的NDJSON归档文件写入器
+type archiveWriter struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+// newArchiveWriter 创建归档文件，文件名为 <类型>-<时间>.ndjson.gz
+func newArchiveWriter(dir, retentionType string, now time.Time) (*archiveWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.ndjson.gz", retentionType, now.Format("20060102T150405")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建归档文件失败: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	return &archiveWriter{
+		path: path,
+		file: file,
+		gz:   gz,
+		enc:  json.NewEncoder(gz),
+	}, nil
+}
+
+// Path 归档文件路径
+func (w *archiveWriter) Path() string {
+	return w.path
+}
+
+// WriteBatch 写入一批访问日志并落盘
+// 返回后即可安全删除这些记录
+func (w *archiveWriter) WriteBatch(logs []*model.AccessLog) error {
+	for _, accessLog := range logs {
+		if err := w.enc.Encode(accessLog); err != nil {
+			return fmt.Errorf("写入归档文件失败: %w", err)
+		}
+	}
+	if err := w.gz.Flush(); err != nil {
+		return fmt.Errorf("写入归档文件失败: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("同步归档文件失败: %w", err)
+	}
+	return nil
+}
+
+// Close 写入gzip尾部并关闭文件
+func (w *archiveWriter) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("关闭归档文件失败: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("关闭归档文件失败: %w", err)
+	}
+	return nil
+}
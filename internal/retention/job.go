@@ -0,0 +1,364 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// ErrRunning 清理任务正在执行
+var ErrRunning = errors.New("数据清理任务正在执行")
+
+// Config 数据保留清理配置
+type Config struct {
+	Enabled      bool          // 是否定期执行，手动触发不受影响
+	Interval     time.Duration // 定期执行间隔
+	InitialDelay time.Duration // 启动后首次执行的延迟
+	BatchSize    int           // 单批次最多处理的行数
+	BatchPause   time.Duration // 批次之间的暂停时间，避免长时间占用写锁
+
+	AccessLogDays     int    // 访问日志保留天数，0表示不清理
+	ArchiveAccessLogs bool   // 删除前是否将访问日志归档为gzip压缩的NDJSON文件
+	ArchiveDir        string // 归档目录
+	UsageHourlyDays   int    // 小时粒度使用量汇总保留天数，0表示不清理
+	UsageDailyDays    int    // 天粒度使用量汇总保留天数，0表示不清理
+}
+
+// Job 数据保留清理任务
+// 按各日志类型的保留策略分批删除过期数据，同一时间只允许一个任务执行
+type Job struct {
+	store  store.Store
+	config Config
+
+	running atomic.Bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	started bool
+
+	mu         sync.Mutex
+	lastReport *model.RetentionReport
+	nextRunAt  time.Time
+}
+
+// NewJob 创建数据保留清理任务
+func NewJob(store store.Store, config Config) *Job {
+	if config.Interval <= 0 {
+		config.Interval = 24 * time.Hour
+	}
+	if config.InitialDelay <= 0 {
+		config.InitialDelay = time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if config.ArchiveDir == "" {
+		config.ArchiveDir = "archive"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Job{
+		store:  store,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start 启动定期执行，未启用时不做任何事
+func (j *Job) Start() {
+	if !j.config.Enabled {
+		return
+	}
+	j.started = true
+
+	go func() {
+		defer close(j.done)
+
+		timer := time.NewTimer(j.config.InitialDelay)
+		defer timer.Stop()
+		j.setNextRunAt(time.Now().Add(j.config.InitialDelay))
+
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-timer.C:
+				if _, err := j.Run(j.ctx, model.RetentionTriggerScheduled); err != nil && !errors.Is(err, ErrRunning) {
+					slog.Error("定期数据清理失败", "error", err)
+				}
+				timer.Reset(j.config.Interval)
+				j.setNextRunAt(time.Now().Add(j.config.Interval))
+			}
+		}
+	}()
+}
+
+// Close 停止定期执行并中断正在执行的清理
+// 已删除的批次不会回滚，未处理的数据留待下次清理
+func (j *Job) Close(ctx context.Context) error {
+	j.cancel()
+	if !j.started {
+		return nil
+	}
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待数据清理任务停止超时: %w", ctx.Err())
+	}
+}
+
+// RunAsync 在后台执行一次清理，已有任务执行时返回ErrRunning
+func (j *Job) RunAsync(trigger string) error {
+	if !j.running.CompareAndSwap(false, true) {
+		return ErrRunning
+	}
+
+	go func() {
+		defer j.running.Store(false)
+		j.run(j.ctx, trigger)
+	}()
+	return nil
+}
+
+// Run 执行一次清理并返回执行报告，已有任务执行时返回ErrRunning
+// 单个日志类型清理失败不影响其他类型，错误记录在报告中
+func (j *Job) Run(ctx context.Context, trigger string) (*model.RetentionReport, error) {
+	if !j.running.CompareAndSwap(false, true) {
+		return nil, ErrRunning
+	}
+	defer j.running.Store(false)
+
+	return j.run(ctx, trigger), nil
+}
+
+// Status 获取清理任务状态
+func (j *Job) Status() model.RetentionStatusResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := model.RetentionStatusResponse{
+		Enabled:    j.config.Enabled,
+		Running:    j.running.Load(),
+		Interval:   j.config.Interval.String(),
+		ArchiveDir: j.config.ArchiveDir,
+		Policies: []model.RetentionPolicyStatus{
+			{Type: model.RetentionTypeAccessLogs, RetentionDays: j.config.AccessLogDays, Archive: j.config.ArchiveAccessLogs},
+			{Type: model.RetentionTypeUsageHourly, RetentionDays: j.config.UsageHourlyDays},
+			{Type: model.RetentionTypeUsageDaily, RetentionDays: j.config.UsageDailyDays},
+		},
+		LastReport: j.lastReport,
+	}
+	if j.config.Enabled && !j.nextRunAt.IsZero() {
+		nextRunAt := j.nextRunAt
+		status.NextRunAt = &nextRunAt
+	}
+	return status
+}
+
+// setNextRunAt 记录下次定期执行时间
+func (j *Job) setNextRunAt(t time.Time) {
+	j.mu.Lock()
+	j.nextRunAt = t
+	j.mu.Unlock()
+}
+
+// run 依次清理各日志类型
+func (j *Job) run(ctx context.Context, trigger string) *model.RetentionReport {
+	now := time.Now()
+	report := &model.RetentionReport{
+		Trigger:   trigger,
+		StartedAt: now,
+		Results:   make([]model.RetentionResult, 0, 7),
+	}
+
+	if j.config.AccessLogDays > 0 {
+		report.Results = append(report.Results, j.cleanAccessLogs(ctx, now.AddDate(0, 0, -j.config.AccessLogDays)))
+	}
+	if j.config.UsageHourlyDays > 0 {
+		report.Results = append(report.Results, j.cleanUsageRollups(ctx,
+			model.RetentionTypeUsageHourly, model.GranularityHour, now.AddDate(0, 0, -j.config.UsageHourlyDays)))
+	}
+	if j.config.UsageDailyDays > 0 {
+		report.Results = append(report.Results, j.cleanUsageRollups(ctx,
+			model.RetentionTypeUsageDaily, model.GranularityDay, now.AddDate(0, 0, -j.config.UsageDailyDays)))
+	}
+	// 抓取记录的保留时间由抓取规则决定，刷新令牌、会话和签名随机数的有效期由认证配置决定，始终清理已过期的记录
+	report.Results = append(report.Results,
+		j.cleanExpired(ctx, model.RetentionTypeCaptures, now, j.store.Captures().DeleteExpired),
+		j.cleanExpired(ctx, model.RetentionTypeRefreshTokens, now, j.store.RefreshTokens().DeleteExpired),
+		j.cleanExpired(ctx, model.RetentionTypeSessions, now, j.store.Sessions().DeleteExpired),
+		j.cleanExpired(ctx, model.RetentionTypeSignatureNonces, now, j.store.SignatureNonces().DeleteExpired),
+	)
+
+	report.FinishedAt = time.Now()
+	report.DurationMs = report.FinishedAt.Sub(report.StartedAt).Milliseconds()
+
+	for _, result := range report.Results {
+		attrs := []any{
+			"trigger", trigger,
+			"type", result.Type,
+			"cutoff", result.Cutoff,
+			"deleted", result.Deleted,
+			"archived", result.Archived,
+			"batches", result.Batches,
+		}
+		if result.Error != "" {
+			slog.Error("数据清理失败", append(attrs, "error", result.Error)...)
+		} else {
+			slog.Info("数据清理完成", attrs...)
+		}
+	}
+
+	j.mu.Lock()
+	j.lastReport = report
+	j.mu.Unlock()
+
+	return report
+}
+
+// cleanAccessLogs 分批清理访问日志，启用归档时每批先写入归档文件再删除
+// 使用命名返回值，以便关闭归档文件的错误能写入结果
+func (j *Job) cleanAccessLogs(ctx context.Context, cutoff time.Time) (result model.RetentionResult) {
+	result = model.RetentionResult{Type: model.RetentionTypeAccessLogs, Cutoff: cutoff}
+
+	var archive *archiveWriter
+	defer func() {
+		if archive != nil {
+			if err := archive.Close(); err != nil && result.Error == "" {
+				result.Error = err.Error()
+			}
+		}
+	}()
+
+	for {
+		maxID := 0
+		batchLen := 0
+
+		if j.config.ArchiveAccessLogs {
+			logs, err := j.store.AccessLogs().ListBefore(ctx, cutoff, j.config.BatchSize)
+			if err != nil {
+				result.Error = err.Error()
+				return result
+			}
+			if len(logs) == 0 {
+				return result
+			}
+
+			if archive == nil {
+				archive, err = newArchiveWriter(j.config.ArchiveDir, model.RetentionTypeAccessLogs, time.Now())
+				if err != nil {
+					result.Error = err.Error()
+					return result
+				}
+				result.ArchiveFile = archive.Path()
+			}
+
+			// 归档写入并落盘后才删除，归档失败时保留数据
+			if err := archive.WriteBatch(logs); err != nil {
+				result.Error = err.Error()
+				return result
+			}
+			result.Archived += int64(len(logs))
+			maxID = logs[len(logs)-1].ID
+			batchLen = len(logs)
+		}
+
+		deleted, err := j.store.AccessLogs().DeleteBefore(ctx, cutoff, maxID, j.config.BatchSize)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Deleted += deleted
+		result.Batches++
+
+		if !j.config.ArchiveAccessLogs {
+			batchLen = int(deleted)
+		}
+		if batchLen < j.config.BatchSize {
+			return result
+		}
+
+		if err := j.pause(ctx); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+}
+
+// cleanUsageRollups 分批清理指定粒度的使用量汇总
+func (j *Job) cleanUsageRollups(ctx context.Context, retentionType, granularity string, cutoff time.Time) model.RetentionResult {
+	result := model.RetentionResult{Type: retentionType, Cutoff: cutoff}
+	bucket := model.UsageBucket(cutoff, granularity)
+
+	for {
+		deleted, err := j.store.UsageRollups().DeleteBefore(ctx, granularity, bucket, j.config.BatchSize)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Deleted += deleted
+		result.Batches++
+
+		if deleted < int64(j.config.BatchSize) {
+			return result
+		}
+
+		if err := j.pause(ctx); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+}
+
+// cleanExpired 分批清理已过期的记录，deleteExpired每次最多删除limit条
+func (j *Job) cleanExpired(ctx context.Context, retentionType string, now time.Time,
+	deleteExpired func(ctx context.Context, now time.Time, limit int) (int64, error)) model.RetentionResult {
+	result := model.RetentionResult{Type: retentionType, Cutoff: now}
+
+	for {
+		deleted, err := deleteExpired(ctx, now, j.config.BatchSize)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Deleted += deleted
+		result.Batches++
+
+		if deleted < int64(j.config.BatchSize) {
+			return result
+		}
+
+		if err := j.pause(ctx); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+}
+
+// pause 批次之间暂停，让出数据库写锁
+func (j *Job) pause(ctx context.Context) error {
+	if j.config.BatchPause <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(j.config.BatchPause)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
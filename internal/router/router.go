@@ -1,12 +1,23 @@
 package router
 
 import (
+	"context"
+	"log/slog"
+	"time"
+
+	"apihub/internal/accesslog"
 	"apihub/internal/auth"
+	"apihub/internal/capture"
 	dashboardRouter "apihub/internal/dashboard/router"
+	"apihub/internal/live"
+	"apihub/internal/metrics"
+	"apihub/internal/middleware"
 	"apihub/internal/model"
 	"apihub/internal/provider"
 	"apihub/internal/provider/registry"
+	"apihub/internal/retention"
 	"apihub/internal/store"
+	"apihub/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -38,30 +49,152 @@ import (
 // @name X-API-Key
 // @description API Key 认证
 
+// RouterConfig 路由配置
+type RouterConfig struct {
+	// 全局准入控制配置
+	Admission middleware.AdmissionConfig
+
+	// 访问日志写入配置
+	AccessLog accesslog.WriterConfig
+
+	// 受信任的反向代理地址或CIDR，为空时不信任任何代理
+	TrustedProxies []string
+	// 用于获取客户端真实IP的请求头
+	RemoteIPHeaders []string
+
+	// Prometheus指标接口配置
+	Metrics metrics.Config
+
+	// 数据保留清理配置
+	Retention retention.Config
+
+	// 请求/响应抓取配置
+	Capture capture.Config
+}
+
 // Router 主路由管理器
 type Router struct {
 	store        store.Store
 	authServices *auth.AuthServices
 	registry     *registry.ServiceRegistry
+	rateLimiter  *middleware.RateLimiter
+	admission    *middleware.AdmissionController
+	ipFilter     *middleware.IPFilter
+	logWriter    *accesslog.Writer
+	retentionJob *retention.Job
+	liveHub      *live.Hub
+	recorder     *capture.Recorder
+	replayer     *provider.Replayer
+	config       RouterConfig
 }
 
 // NewRouter 创建主路由管理器实例
-func NewRouter(store store.Store, authServices *auth.AuthServices, registry *registry.ServiceRegistry) *Router {
+func NewRouter(store store.Store, authServices *auth.AuthServices, registry *registry.ServiceRegistry, config RouterConfig) *Router {
+	// 创建限流器，默认限制为60次/分钟
+	rateLimiter := middleware.NewRateLimiter(60)
+
+	// 启动定期清理任务，每小时清理一次，清理超过6小时未访问的限流器
+	rateLimiter.StartCleanupTask(1*time.Hour, 6*time.Hour)
+
+	// 创建IP过滤器并加载规则，之后每分钟同步一次
+	ipFilter := middleware.NewIPFilter(store)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := ipFilter.Reload(ctx); err != nil {
+		slog.Error("加载IP规则失败", "error", err)
+	}
+	cancel()
+	ipFilter.StartReloadTask(1 * time.Minute)
+
+	// 创建访问日志写入器，准入控制根据其积压情况判断是否过载
+	// 实时访问流从访问日志写入器获取数据
+	logWriter := accesslog.NewWriter(store, config.AccessLog)
+	liveHub := live.NewHub()
+	logWriter.AddListener(liveHub.Publish)
+	logWriter.Start()
+
+	admission := middleware.NewAdmissionController(config.Admission)
+	admission.SetQueueDepthFunc(logWriter.QueueDepth)
+
+	// 创建请求/响应抓取记录器并加载规则，之后每分钟同步一次
+	recorder := capture.NewRecorder(store, authServices.CryptoService, config.Capture)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	if err := recorder.Reload(ctx); err != nil {
+		slog.Error("加载抓取规则失败", "error", err)
+	}
+	cancel()
+	recorder.Start()
+	recorder.StartReloadTask(1 * time.Minute)
+
+	// 创建请求重放器，非试运行的重放与线上请求共用访问日志写入器
+	replayer := provider.NewReplayer(registry, logWriter)
+
+	registerMetrics(registry, admission, logWriter, liveHub, recorder)
+
+	// 创建数据保留清理任务，按配置定期清理过期的访问日志和使用量汇总
+	retentionJob := retention.NewJob(store, config.Retention)
+	retentionJob.Start()
+
 	return &Router{
 		store:        store,
 		authServices: authServices,
 		registry:     registry,
+		rateLimiter:  rateLimiter,
+		admission:    admission,
+		ipFilter:     ipFilter,
+		logWriter:    logWriter,
+		retentionJob: retentionJob,
+		liveHub:      liveHub,
+		recorder:     recorder,
+		replayer:     replayer,
+		config:       config,
+	}
+}
+
+// CloseStreams 结束所有实时访问流连接
+// 应注册为HTTP服务器的关闭回调，避免长连接阻塞优雅退出
+func (r *Router) CloseStreams() {
+	r.liveHub.Close()
+}
+
+// Shutdown 释放路由持有的后台资源
+// 应在HTTP服务器停止接收请求后调用，中断正在执行的清理任务并将剩余访问日志写入数据库
+func (r *Router) Shutdown(ctx context.Context) error {
+	if err := r.retentionJob.Close(ctx); err != nil {
+		slog.ErrorContext(ctx, "停止数据清理任务失败", "error", err)
+	}
+	if err := r.recorder.Close(ctx); err != nil {
+		slog.ErrorContext(ctx, "停止抓取记录器失败", "error", err)
 	}
+	return r.logWriter.Close(ctx)
 }
 
 // SetupRoutes 设置所有路由
 func (r *Router) SetupRoutes() *gin.Engine {
-	// 创建Gin引擎
-	engine := gin.Default()
+	// 创建Gin引擎，使用结构化日志替代gin默认的日志和恢复中间件
+	engine := gin.New()
+
+	// 配置受信任代理，只有来自受信任代理的请求才会解析转发头，防止伪造客户端IP
+	if err := engine.SetTrustedProxies(r.config.TrustedProxies); err != nil {
+		slog.Warn("受信任代理配置无效，将不信任任何代理", "error", err)
+		_ = engine.SetTrustedProxies(nil)
+	}
+	if len(r.config.RemoteIPHeaders) > 0 {
+		engine.RemoteIPHeaders = r.config.RemoteIPHeaders
+	}
 
 	// 添加全局中间件
+	// 追踪中间件最先执行，从请求头提取上游追踪上下文并创建请求根span
+	engine.Use(tracing.GinMiddleware("apihub"))
+	engine.Use(middleware.RequestIDMiddleware())
+	engine.Use(middleware.RequestLogMiddleware())
+	engine.Use(middleware.RecoveryMiddleware())
 	engine.Use(corsMiddleware())
 
+	// 指标接口，配置了独立监听地址时由独立服务提供
+	if r.config.Metrics.Enabled && r.config.Metrics.Listen == "" {
+		engine.GET("/metrics", gin.WrapH(metrics.Handler(r.config.Metrics.Token)))
+	}
+
 	// Swagger文档路由
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -72,17 +205,57 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		v1.GET("/health", healthCheck)
 
 		// 创建并注册Dashboard路由
-		dashboard := dashboardRouter.NewRouter(r.store, r.authServices)
+		dashboard := dashboardRouter.NewRouter(r.store, r.authServices, r.rateLimiter, r.ipFilter, r.retentionJob, r.liveHub, r.recorder, r.replayer)
 		dashboard.SetupSubRoutes(v1)
 
+		// JWKS公钥路由和OAuth2令牌端点位于根路径，供其他服务验证APIHub签发的令牌及客户端获取访问令牌
+		dashboard.RegisterWellKnownRoutes(engine)
+
 		// 注册Provider路由
-		providerRouter := provider.NewProviderRouter(r.registry, r.authServices, r.store)
+		providerRouter := provider.NewProviderRouter(r.registry, r.authServices, r.store, r.rateLimiter, r.admission, r.ipFilter, r.logWriter, r.recorder)
 		providerRouter.RegisterRoutes(v1)
 	}
 
 	return engine
 }
 
+// registerMetrics 注册在采集时读取的运行状态指标
+func registerMetrics(registry *registry.ServiceRegistry, admission *middleware.AdmissionController, logWriter *accesslog.Writer, liveHub *live.Hub, recorder *capture.Recorder) {
+	metrics.RegisterGaugeFunc("registry_services", "已注册的功能API服务数", func() float64 {
+		return float64(registry.ServiceCount())
+	})
+	metrics.RegisterGaugeFunc("admission_in_flight", "正在处理的功能API请求数", func() float64 {
+		return float64(admission.Stats().InFlight)
+	})
+	metrics.RegisterGaugeFunc("live_subscribers", "实时访问流连接数", func() float64 {
+		return float64(liveHub.SubscriberCount())
+	})
+	metrics.RegisterGaugeFunc("accesslog_queue_depth", "尚未写入完成的访问日志数", func() float64 {
+		return float64(logWriter.QueueDepth())
+	})
+	metrics.RegisterGaugeFunc("accesslog_queue_capacity", "访问日志写入队列容量", func() float64 {
+		return float64(logWriter.Stats().QueueCapacity)
+	})
+	metrics.RegisterCounterFunc("accesslog_written_total", "已写入的访问日志数", func() float64 {
+		return float64(logWriter.Stats().Written)
+	})
+	metrics.RegisterCounterFunc("accesslog_dropped_total", "因队列满或已关闭被丢弃的访问日志数", func() float64 {
+		return float64(logWriter.Stats().Dropped)
+	})
+	metrics.RegisterCounterFunc("accesslog_failed_total", "写入失败的访问日志数", func() float64 {
+		return float64(logWriter.Stats().Failed)
+	})
+	metrics.RegisterCounterFunc("capture_written_total", "已写入的请求/响应抓取记录数", func() float64 {
+		return float64(recorder.Stats().Captured)
+	})
+	metrics.RegisterCounterFunc("capture_dropped_total", "因队列满或已关闭被丢弃的抓取记录数", func() float64 {
+		return float64(recorder.Stats().Dropped)
+	})
+	metrics.RegisterCounterFunc("capture_failed_total", "脱敏、加密或写入失败的抓取记录数", func() float64 {
+		return float64(recorder.Stats().Failed)
+	})
+}
+
 // @Summary      健康检查接口
 // @Description  返回服务健康状态
 // @Tags         系统
@@ -103,8 +276,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-Request-ID, X-Apihub-Access-Key, X-Apihub-Timestamp, X-Apihub-Nonce, X-Apihub-Signature")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -3,24 +3,45 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"apihub/internal/model"
 	"apihub/internal/store"
 )
 
+// accessLogColumns 访问日志查询列
+const accessLogColumns = `id, api_key_id, user_id, service_name, endpoint, status, cost, created_at,
+	duration_ms, client_ip, method, user_agent, request_size, response_size, request_id, auth_method, error_code`
+
 // AccessLogRepository 访问日志仓库SQLite实现
 type AccessLogRepository struct {
 	db DBExecutor
 }
 
+// scanAccessLog 扫描一行访问日志
+func scanAccessLog(row interface{ Scan(dest ...any) error }) (*model.AccessLog, error) {
+	accessLog := &model.AccessLog{}
+	err := row.Scan(
+		&accessLog.ID, &accessLog.APIKeyID, &accessLog.UserID, &accessLog.ServiceName,
+		&accessLog.Endpoint, &accessLog.Status, &accessLog.Cost, &accessLog.CreatedAt,
+		&accessLog.DurationMs, &accessLog.ClientIP, &accessLog.Method, &accessLog.UserAgent,
+		&accessLog.RequestSize, &accessLog.ResponseSize, &accessLog.RequestID,
+		&accessLog.AuthMethod, &accessLog.ErrorCode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accessLog, nil
+}
+
 // Create 创建访问日志
 func (r *AccessLogRepository) Create(ctx context.Context, accessLog *model.AccessLog) error {
 	query := `
-		INSERT INTO access_logs (api_key_id, user_id, service_name, endpoint, status, cost, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO access_logs (api_key_id, user_id, service_name, endpoint, status, cost, created_at,
+			duration_ms, client_ip, method, user_agent, request_size, response_size, request_id, auth_method, error_code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	accessLog.CreatedAt = time.Now()
@@ -36,10 +57,18 @@ func (r *AccessLogRepository) Create(ctx context.Context, accessLog *model.Acces
 	result, err := r.db.ExecContext(ctx, query,
 		accessLog.APIKeyID, accessLog.UserID, accessLog.ServiceName, accessLog.Endpoint,
 		accessLog.Status, accessLog.Cost, accessLog.CreatedAt,
+		accessLog.DurationMs, accessLog.ClientIP, accessLog.Method, accessLog.UserAgent,
+		accessLog.RequestSize, accessLog.ResponseSize, accessLog.RequestID,
+		accessLog.AuthMethod, accessLog.ErrorCode,
 	)
 	if err != nil {
-		fmt.Printf("SQL错误: %v, 参数: [%d, %d, %s, %s, %d, %d]\n",
-			err, accessLog.APIKeyID, accessLog.UserID, accessLog.ServiceName, accessLog.Endpoint, accessLog.Status, accessLog.Cost)
+		slog.ErrorContext(ctx, "写入访问日志失败",
+			"error", err,
+			"api_key_id", accessLog.APIKeyID,
+			"user_id", accessLog.UserID,
+			"service", accessLog.ServiceName,
+			"endpoint", accessLog.Endpoint,
+		)
 		return &store.DBError{
 			Code:    store.ErrDataConstraint,
 			Message: "failed to create access log",
@@ -60,18 +89,60 @@ func (r *AccessLogRepository) Create(ctx context.Context, accessLog *model.Acces
 	return nil
 }
 
+// accessLogBatchRows 单条INSERT语句写入的最大行数
+// 每行16个参数，控制在SQLite默认参数数量上限(999)以内
+const accessLogBatchRows = 60
+
+// CreateBatch 批量创建访问日志
+// 使用多行INSERT，按accessLogBatchRows分片写入
+func (r *AccessLogRepository) CreateBatch(ctx context.Context, logs []*model.AccessLog) error {
+	for start := 0; start < len(logs); start += accessLogBatchRows {
+		end := min(start+accessLogBatchRows, len(logs))
+		chunk := logs[start:end]
+
+		var query strings.Builder
+		query.WriteString(`INSERT INTO access_logs (api_key_id, user_id, service_name, endpoint, status, cost, created_at,
+			duration_ms, client_ip, method, user_agent, request_size, response_size, request_id, auth_method, error_code) VALUES `)
+
+		args := make([]interface{}, 0, len(chunk)*16)
+		for i, accessLog := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+
+			if accessLog.CreatedAt.IsZero() {
+				accessLog.CreatedAt = time.Now()
+			}
+			args = append(args,
+				max(accessLog.APIKeyID, 0), max(accessLog.UserID, 0), accessLog.ServiceName, accessLog.Endpoint,
+				accessLog.Status, accessLog.Cost, accessLog.CreatedAt,
+				accessLog.DurationMs, accessLog.ClientIP, accessLog.Method, accessLog.UserAgent,
+				accessLog.RequestSize, accessLog.ResponseSize, accessLog.RequestID,
+				accessLog.AuthMethod, accessLog.ErrorCode,
+			)
+		}
+
+		if _, err := r.db.ExecContext(ctx, query.String(), args...); err != nil {
+			return &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to create access logs in batch",
+				Err:     err,
+			}
+		}
+	}
+
+	return nil
+}
+
 // GetByID 根据ID获取访问日志
 func (r *AccessLogRepository) GetByID(ctx context.Context, id int) (*model.AccessLog, error) {
 	query := `
-		SELECT id, api_key_id, user_id, service_name, endpoint, status, cost, created_at
+		SELECT ` + accessLogColumns + `
 		FROM access_logs WHERE id = ?
 	`
 
-	accessLog := &model.AccessLog{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&accessLog.ID, &accessLog.APIKeyID, &accessLog.UserID, &accessLog.ServiceName,
-		&accessLog.Endpoint, &accessLog.Status, &accessLog.Cost, &accessLog.CreatedAt,
-	)
+	accessLog, err := scanAccessLog(r.db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -93,7 +164,7 @@ func (r *AccessLogRepository) GetByID(ctx context.Context, id int) (*model.Acces
 // GetByUserID 根据用户ID获取访问日志
 func (r *AccessLogRepository) GetByUserID(ctx context.Context, userID int, offset, limit int) ([]*model.AccessLog, error) {
 	query := `
-		SELECT id, api_key_id, user_id, service_name, endpoint, status, cost, created_at
+		SELECT ` + accessLogColumns + `
 		FROM access_logs 
 		WHERE user_id = ?
 		ORDER BY created_at DESC
@@ -110,17 +181,13 @@ func (r *AccessLogRepository) GetByUserID(ctx context.Context, userID int, offse
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭访问日志查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭访问日志查询时出错", "error", closeErr)
 		}
 	}()
 
 	var logs []*model.AccessLog
 	for rows.Next() {
-		accessLog := &model.AccessLog{}
-		err := rows.Scan(
-			&accessLog.ID, &accessLog.APIKeyID, &accessLog.UserID, &accessLog.ServiceName,
-			&accessLog.Endpoint, &accessLog.Status, &accessLog.Cost, &accessLog.CreatedAt,
-		)
+		accessLog, err := scanAccessLog(rows)
 		if err != nil {
 			return nil, &store.DBError{
 				Code:    store.ErrDataConstraint,
@@ -145,7 +212,7 @@ func (r *AccessLogRepository) GetByUserID(ctx context.Context, userID int, offse
 // GetByAPIKeyID 根据API密钥ID获取访问日志
 func (r *AccessLogRepository) GetByAPIKeyID(ctx context.Context, apiKeyID int, offset, limit int) ([]*model.AccessLog, error) {
 	query := `
-		SELECT id, api_key_id, user_id, service_name, endpoint, status, cost, created_at
+		SELECT ` + accessLogColumns + `
 		FROM access_logs 
 		WHERE api_key_id = ?
 		ORDER BY created_at DESC
@@ -162,17 +229,13 @@ func (r *AccessLogRepository) GetByAPIKeyID(ctx context.Context, apiKeyID int, o
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭API密钥访问日志查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭API密钥访问日志查询时出错", "error", closeErr)
 		}
 	}()
 
 	var logs []*model.AccessLog
 	for rows.Next() {
-		accessLog := &model.AccessLog{}
-		err := rows.Scan(
-			&accessLog.ID, &accessLog.APIKeyID, &accessLog.UserID, &accessLog.ServiceName,
-			&accessLog.Endpoint, &accessLog.Status, &accessLog.Cost, &accessLog.CreatedAt,
-		)
+		accessLog, err := scanAccessLog(rows)
 		if err != nil {
 			return nil, &store.DBError{
 				Code:    store.ErrDataConstraint,
@@ -195,27 +258,30 @@ func (r *AccessLogRepository) GetByAPIKeyID(ctx context.Context, apiKeyID int, o
 }
 
 // GetUsageStats 获取使用统计
+// 从按天汇总的usage_rollups表读取，不扫描原始访问日志
 func (r *AccessLogRepository) GetUsageStats(ctx context.Context, userID int, serviceName, startDate, endDate string) (*model.UsageStatsResponse, error) {
 	// 构建基础查询
 	baseQuery := `
 		SELECT 
-			DATE(created_at) as date,
-			COUNT(*) as total_calls,
-			SUM(CASE WHEN status >= 200 AND status < 300 THEN 1 ELSE 0 END) as success_calls,
-			SUM(CASE WHEN status >= 400 THEN 1 ELSE 0 END) as error_calls,
-			SUM(cost) as total_cost
-		FROM access_logs 
-		WHERE user_id = ? AND created_at >= ? AND created_at <= ?
+			bucket as date,
+			SUM(total_calls) as total_calls,
+			SUM(CASE WHEN status_class = '2xx' THEN total_calls ELSE 0 END) as success_calls,
+			SUM(CASE WHEN status_class IN ('4xx', '5xx') THEN total_calls ELSE 0 END) as error_calls,
+			SUM(total_cost) as total_cost,
+			SUM(total_duration_ms) as total_duration_ms,
+			MAX(max_duration_ms) as max_duration_ms
+		FROM usage_rollups 
+		WHERE granularity = ? AND user_id = ? AND bucket >= ? AND bucket <= ?
 	`
 
-	args := []interface{}{userID, startDate + " 00:00:00", endDate + " 23:59:59"}
+	args := []interface{}{model.GranularityDay, userID, startDate, endDate}
 
 	if serviceName != "" {
 		baseQuery += " AND service_name = ?"
 		args = append(args, serviceName)
 	}
 
-	baseQuery += " GROUP BY DATE(created_at) ORDER BY date"
+	baseQuery += " GROUP BY bucket ORDER BY bucket"
 
 	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
@@ -227,7 +293,7 @@ func (r *AccessLogRepository) GetUsageStats(ctx context.Context, userID int, ser
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭使用统计查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭使用统计查询时出错", "error", closeErr)
 		}
 	}()
 
@@ -239,11 +305,14 @@ func (r *AccessLogRepository) GetUsageStats(ctx context.Context, userID int, ser
 	}
 
 	totalUsage := 0
+	var totalDurationMs int64
 	for rows.Next() {
 		var summary model.AccessLogSummary
+		var dayDurationMs int64
 		err := rows.Scan(
 			&summary.Date, &summary.TotalCalls, &summary.SuccessCalls,
 			&summary.ErrorCalls, &summary.TotalCost,
+			&dayDurationMs, &summary.MaxDurationMs,
 		)
 		if err != nil {
 			return nil, &store.DBError{
@@ -253,9 +322,15 @@ func (r *AccessLogRepository) GetUsageStats(ctx context.Context, userID int, ser
 			}
 		}
 
+		if summary.TotalCalls > 0 {
+			summary.AvgDurationMs = float64(dayDurationMs) / float64(summary.TotalCalls)
+		}
+
 		stats.DailyUsage[summary.Date] = summary.TotalCalls
 		stats.Details = append(stats.Details, summary)
 		totalUsage += summary.TotalCalls
+		totalDurationMs += dayDurationMs
+		stats.MaxDurationMs = max(stats.MaxDurationMs, summary.MaxDurationMs)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -267,13 +342,16 @@ func (r *AccessLogRepository) GetUsageStats(ctx context.Context, userID int, ser
 	}
 
 	stats.TotalUsage = totalUsage
+	if totalUsage > 0 {
+		stats.AvgDurationMs = float64(totalDurationMs) / float64(totalUsage)
+	}
 	return stats, nil
 }
 
 // List 获取访问日志列表
 func (r *AccessLogRepository) List(ctx context.Context, offset, limit int) ([]*model.AccessLog, error) {
 	query := `
-		SELECT id, api_key_id, user_id, service_name, endpoint, status, cost, created_at
+		SELECT ` + accessLogColumns + `
 		FROM access_logs 
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -289,17 +367,13 @@ func (r *AccessLogRepository) List(ctx context.Context, offset, limit int) ([]*m
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭访问日志列表查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭访问日志列表查询时出错", "error", closeErr)
 		}
 	}()
 
 	var logs []*model.AccessLog
 	for rows.Next() {
-		accessLog := &model.AccessLog{}
-		err := rows.Scan(
-			&accessLog.ID, &accessLog.APIKeyID, &accessLog.UserID, &accessLog.ServiceName,
-			&accessLog.Endpoint, &accessLog.Status, &accessLog.Cost, &accessLog.CreatedAt,
-		)
+		accessLog, err := scanAccessLog(rows)
 		if err != nil {
 			return nil, &store.DBError{
 				Code:    store.ErrDataConstraint,
@@ -343,6 +417,273 @@ func (r *AccessLogRepository) DeleteOldLogs(ctx context.Context, beforeDate stri
 		}
 	}
 
-	fmt.Printf("Deleted %d old access logs\n", rowsAffected)
+	slog.InfoContext(ctx, "已清理过期访问日志", "deleted", rowsAffected)
+	return nil
+}
+
+// ListBefore 按ID升序获取早于指定时间的访问日志
+func (r *AccessLogRepository) ListBefore(ctx context.Context, before time.Time, limit int) ([]*model.AccessLog, error) {
+	query := `SELECT ` + accessLogColumns + ` FROM access_logs WHERE created_at < ? ORDER BY id ASC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, before.In(time.Local), limit)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list old access logs",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭过期访问日志查询时出错", "error", closeErr)
+		}
+	}()
+
+	logs := make([]*model.AccessLog, 0, limit)
+	for rows.Next() {
+		accessLog, err := scanAccessLog(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan access log",
+				Err:     err,
+			}
+		}
+		logs = append(logs, accessLog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate old access logs",
+			Err:     err,
+		}
+	}
+
+	return logs, nil
+}
+
+// DeleteBefore 分批删除早于指定时间的访问日志
+// SQLite默认不支持DELETE ... LIMIT，通过子查询限制单次删除的行数
+func (r *AccessLogRepository) DeleteBefore(ctx context.Context, before time.Time, maxID, limit int) (int64, error) {
+	query := `DELETE FROM access_logs WHERE id IN (
+		SELECT id FROM access_logs WHERE created_at < ? AND (? <= 0 OR id <= ?) ORDER BY id LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, before.In(time.Local), maxID, maxID, limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete old access logs",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
+
+// CallStatsByAPIKey 统计API密钥自since起的调用次数、最后调用时间和最多maxClientIPs个客户端IP
+func (r *AccessLogRepository) CallStatsByAPIKey(ctx context.Context, apiKeyID int, since time.Time, maxClientIPs int) (*model.APIKeyCallStats, error) {
+	stats := &model.APIKeyCallStats{APIKeyID: apiKeyID, ClientIPs: make([]string, 0)}
+	sinceLocal := since.In(time.Local)
+
+	query := `SELECT COUNT(*) FROM access_logs WHERE api_key_id = ? AND created_at >= ?`
+	if err := r.db.QueryRowContext(ctx, query, apiKeyID, sinceLocal).Scan(&stats.Calls); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to count API key calls",
+			Err:     err,
+		}
+	}
+	if stats.Calls == 0 {
+		return stats, nil
+	}
+
+	// 聚合函数的结果没有列类型，无法直接扫描为时间，单独查询最后一条日志
+	query = `SELECT created_at FROM access_logs WHERE api_key_id = ? AND created_at >= ? ORDER BY id DESC LIMIT 1`
+	var lastCalledAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, apiKeyID, sinceLocal).Scan(&lastCalledAt); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get last API key call",
+			Err:     err,
+		}
+	}
+	stats.LastCalledAt = &lastCalledAt
+
+	query = `SELECT DISTINCT client_ip FROM access_logs WHERE api_key_id = ? AND created_at >= ? AND client_ip != '' LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, apiKeyID, sinceLocal, maxClientIPs)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list API key client IPs",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭客户端IP查询时出错", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var clientIP string
+		if err := rows.Scan(&clientIP); err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan client IP",
+				Err:     err,
+			}
+		}
+		stats.ClientIPs = append(stats.ClientIPs, clientIP)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate client IPs",
+			Err:     err,
+		}
+	}
+
+	return stats, nil
+}
+
+// buildAccessLogFilterQuery 根据查询条件构建SQL
+func buildAccessLogFilterQuery(filter model.AccessLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.UserID > 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.APIKeyID > 0 {
+		conditions = append(conditions, "api_key_id = ?")
+		args = append(args, filter.APIKeyID)
+	}
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = ?")
+		args = append(args, filter.ServiceName)
+	}
+	if filter.Endpoint != "" {
+		conditions = append(conditions, `endpoint LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(filter.Endpoint)+"%")
+	}
+	if low, high, ok := statusClassRange(filter.StatusClass); ok {
+		conditions = append(conditions, "status >= ? AND status < ?")
+		args = append(args, low, high)
+	}
+	// created_at以本地时区写入，比较前统一转换
+	if !filter.StartTime.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.StartTime.In(time.Local))
+	}
+	if !filter.EndTime.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.EndTime.In(time.Local))
+	}
+	if filter.Cursor > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.Cursor)
+	}
+
+	query := "SELECT " + accessLogColumns + " FROM access_logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	return query, args
+}
+
+// statusClassRange 将状态码分类转换为区间 [low, high)
+func statusClassRange(class string) (int, int, bool) {
+	switch class {
+	case model.StatusClass2xx:
+		return 200, 300, true
+	case model.StatusClass3xx:
+		return 300, 400, true
+	case model.StatusClass4xx:
+		return 400, 500, true
+	case model.StatusClass5xx:
+		return 500, 600, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// Query 按条件查询访问日志
+func (r *AccessLogRepository) Query(ctx context.Context, filter model.AccessLogFilter) ([]*model.AccessLog, error) {
+	logs := make([]*model.AccessLog, 0)
+	err := r.Iterate(ctx, filter, func(accessLog *model.AccessLog) error {
+		logs = append(logs, accessLog)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Iterate 按条件逐条遍历访问日志
+func (r *AccessLogRepository) Iterate(ctx context.Context, filter model.AccessLogFilter, fn func(*model.AccessLog) error) error {
+	query, args := buildAccessLogFilterQuery(filter)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to query access logs",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭访问日志查询时出错", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		accessLog, err := scanAccessLog(rows)
+		if err != nil {
+			return &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan access log",
+				Err:     err,
+			}
+		}
+		if err := fn(accessLog); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate access logs",
+			Err:     err,
+		}
+	}
+
 	return nil
 }
@@ -3,12 +3,18 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"log/slog"
 	"time"
 
 	"apihub/internal/model"
 	"apihub/internal/store"
 )
 
+// apiKeyColumns API密钥查询列
+const apiKeyColumns = `id, user_id, key_name, key_prefix, key_hash, legacy_key, scopes, restrictions, use_count, status, created_at, expires_at,
+	successor_id, predecessor_id, rotated_at, rotation_notified_at, last_used_at, last_used_ip, signing_secret`
+
 // APIKeyRepository API密钥仓库SQLite实现
 type APIKeyRepository struct {
 	db DBExecutor
@@ -17,15 +23,15 @@ type APIKeyRepository struct {
 // Create 创建API密钥
 func (r *APIKeyRepository) Create(ctx context.Context, apiKey *model.APIKey) error {
 	query := `
-		INSERT INTO api_keys (user_id, key_name, api_key, status, created_at, expires_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO api_keys (user_id, key_name, key_prefix, key_hash, scopes, restrictions, status, created_at, expires_at, predecessor_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	apiKey.CreatedAt = time.Now()
 
 	result, err := r.db.ExecContext(ctx, query,
-		apiKey.UserID, apiKey.KeyName, apiKey.APIKey, apiKey.Status,
-		apiKey.CreatedAt, apiKey.ExpiresAt,
+		apiKey.UserID, apiKey.KeyName, apiKey.KeyPrefix, apiKey.KeyHash, encodeStringList(apiKey.Scopes),
+		encodeRestrictions(apiKey.Restrictions), apiKey.Status, apiKey.CreatedAt, apiKey.ExpiresAt, apiKey.PredecessorID,
 	)
 	if err != nil {
 		if isUniqueConstraintError(err) {
@@ -57,17 +63,9 @@ func (r *APIKeyRepository) Create(ctx context.Context, apiKey *model.APIKey) err
 
 // GetByID 根据ID获取API密钥
 func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
-	query := `
-		SELECT id, user_id, key_name, api_key, status, created_at, expires_at
-		FROM api_keys WHERE id = ?
-	`
-
-	apiKey := &model.APIKey{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&apiKey.ID, &apiKey.UserID, &apiKey.KeyName, &apiKey.APIKey,
-		&apiKey.Status, &apiKey.CreatedAt, &apiKey.ExpiresAt,
-	)
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
 
+	apiKey, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &store.DBError{
@@ -85,98 +83,38 @@ func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey,
 	return apiKey, nil
 }
 
-// GetByKey 根据密钥获取API密钥
-func (r *APIKeyRepository) GetByKey(ctx context.Context, key string) (*model.APIKey, error) {
-	query := `
-		SELECT id, user_id, key_name, api_key, status, created_at, expires_at
-		FROM api_keys WHERE api_key = ?
-	`
-
-	apiKey := &model.APIKey{}
-	var expiresAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, key).Scan(
-		&apiKey.ID, &apiKey.UserID, &apiKey.KeyName, &apiKey.APIKey,
-		&apiKey.Status, &apiKey.CreatedAt, &expiresAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, &store.DBError{
-				Code:    store.ErrNotFound,
-				Message: "API密钥未找到",
-			}
-		}
-		return nil, &store.DBError{
-			Code:    store.ErrDataConstraint,
-			Message: "获取API密钥失败",
-			Err:     err,
-		}
-	}
-
-	if expiresAt.Valid {
-		apiKey.ExpiresAt = &expiresAt.Time
-	}
+// GetByPrefix 根据密钥前缀获取API密钥
+// 旧版本密钥的前缀可能重复，返回所有匹配的记录，由调用方比对哈希
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) ([]*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_prefix = ?`
 
-	return apiKey, nil
+	return r.query(ctx, query, prefix)
 }
 
 // GetByUserID 根据用户ID获取API密钥列表
 func (r *APIKeyRepository) GetByUserID(ctx context.Context, userID int) ([]*model.APIKey, error) {
 	query := `
-		SELECT id, user_id, key_name, api_key, status, created_at, expires_at
+		SELECT ` + apiKeyColumns + `
 		FROM api_keys WHERE user_id = ?
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, &store.DBError{
-			Code:    store.ErrDataConstraint,
-			Message: "failed to get API keys",
-			Err:     err,
-		}
-	}
-	defer rows.Close()
-
-	var apiKeys []*model.APIKey
-	for rows.Next() {
-		apiKey := &model.APIKey{}
-		err := rows.Scan(
-			&apiKey.ID, &apiKey.UserID, &apiKey.KeyName, &apiKey.APIKey,
-			&apiKey.Status, &apiKey.CreatedAt, &apiKey.ExpiresAt,
-		)
-		if err != nil {
-			return nil, &store.DBError{
-				Code:    store.ErrDataConstraint,
-				Message: "failed to scan API key",
-				Err:     err,
-			}
-		}
-		apiKeys = append(apiKeys, apiKey)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, &store.DBError{
-			Code:    store.ErrDataConstraint,
-			Message: "failed to iterate API keys",
-			Err:     err,
-		}
-	}
-
-	return apiKeys, nil
+	return r.query(ctx, query, userID)
 }
 
 // Update 更新API密钥
 func (r *APIKeyRepository) Update(ctx context.Context, apiKey *model.APIKey) error {
 	query := `
 		UPDATE api_keys 
-		SET key_name = ?, api_key = ?, status = ?, expires_at = ?
+		SET key_name = ?, key_prefix = ?, key_hash = ?, legacy_key = ?, scopes = ?, restrictions = ?, status = ?, expires_at = ?,
+			successor_id = ?, rotated_at = ?, signing_secret = ?
 		WHERE id = ?
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
-		apiKey.KeyName, apiKey.APIKey, apiKey.Status, apiKey.ExpiresAt, apiKey.ID,
+		apiKey.KeyName, apiKey.KeyPrefix, apiKey.KeyHash, apiKey.LegacyKey,
+		encodeStringList(apiKey.Scopes), encodeRestrictions(apiKey.Restrictions),
+		apiKey.Status, apiKey.ExpiresAt, apiKey.SuccessorID, apiKey.RotatedAt, apiKey.SigningSecret, apiKey.ID,
 	)
 	if err != nil {
 		return &store.DBError{
@@ -240,29 +178,194 @@ func (r *APIKeyRepository) Delete(ctx context.Context, id int) error {
 // List 获取API密钥列表
 func (r *APIKeyRepository) List(ctx context.Context, offset, limit int) ([]*model.APIKey, error) {
 	query := `
-		SELECT id, user_id, key_name, api_key, status, created_at, expires_at
+		SELECT ` + apiKeyColumns + `
 		FROM api_keys 
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	return r.query(ctx, query, limit, offset)
+}
+
+// ConsumeUse 在未达到最大使用次数时将使用次数加一，已达到时返回false
+func (r *APIKeyRepository) ConsumeUse(ctx context.Context, id int, maxUses int64) (bool, error) {
+	query := `UPDATE api_keys SET use_count = use_count + 1 WHERE id = ? AND use_count < ?`
+
+	result, err := r.db.ExecContext(ctx, query, id, maxUses)
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update API key use count",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// MarkRotated 将尚未轮换的有效密钥标记为已轮换，已被轮换或已禁用时返回false
+// 并发轮换同一密钥时只有一个请求能标记成功
+func (r *APIKeyRepository) MarkRotated(ctx context.Context, id, successorID int, rotatedAt, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE api_keys SET successor_id = ?, rotated_at = ?, expires_at = ?
+		WHERE id = ? AND successor_id = 0 AND status = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, successorID, rotatedAt, expiresAt, id, model.APIKeyStatusActive)
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to mark API key rotated",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ListRotationsEnding 获取已轮换、尚未发送通知且宽限期在(now, deadline]内结束的有效密钥
+func (r *APIKeyRepository) ListRotationsEnding(ctx context.Context, now, deadline time.Time) ([]*model.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE successor_id != 0 AND rotation_notified_at IS NULL AND status = ?
+			AND expires_at > ? AND expires_at <= ?
+		ORDER BY expires_at
+	`
+
+	return r.query(ctx, query, model.APIKeyStatusActive, now.In(time.Local), deadline.In(time.Local))
+}
+
+// MarkRotationNotified 记录宽限期即将结束的通知已发送，已记录过时返回false
+// 多个实例同时检查时只有一个实例能标记成功，由其发送通知
+func (r *APIKeyRepository) MarkRotationNotified(ctx context.Context, id int, notifiedAt time.Time) (bool, error) {
+	query := `UPDATE api_keys SET rotation_notified_at = ? WHERE id = ? AND rotation_notified_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, notifiedAt, id)
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to mark API key rotation notified",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// TouchLastUsed 更新最后使用时间和IP，已记录的时间更晚时不更新
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int, usedAt time.Time, clientIP string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = ?, last_used_ip = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`
+
+	usedAt = usedAt.In(time.Local)
+	if _, err := r.db.ExecContext(ctx, query, usedAt, clientIP, id, usedAt); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update API key last used",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// ListUnused 获取最后使用时间（从未使用时为创建时间）早于before且未过期的有效密钥
+func (r *APIKeyRepository) ListUnused(ctx context.Context, before, now time.Time) ([]*model.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE status = ? AND COALESCE(last_used_at, created_at) < ?
+			AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY id
+	`
+
+	return r.query(ctx, query, model.APIKeyStatusActive, before.In(time.Local), now.In(time.Local))
+}
+
+// DisableUnused 禁用最后使用时间（从未使用时为创建时间）仍早于before的有效密钥，期间被使用或已禁用时返回false
+func (r *APIKeyRepository) DisableUnused(ctx context.Context, id int, before time.Time) (bool, error) {
+	query := `
+		UPDATE api_keys SET status = ?
+		WHERE id = ? AND status = ? AND COALESCE(last_used_at, created_at) < ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, model.APIKeyStatusDisabled, id, model.APIKeyStatusActive, before.In(time.Local))
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to disable unused API key",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ListLegacy 获取仍以旧版本AES加密保存、尚未计算哈希的API密钥
+func (r *APIKeyRepository) ListLegacy(ctx context.Context) ([]*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE legacy_key != '' ORDER BY id`
+
+	return r.query(ctx, query)
+}
+
+// query 执行查询并扫描API密钥列表
+func (r *APIKeyRepository) query(ctx context.Context, query string, args ...any) ([]*model.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, &store.DBError{
 			Code:    store.ErrDataConstraint,
-			Message: "failed to list API keys",
+			Message: "failed to get API keys",
 			Err:     err,
 		}
 	}
-	defer rows.Close()
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭API密钥查询时出错", "error", closeErr)
+		}
+	}()
 
-	var apiKeys []*model.APIKey
+	apiKeys := make([]*model.APIKey, 0)
 	for rows.Next() {
-		apiKey := &model.APIKey{}
-		err := rows.Scan(
-			&apiKey.ID, &apiKey.UserID, &apiKey.KeyName, &apiKey.APIKey,
-			&apiKey.Status, &apiKey.CreatedAt, &apiKey.ExpiresAt,
-		)
+		apiKey, err := scanAPIKey(rows)
 		if err != nil {
 			return nil, &store.DBError{
 				Code:    store.ErrDataConstraint,
@@ -283,3 +386,52 @@ func (r *APIKeyRepository) List(ctx context.Context, offset, limit int) ([]*mode
 
 	return apiKeys, nil
 }
+
+// scanAPIKey 扫描一行API密钥数据
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	apiKey := &model.APIKey{}
+	var scopes, restrictions string
+	var expiresAt, rotatedAt, rotationNotifiedAt, lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&apiKey.ID, &apiKey.UserID, &apiKey.KeyName, &apiKey.KeyPrefix, &apiKey.KeyHash,
+		&apiKey.LegacyKey, &scopes, &restrictions, &apiKey.UseCount,
+		&apiKey.Status, &apiKey.CreatedAt, &expiresAt,
+		&apiKey.SuccessorID, &apiKey.PredecessorID, &rotatedAt, &rotationNotifiedAt,
+		&lastUsedAt, &apiKey.LastUsedIP, &apiKey.SigningSecret,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Scopes = decodeStringList(scopes)
+	if restrictions != "" {
+		if err := json.Unmarshal([]byte(restrictions), &apiKey.Restrictions); err != nil {
+			return nil, err
+		}
+	}
+
+	if expiresAt.Valid {
+		apiKey.ExpiresAt = &expiresAt.Time
+	}
+	if rotatedAt.Valid {
+		apiKey.RotatedAt = &rotatedAt.Time
+	}
+	if rotationNotifiedAt.Valid {
+		apiKey.RotationNotifiedAt = &rotationNotifiedAt.Time
+	}
+	if lastUsedAt.Valid {
+		apiKey.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return apiKey, nil
+}
+
+// encodeRestrictions 将API密钥使用限制编码为JSON
+func encodeRestrictions(restrictions model.APIKeyRestrictions) string {
+	data, err := json.Marshal(restrictions)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,260 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// auditLogColumns 审计日志查询列
+const auditLogColumns = `id, actor_id, actor_name, action, target_type, target_id, before_data, after_data,
+	client_ip, request_id, created_at, prev_hash, hash`
+
+// AuditLogRepository 审计日志仓库SQLite实现
+type AuditLogRepository struct {
+	db DBExecutor
+}
+
+// Append 追加审计日志
+// 先插入记录取得数据库写锁，再读取上一条记录的哈希补写本条哈希，
+// 写锁保证并发写入时哈希链不会分叉。不在事务中调用时自动开启事务
+func (r *AuditLogRepository) Append(ctx context.Context, log *model.AuditLog) error {
+	if _, ok := unwrapExecutor(r.db).(*sql.Tx); ok {
+		return r.append(ctx, log)
+	}
+
+	db, ok := unwrapExecutor(r.db).(*sql.DB)
+	if !ok {
+		return &store.DBError{
+			Code:    store.ErrTransactionFailed,
+			Message: "invalid database executor",
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrTransactionFailed,
+			Message: "failed to begin transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	txRepo := &AuditLogRepository{db: traced(tx)}
+	if err := txRepo.append(ctx, log); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &store.DBError{
+			Code:    store.ErrTransactionFailed,
+			Message: "failed to commit audit log",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// append 在当前事务中写入审计日志
+func (r *AuditLogRepository) append(ctx context.Context, log *model.AuditLog) error {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	// 截断到存储精度，保证写入与读出的记录计算出相同的哈希
+	createdAt := log.CreatedAt.UTC().Format(model.AuditTimeFormat)
+	log.CreatedAt, _ = time.Parse(model.AuditTimeFormat, createdAt)
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_logs (actor_id, actor_name, action, target_type, target_id, before_data, after_data,
+			client_ip, request_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		log.ActorID, log.ActorName, log.Action, log.TargetType, log.TargetID,
+		string(log.Before), string(log.After), log.ClientIP, log.RequestID, createdAt,
+	)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create audit log",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get audit log ID",
+			Err:     err,
+		}
+	}
+	log.ID = int(id)
+
+	var prevHash string
+	err = r.db.QueryRowContext(ctx, `SELECT hash FROM audit_logs WHERE id < ? ORDER BY id DESC LIMIT 1`, log.ID).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get previous audit log hash",
+			Err:     err,
+		}
+	}
+
+	log.PrevHash = prevHash
+	log.Hash = log.ComputeHash()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE audit_logs SET prev_hash = ?, hash = ? WHERE id = ?`,
+		log.PrevHash, log.Hash, log.ID); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update audit log hash",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// Query 按条件查询审计日志
+func (r *AuditLogRepository) Query(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, error) {
+	logs := make([]*model.AuditLog, 0)
+	err := r.Iterate(ctx, filter, func(log *model.AuditLog) error {
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Iterate 按条件逐条遍历审计日志
+func (r *AuditLogRepository) Iterate(ctx context.Context, filter model.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	query, args := buildAuditLogFilterQuery(filter)
+	return r.iterate(ctx, query, args, fn)
+}
+
+// Walk 按ID升序遍历全部审计日志
+func (r *AuditLogRepository) Walk(ctx context.Context, fn func(*model.AuditLog) error) error {
+	query := `SELECT ` + auditLogColumns + ` FROM audit_logs ORDER BY id ASC`
+	return r.iterate(ctx, query, nil, fn)
+}
+
+// iterate 执行查询并逐条回调
+func (r *AuditLogRepository) iterate(ctx context.Context, query string, args []interface{}, fn func(*model.AuditLog) error) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to query audit logs",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭审计日志查询时出错", "error", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan audit log",
+				Err:     err,
+			}
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate audit logs",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// buildAuditLogFilterQuery 根据查询条件构造SQL
+func buildAuditLogFilterQuery(filter model.AuditLogFilter) (string, []interface{}) {
+	conditions := make([]string, 0, 7)
+	args := make([]interface{}, 0, 8)
+
+	if filter.ActorID > 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if !filter.StartTime.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.StartTime.UTC().Format(model.AuditTimeFormat))
+	}
+	if !filter.EndTime.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.EndTime.UTC().Format(model.AuditTimeFormat))
+	}
+	if filter.Cursor > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.Cursor)
+	}
+
+	query := "SELECT " + auditLogColumns + " FROM audit_logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	return query, args
+}
+
+// scanAuditLog 扫描一行审计日志
+func scanAuditLog(rows *sql.Rows) (*model.AuditLog, error) {
+	log := &model.AuditLog{}
+	var before, after, createdAt string
+	err := rows.Scan(
+		&log.ID, &log.ActorID, &log.ActorName, &log.Action, &log.TargetType, &log.TargetID,
+		&before, &after, &log.ClientIP, &log.RequestID, &createdAt, &log.PrevHash, &log.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if before != "" {
+		log.Before = []byte(before)
+	}
+	if after != "" {
+		log.After = []byte(after)
+	}
+	log.CreatedAt, err = time.Parse(model.AuditTimeFormat, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
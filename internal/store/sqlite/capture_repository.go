@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// capturedExchangeColumns 抓取记录元数据查询列，不包含加密内容
+const capturedExchangeColumns = `id, rule_id, request_id, service_name, user_id, api_key_id, method, endpoint,
+	status, duration_ms, request_truncated, response_truncated, replay_payload != '', created_at, expires_at`
+
+// CaptureRepository 请求/响应抓取记录仓库SQLite实现
+type CaptureRepository struct {
+	db DBExecutor
+}
+
+// scanCapturedExchange 扫描一行抓取记录元数据，extra为额外查询列的接收变量
+func scanCapturedExchange(row interface{ Scan(dest ...any) error }, extra ...any) (*model.CapturedExchange, error) {
+	exchange := &model.CapturedExchange{}
+	dest := []any{
+		&exchange.ID, &exchange.RuleID, &exchange.RequestID, &exchange.ServiceName,
+		&exchange.UserID, &exchange.APIKeyID, &exchange.Method, &exchange.Endpoint,
+		&exchange.Status, &exchange.DurationMs, &exchange.RequestTruncated, &exchange.ResponseTruncated,
+		&exchange.Replayable, &exchange.CreatedAt, &exchange.ExpiresAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return exchange, nil
+}
+
+// Create 创建抓取记录
+func (r *CaptureRepository) Create(ctx context.Context, exchange *model.CapturedExchange) error {
+	query := `
+		INSERT INTO captured_exchanges (rule_id, request_id, service_name, user_id, api_key_id, method, endpoint,
+			status, duration_ms, request_truncated, response_truncated, payload, replay_payload, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		exchange.RuleID, exchange.RequestID, exchange.ServiceName, exchange.UserID, exchange.APIKeyID,
+		exchange.Method, exchange.Endpoint, exchange.Status, exchange.DurationMs,
+		exchange.RequestTruncated, exchange.ResponseTruncated, exchange.Payload, exchange.ReplayPayload,
+		exchange.CreatedAt, exchange.ExpiresAt,
+	)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create captured exchange",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get captured exchange ID",
+			Err:     err,
+		}
+	}
+
+	exchange.ID = int(id)
+	return nil
+}
+
+// GetByID 根据ID获取未过期的抓取记录，包含加密内容和重放请求
+func (r *CaptureRepository) GetByID(ctx context.Context, id int) (*model.CapturedExchange, error) {
+	query := `SELECT ` + capturedExchangeColumns + `, payload, replay_payload FROM captured_exchanges WHERE id = ? AND expires_at > ?`
+
+	var payload, replayPayload string
+	exchange, err := scanCapturedExchange(r.db.QueryRowContext(ctx, query, id, time.Now()), &payload, &replayPayload)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "captured exchange not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get captured exchange",
+			Err:     err,
+		}
+	}
+
+	exchange.Payload = payload
+	exchange.ReplayPayload = replayPayload
+	return exchange, nil
+}
+
+// Query 按条件查询未过期的抓取记录
+func (r *CaptureRepository) Query(ctx context.Context, filter model.CaptureFilter) ([]*model.CapturedExchange, error) {
+	conditions := []string{"expires_at > ?"}
+	args := []interface{}{time.Now()}
+
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = ?")
+		args = append(args, filter.ServiceName)
+	}
+	if filter.APIKeyID > 0 {
+		conditions = append(conditions, "api_key_id = ?")
+		args = append(args, filter.APIKeyID)
+	}
+	if filter.UserID > 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = ?")
+		args = append(args, filter.RequestID)
+	}
+	// created_at以本地时区写入，比较前统一转换
+	if !filter.StartTime.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.StartTime.In(time.Local))
+	}
+	if !filter.EndTime.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.EndTime.In(time.Local))
+	}
+	if filter.Cursor > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.Cursor)
+	}
+
+	query := "SELECT " + capturedExchangeColumns + " FROM captured_exchanges WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to query captured exchanges",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭抓取记录查询时出错", "error", closeErr)
+		}
+	}()
+
+	exchanges := make([]*model.CapturedExchange, 0)
+	for rows.Next() {
+		exchange, err := scanCapturedExchange(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan captured exchange",
+				Err:     err,
+			}
+		}
+		exchanges = append(exchanges, exchange)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate captured exchanges",
+			Err:     err,
+		}
+	}
+
+	return exchanges, nil
+}
+
+// DeleteExpired 删除已过期的抓取记录
+func (r *CaptureRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	query := `DELETE FROM captured_exchanges WHERE id IN (
+		SELECT id FROM captured_exchanges WHERE expires_at <= ? ORDER BY id LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, now.In(time.Local), limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete expired captured exchanges",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// captureRuleColumns 抓取规则查询列
+const captureRuleColumns = `id, scope, target, enabled, sample_rate, max_body_bytes,
+	redact_headers, redact_json_paths, redact_patterns, retention_hours, replayable,
+	description, created_by, created_at, updated_at`
+
+// CaptureRuleRepository 请求/响应抓取规则仓库SQLite实现
+type CaptureRuleRepository struct {
+	db DBExecutor
+}
+
+// Create 创建抓取规则
+func (r *CaptureRuleRepository) Create(ctx context.Context, rule *model.CaptureRule) error {
+	query := `
+		INSERT INTO capture_rules (scope, target, enabled, sample_rate, max_body_bytes,
+			redact_headers, redact_json_paths, redact_patterns, retention_hours, replayable,
+			description, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Scope, rule.Target, rule.Enabled, rule.SampleRate, rule.MaxBodyBytes,
+		encodeStringList(rule.RedactHeaders), encodeStringList(rule.RedactJSONPaths), encodeStringList(rule.RedactPatterns),
+		rule.RetentionHours, rule.Replayable, rule.Description, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "capture rule already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create capture rule",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get capture rule ID",
+			Err:     err,
+		}
+	}
+
+	rule.ID = int(id)
+	return nil
+}
+
+// GetByID 根据ID获取抓取规则
+func (r *CaptureRuleRepository) GetByID(ctx context.Context, id int) (*model.CaptureRule, error) {
+	query := `SELECT ` + captureRuleColumns + ` FROM capture_rules WHERE id = ?`
+
+	rule, err := scanCaptureRule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "capture rule not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get capture rule",
+			Err:     err,
+		}
+	}
+
+	return rule, nil
+}
+
+// List 按作用范围和目标获取抓取规则
+func (r *CaptureRuleRepository) List(ctx context.Context, scope, target string) ([]*model.CaptureRule, error) {
+	query := `
+		SELECT ` + captureRuleColumns + `
+		FROM capture_rules
+		WHERE (? = '' OR scope = ?) AND (? = '' OR target = ?)
+		ORDER BY scope, target, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scope, scope, target, target)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list capture rules",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭抓取规则查询时出错", "error", closeErr)
+		}
+	}()
+
+	rules := make([]*model.CaptureRule, 0)
+	for rows.Next() {
+		rule, err := scanCaptureRule(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan capture rule",
+				Err:     err,
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate capture rules",
+			Err:     err,
+		}
+	}
+
+	return rules, nil
+}
+
+// Update 更新抓取规则，作用范围和目标不可修改
+func (r *CaptureRuleRepository) Update(ctx context.Context, rule *model.CaptureRule) error {
+	query := `
+		UPDATE capture_rules
+		SET enabled = ?, sample_rate = ?, max_body_bytes = ?,
+			redact_headers = ?, redact_json_paths = ?, redact_patterns = ?,
+			retention_hours = ?, replayable = ?, description = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	rule.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Enabled, rule.SampleRate, rule.MaxBodyBytes,
+		encodeStringList(rule.RedactHeaders), encodeStringList(rule.RedactJSONPaths), encodeStringList(rule.RedactPatterns),
+		rule.RetentionHours, rule.Replayable, rule.Description, rule.UpdatedAt, rule.ID,
+	)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update capture rule",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "capture rule not found",
+		}
+	}
+
+	return nil
+}
+
+// Delete 删除抓取规则，已抓取的记录保留到各自的过期时间
+func (r *CaptureRuleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM capture_rules WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete capture rule",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "capture rule not found",
+		}
+	}
+
+	return nil
+}
+
+// scanCaptureRule 扫描一行抓取规则
+func scanCaptureRule(row interface{ Scan(dest ...any) error }) (*model.CaptureRule, error) {
+	rule := &model.CaptureRule{}
+	var redactHeaders, redactJSONPaths, redactPatterns string
+
+	err := row.Scan(
+		&rule.ID, &rule.Scope, &rule.Target, &rule.Enabled, &rule.SampleRate, &rule.MaxBodyBytes,
+		&redactHeaders, &redactJSONPaths, &redactPatterns, &rule.RetentionHours, &rule.Replayable,
+		&rule.Description, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.RedactHeaders = decodeStringList(redactHeaders)
+	rule.RedactJSONPaths = decodeStringList(redactJSONPaths)
+	rule.RedactPatterns = decodeStringList(redactPatterns)
+	return rule, nil
+}
+
+// encodeStringList 将字符串列表编码为JSON数组
+func encodeStringList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// decodeStringList 解析JSON数组格式的字符串列表，无法解析时返回空列表
+func decodeStringList(data string) []string {
+	values := make([]string, 0)
+	if data == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return make([]string, 0)
+	}
+	return values
+}
@@ -139,10 +139,10 @@ func (r *ConfigRepository) Delete(ctx context.Context, key string) error {
 // BatchSet 批量设置配置项
 func (r *ConfigRepository) BatchSet(ctx context.Context, configs map[string]string) error {
 	// 开始事务（如果当前不在事务中）
-	_, ok := r.db.(*sql.Tx)
+	_, ok := unwrapExecutor(r.db).(*sql.Tx)
 	if !ok {
 		// 如果不是事务，需要创建事务
-		db, ok := r.db.(*sql.DB)
+		db, ok := unwrapExecutor(r.db).(*sql.DB)
 		if !ok {
 			return &store.DBError{
 				Code:    store.ErrTransactionFailed,
@@ -161,7 +161,7 @@ func (r *ConfigRepository) BatchSet(ctx context.Context, configs map[string]stri
 		defer newTx.Rollback()
 
 		// 使用新事务执行批量操作
-		txRepo := &ConfigRepository{db: newTx}
+		txRepo := &ConfigRepository{db: traced(newTx)}
 		for key, value := range configs {
 			if err := txRepo.Set(ctx, key, value); err != nil {
 				return err
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// IPRuleRepository IP访问规则仓库SQLite实现
+type IPRuleRepository struct {
+	db DBExecutor
+}
+
+// Create 创建IP访问规则
+func (r *IPRuleRepository) Create(ctx context.Context, rule *model.IPRule) error {
+	query := `
+		INSERT INTO ip_rules (scope, target, action, cidr, description, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	rule.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.Scope, rule.Target, rule.Action, rule.CIDR,
+		rule.Description, rule.CreatedBy, rule.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "IP rule already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create IP rule",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get IP rule ID",
+			Err:     err,
+		}
+	}
+
+	rule.ID = int(id)
+	return nil
+}
+
+// GetByID 根据ID获取IP访问规则
+func (r *IPRuleRepository) GetByID(ctx context.Context, id int) (*model.IPRule, error) {
+	query := `
+		SELECT id, scope, target, action, cidr, description, created_by, created_at
+		FROM ip_rules WHERE id = ?
+	`
+
+	rule := &model.IPRule{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&rule.ID, &rule.Scope, &rule.Target, &rule.Action, &rule.CIDR,
+		&rule.Description, &rule.CreatedBy, &rule.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "IP rule not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get IP rule",
+			Err:     err,
+		}
+	}
+
+	return rule, nil
+}
+
+// List 按作用范围和目标获取IP访问规则
+func (r *IPRuleRepository) List(ctx context.Context, scope, target string) ([]*model.IPRule, error) {
+	query := `
+		SELECT id, scope, target, action, cidr, description, created_by, created_at
+		FROM ip_rules
+		WHERE (? = '' OR scope = ?) AND (? = '' OR target = ?)
+		ORDER BY scope, target, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scope, scope, target, target)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list IP rules",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭IP规则查询时出错", "error", closeErr)
+		}
+	}()
+
+	var rules []*model.IPRule
+	for rows.Next() {
+		rule := &model.IPRule{}
+		err := rows.Scan(
+			&rule.ID, &rule.Scope, &rule.Target, &rule.Action, &rule.CIDR,
+			&rule.Description, &rule.CreatedBy, &rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan IP rule",
+				Err:     err,
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate IP rules",
+			Err:     err,
+		}
+	}
+
+	return rules, nil
+}
+
+// Delete 删除IP访问规则
+func (r *IPRuleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM ip_rules WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete IP rule",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "IP rule not found",
+		}
+	}
+
+	return nil
+}
+
+// DeleteByTarget 删除指定作用范围和目标的所有IP访问规则
+func (r *IPRuleRepository) DeleteByTarget(ctx context.Context, scope, target string) error {
+	query := `DELETE FROM ip_rules WHERE scope = ? AND target = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, target); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete IP rules",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
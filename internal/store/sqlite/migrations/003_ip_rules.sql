@@ -0,0 +1,18 @@
+-- IP访问规则表
+-- scope: global-全局, service-服务级, apikey-API密钥级
+-- target: 服务名称或API密钥ID，全局规则为空字符串
+-- action: allow-允许列表, deny-拒绝列表
+CREATE TABLE IF NOT EXISTS ip_rules (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope       TEXT NOT NULL,
+    target      TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,
+    cidr        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by  INTEGER NOT NULL DEFAULT 0,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scope, target, action, cidr)
+);
+
+-- 创建IP访问规则表索引
+CREATE INDEX IF NOT EXISTS idx_ip_rules_scope_target ON ip_rules(scope, target);
@@ -0,0 +1,14 @@
+-- 访问日志增加请求详情字段，用于排查慢调用和定位滥用来源
+ALTER TABLE access_logs ADD COLUMN duration_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE access_logs ADD COLUMN client_ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_logs ADD COLUMN method TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_logs ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_logs ADD COLUMN request_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE access_logs ADD COLUMN response_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE access_logs ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_logs ADD COLUMN auth_method TEXT NOT NULL DEFAULT '';
+ALTER TABLE access_logs ADD COLUMN error_code INTEGER NOT NULL DEFAULT 0;
+
+-- 按请求ID和客户端IP查询的索引
+CREATE INDEX IF NOT EXISTS idx_access_logs_request_id ON access_logs(request_id);
+CREATE INDEX IF NOT EXISTS idx_access_logs_client_ip ON access_logs(client_ip, created_at);
@@ -0,0 +1,39 @@
+-- 使用量汇总表，按小时/天/月增量维护，统计接口直接查询该表
+-- 时间桶按UTC划分：hour为'YYYY-MM-DD HH:00'，day为'YYYY-MM-DD'，month为'YYYY-MM'
+CREATE TABLE IF NOT EXISTS usage_rollups (
+    granularity       TEXT NOT NULL,
+    bucket            TEXT NOT NULL,
+    user_id           INTEGER NOT NULL DEFAULT 0,
+    api_key_id        INTEGER NOT NULL DEFAULT 0,
+    service_name      TEXT NOT NULL,
+    status_class      TEXT NOT NULL,
+    total_calls       INTEGER NOT NULL DEFAULT 0,
+    total_cost        INTEGER NOT NULL DEFAULT 0,
+    total_duration_ms INTEGER NOT NULL DEFAULT 0,
+    max_duration_ms   INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (granularity, bucket, user_id, api_key_id, service_name, status_class)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_rollups_user ON usage_rollups(granularity, user_id, bucket);
+
+-- 根据已有访问日志回填汇总数据
+INSERT INTO usage_rollups (granularity, bucket, user_id, api_key_id, service_name, status_class,
+    total_calls, total_cost, total_duration_ms, max_duration_ms)
+SELECT 'hour', strftime('%Y-%m-%d %H:00', created_at), user_id, api_key_id, service_name, (status / 100) || 'xx',
+    COUNT(*), SUM(cost), SUM(duration_ms), MAX(duration_ms)
+FROM access_logs
+GROUP BY 2, 3, 4, 5, 6;
+
+INSERT INTO usage_rollups (granularity, bucket, user_id, api_key_id, service_name, status_class,
+    total_calls, total_cost, total_duration_ms, max_duration_ms)
+SELECT 'day', strftime('%Y-%m-%d', created_at), user_id, api_key_id, service_name, (status / 100) || 'xx',
+    COUNT(*), SUM(cost), SUM(duration_ms), MAX(duration_ms)
+FROM access_logs
+GROUP BY 2, 3, 4, 5, 6;
+
+INSERT INTO usage_rollups (granularity, bucket, user_id, api_key_id, service_name, status_class,
+    total_calls, total_cost, total_duration_ms, max_duration_ms)
+SELECT 'month', strftime('%Y-%m', created_at), user_id, api_key_id, service_name, (status / 100) || 'xx',
+    COUNT(*), SUM(cost), SUM(duration_ms), MAX(duration_ms)
+FROM access_logs
+GROUP BY 2, 3, 4, 5, 6;
@@ -0,0 +1,36 @@
+-- 审计日志表，记录管理操作和安全相关操作，只允许追加
+-- 每条记录的hash覆盖自身内容和上一条记录的hash，形成哈希链，任何修改或删除都会使校验失败
+-- created_at以固定宽度的UTC文本存储，保证哈希计算与字符串比较结果稳定
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id    INTEGER NOT NULL DEFAULT 0,
+    actor_name  TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id   TEXT NOT NULL DEFAULT '',
+    before_data TEXT NOT NULL DEFAULT '',
+    after_data  TEXT NOT NULL DEFAULT '',
+    client_ip   TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL DEFAULT '',
+    created_at  TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL DEFAULT '',
+    hash        TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs(target_type, target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+-- 写入时先插入记录占得写锁，再补写哈希，因此只允许更新哈希尚为空的记录
+CREATE TRIGGER IF NOT EXISTS audit_logs_no_update
+BEFORE UPDATE ON audit_logs
+WHEN OLD.hash != ''
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_logs_no_delete
+BEFORE DELETE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
@@ -0,0 +1,46 @@
+-- 请求/响应内容抓取规则表
+-- scope: service-服务级, apikey-API密钥级，同时命中时API密钥级规则优先
+-- target: 服务名称或API密钥ID
+-- redact_headers/redact_json_paths/redact_patterns: JSON数组格式的脱敏规则
+CREATE TABLE IF NOT EXISTS capture_rules (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope             TEXT NOT NULL,
+    target            TEXT NOT NULL,
+    enabled           INTEGER NOT NULL DEFAULT 1,
+    sample_rate       REAL NOT NULL DEFAULT 1,
+    max_body_bytes    INTEGER NOT NULL DEFAULT 0,
+    redact_headers    TEXT NOT NULL DEFAULT '[]',
+    redact_json_paths TEXT NOT NULL DEFAULT '[]',
+    redact_patterns   TEXT NOT NULL DEFAULT '[]',
+    retention_hours   INTEGER NOT NULL DEFAULT 0,
+    description       TEXT NOT NULL DEFAULT '',
+    created_by        INTEGER NOT NULL DEFAULT 0,
+    created_at        DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scope, target)
+);
+
+-- 抓取到的请求/响应记录表
+-- payload为加密后的请求头、请求体、响应头和响应体，其余字段为明文元数据，用于检索
+CREATE TABLE IF NOT EXISTS captured_exchanges (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id            INTEGER NOT NULL DEFAULT 0,
+    request_id         TEXT NOT NULL DEFAULT '',
+    service_name       TEXT NOT NULL,
+    user_id            INTEGER NOT NULL DEFAULT 0,
+    api_key_id         INTEGER NOT NULL DEFAULT 0,
+    method             TEXT NOT NULL DEFAULT '',
+    endpoint           TEXT NOT NULL DEFAULT '',
+    status             INTEGER NOT NULL DEFAULT 0,
+    duration_ms        INTEGER NOT NULL DEFAULT 0,
+    request_truncated  INTEGER NOT NULL DEFAULT 0,
+    response_truncated INTEGER NOT NULL DEFAULT 0,
+    payload            TEXT NOT NULL,
+    created_at         DATETIME NOT NULL,
+    expires_at         DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_captured_exchanges_service ON captured_exchanges(service_name, id);
+CREATE INDEX IF NOT EXISTS idx_captured_exchanges_api_key ON captured_exchanges(api_key_id, id);
+CREATE INDEX IF NOT EXISTS idx_captured_exchanges_request_id ON captured_exchanges(request_id);
+CREATE INDEX IF NOT EXISTS idx_captured_exchanges_expires_at ON captured_exchanges(expires_at);
@@ -0,0 +1,7 @@
+-- 抓取规则增加重放开关
+-- replayable: 为1时额外保存未脱敏的原始请求（不含认证相关请求头），用于在管理后台重放
+ALTER TABLE capture_rules ADD COLUMN replayable INTEGER NOT NULL DEFAULT 0;
+
+-- 抓取记录增加重放请求
+-- replay_payload为加密后的原始请求，未开启重放或请求体被截断时为空
+ALTER TABLE captured_exchanges ADD COLUMN replay_payload TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- JWT签名密钥表
+-- private_key为加密后的RSA私钥PEM，public_key为公钥PEM，通过kid在令牌头中标识
+-- activates_at起开始用于签名，sign_until后不再签发新令牌，verify_until前仍可用于验证已签发的令牌
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    kid          TEXT NOT NULL UNIQUE,
+    algorithm    TEXT NOT NULL DEFAULT 'RS256',
+    private_key  TEXT NOT NULL,
+    public_key   TEXT NOT NULL,
+    activates_at DATETIME NOT NULL,
+    sign_until   DATETIME NOT NULL,
+    verify_until DATETIME NOT NULL,
+    created_at   DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_verify_until ON jwt_signing_keys(verify_until);
@@ -0,0 +1,20 @@
+-- 刷新令牌表
+-- 只保存令牌的SHA-256哈希；同一次登录轮换产生的令牌属于同一家族（family_id），
+-- 每个令牌只能使用一次，used_at记录使用时间，已使用的令牌再次出现时撤销整个家族
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL,
+    family_id     TEXT NOT NULL,
+    token_hash    TEXT NOT NULL UNIQUE,
+    parent_id     INTEGER NOT NULL DEFAULT 0,
+    expires_at    DATETIME NOT NULL,
+    used_at       DATETIME,
+    revoked_at    DATETIME,
+    revoke_reason TEXT NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
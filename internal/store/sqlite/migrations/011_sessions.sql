@@ -0,0 +1,19 @@
+-- 登录会话表
+-- id即访问令牌中的jti，同一会话刷新后签发的访问令牌沿用该ID，同时作为刷新令牌的家族ID；
+-- 会话撤销后其访问令牌立即失效，刷新令牌一并撤销
+CREATE TABLE IF NOT EXISTS sessions (
+    id            TEXT PRIMARY KEY,
+    user_id       INTEGER NOT NULL,
+    device        TEXT NOT NULL DEFAULT '',
+    client_ip     TEXT NOT NULL DEFAULT '',
+    user_agent    TEXT NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL,
+    last_seen_at  DATETIME NOT NULL,
+    expires_at    DATETIME NOT NULL,
+    revoked_at    DATETIME,
+    revoke_reason TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
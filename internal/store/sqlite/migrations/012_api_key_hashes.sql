@@ -0,0 +1,25 @@
+-- API密钥改为保存带前缀的哈希
+-- 新密钥格式为 ahk_<密钥ID>_<随机部分>，key_prefix保存 ahk_<密钥ID> 用于查找，key_hash保存完整密钥的HMAC-SHA256；
+-- 旧版本AES加密保存的密钥暂存在legacy_key中，由服务启动时解密并重新计算哈希后清空
+-- api_keys没有被其他表的外键引用，直接重建表以去掉api_key列上的唯一约束
+CREATE TABLE api_keys_new (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL,
+    key_name    TEXT NOT NULL,
+    key_prefix  TEXT NOT NULL DEFAULT '',
+    key_hash    TEXT NOT NULL DEFAULT '',
+    legacy_key  TEXT NOT NULL DEFAULT '',
+    status      INTEGER NOT NULL DEFAULT 1,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at  DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO api_keys_new (id, user_id, key_name, legacy_key, status, created_at, expires_at)
+SELECT id, user_id, key_name, api_key, status, created_at, expires_at FROM api_keys;
+
+DROP TABLE api_keys;
+ALTER TABLE api_keys_new RENAME TO api_keys;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,3 @@
+-- API密钥权限范围，JSON数组格式
+-- 已有密钥保持原有行为，可以调用所有服务，但不能访问Dashboard接口
+ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT '["service:*"]';
@@ -0,0 +1,5 @@
+-- API密钥使用限制
+-- restrictions为JSON对象，包含允许的网段、请求来源、使用时间段和最大使用次数；
+-- use_count仅在设置了最大使用次数时累计
+ALTER TABLE api_keys ADD COLUMN restrictions TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE api_keys ADD COLUMN use_count INTEGER NOT NULL DEFAULT 0;
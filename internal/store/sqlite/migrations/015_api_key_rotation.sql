@@ -0,0 +1,26 @@
+-- API密钥轮换
+-- 轮换时创建继任密钥，旧密钥的successor_id指向继任密钥、predecessor_id反向关联，
+-- 旧密钥在宽限期内继续有效，宽限期结束时间即其expires_at；
+-- rotation_notified_at记录宽限期即将结束的通知发送时间，避免重复通知
+ALTER TABLE api_keys ADD COLUMN successor_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN predecessor_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN rotated_at DATETIME;
+ALTER TABLE api_keys ADD COLUMN rotation_notified_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_rotation ON api_keys(successor_id, expires_at);
+
+-- 站内通知表
+CREATE TABLE IF NOT EXISTS notifications (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL,
+    type        TEXT NOT NULL,
+    title       TEXT NOT NULL,
+    message     TEXT NOT NULL DEFAULT '',
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id   TEXT NOT NULL DEFAULT '',
+    read_at     DATETIME,
+    created_at  DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, id);
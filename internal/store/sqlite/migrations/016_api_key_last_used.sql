@@ -0,0 +1,9 @@
+-- API密钥最后使用信息
+-- 由访问日志写入器在批量写入日志时一并更新，不在认证路径上写库；
+-- 已有密钥按现存的访问日志初始化，没有访问日志的密钥保持为空，按创建时间判断是否长期未使用
+ALTER TABLE api_keys ADD COLUMN last_used_at DATETIME;
+ALTER TABLE api_keys ADD COLUMN last_used_ip TEXT NOT NULL DEFAULT '';
+
+UPDATE api_keys SET
+    last_used_at = (SELECT created_at FROM access_logs WHERE api_key_id = api_keys.id ORDER BY id DESC LIMIT 1),
+    last_used_ip = COALESCE((SELECT client_ip FROM access_logs WHERE api_key_id = api_keys.id ORDER BY id DESC LIMIT 1), '');
@@ -0,0 +1,4 @@
+-- API密钥请求签名
+-- 启用签名后以key_prefix作为访问密钥（Access Key），signing_secret保存加密后的签名密钥（Secret Key）；
+-- 服务端验证签名时需要签名密钥原文，因此只能加密保存而不能像API密钥一样只保存哈希，为空表示未启用签名
+ALTER TABLE api_keys ADD COLUMN signing_secret TEXT NOT NULL DEFAULT '';
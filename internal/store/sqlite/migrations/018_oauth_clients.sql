@@ -0,0 +1,18 @@
+-- OAuth2客户端表
+-- 用户创建的客户端凭据，用于客户端凭据模式（client_credentials）获取访问令牌；
+-- client_secret与API密钥一样只保存HMAC-SHA256哈希，scopes为允许申请的权限范围（JSON数组），
+-- 签发的访问令牌以所属用户的身份调用服务，使用量和配额计入该用户
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL,
+    name          TEXT NOT NULL,
+    client_id     TEXT NOT NULL UNIQUE,
+    secret_hash   TEXT NOT NULL,
+    scopes        TEXT NOT NULL DEFAULT '[]',
+    status        INTEGER NOT NULL DEFAULT 1,
+    created_at    DATETIME NOT NULL,
+    last_token_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_user_id ON oauth_clients(user_id);
@@ -0,0 +1,8 @@
+-- API密钥允许的网段统一由IP访问规则检查
+-- 将使用限制中的allowed_cidrs迁移为apikey作用范围的允许规则，并从使用限制中移除
+INSERT OR IGNORE INTO ip_rules (scope, target, action, cidr, description, created_by, created_at)
+SELECT 'apikey', CAST(api_keys.id AS TEXT), 'allow', cidr.value, '由API密钥使用限制迁移', api_keys.user_id, CURRENT_TIMESTAMP
+FROM api_keys, json_each(api_keys.restrictions, '$.allowed_cidrs') AS cidr;
+
+UPDATE api_keys SET restrictions = json_remove(restrictions, '$.allowed_cidrs')
+WHERE json_extract(restrictions, '$.allowed_cidrs') IS NOT NULL;
@@ -0,0 +1,11 @@
+-- 签名请求已使用的随机数
+-- 同一访问密钥的随机数在请求时间戳有效期内不能重复，expires_at为请求时间戳加允许的最大偏差，
+-- 过期后对应的请求已因时间戳超出范围被拒绝，记录由数据清理任务删除；保存在数据库中使多个实例共享
+CREATE TABLE IF NOT EXISTS signature_nonces (
+    access_key TEXT NOT NULL,
+    nonce      TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (access_key, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_signature_nonces_expires_at ON signature_nonces(expires_at);
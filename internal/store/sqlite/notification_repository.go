@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// notificationColumns 通知查询列
+const notificationColumns = `id, user_id, type, title, message, target_type, target_id, read_at, created_at`
+
+// NotificationRepository 站内通知仓库SQLite实现
+type NotificationRepository struct {
+	db DBExecutor
+}
+
+// Create 创建通知
+func (r *NotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, type, title, message, target_type, target_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	notification.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		notification.UserID, notification.Type, notification.Title, notification.Message,
+		notification.TargetType, notification.TargetID, notification.CreatedAt,
+	)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create notification",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get notification ID",
+			Err:     err,
+		}
+	}
+
+	notification.ID = int(id)
+	return nil
+}
+
+// ListByUser 获取用户的通知，按ID倒序，unreadOnly为true时只返回未读通知
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID int, unreadOnly bool, offset, limit int) ([]*model.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = ?`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list notifications",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭通知查询时出错", "error", closeErr)
+		}
+	}()
+
+	notifications := make([]*model.Notification, 0)
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan notification",
+				Err:     err,
+			}
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate notifications",
+			Err:     err,
+		}
+	}
+
+	return notifications, nil
+}
+
+// CountUnread 获取用户的未读通知数
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to count unread notifications",
+			Err:     err,
+		}
+	}
+
+	return count, nil
+}
+
+// MarkRead 将用户的未读通知标记为已读，ids为空时标记全部，返回标记的通知数
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID int, ids []int, readAt time.Time) (int64, error) {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []any{readAt, userID}
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to mark notifications read",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
+
+// scanNotification 扫描一行通知数据
+func scanNotification(row rowScanner) (*model.Notification, error) {
+	notification := &model.Notification{}
+	var readAt sql.NullTime
+
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &notification.Type, &notification.Title,
+		&notification.Message, &notification.TargetType, &notification.TargetID,
+		&readAt, &notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+
+	return notification, nil
+}
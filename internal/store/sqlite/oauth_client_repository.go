@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// oauthClientColumns OAuth2客户端查询列
+const oauthClientColumns = `id, user_id, name, client_id, secret_hash, scopes, status, created_at, last_token_at`
+
+// OAuthClientRepository OAuth2客户端仓库SQLite实现
+type OAuthClientRepository struct {
+	db DBExecutor
+}
+
+// Create 创建OAuth2客户端
+func (r *OAuthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	query := `
+		INSERT INTO oauth_clients (user_id, name, client_id, secret_hash, scopes, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	client.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		client.UserID, client.Name, client.ClientID, client.SecretHash, encodeStringList(client.Scopes),
+		client.Status, client.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "OAuth client already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create OAuth client",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get OAuth client ID",
+			Err:     err,
+		}
+	}
+
+	client.ID = int(id)
+	return nil
+}
+
+// GetByID 根据ID获取OAuth2客户端
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id int) (*model.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+// GetByClientID 根据客户端ID获取OAuth2客户端
+func (r *OAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = ?`
+
+	return r.get(ctx, query, clientID)
+}
+
+// ListByUser 获取用户的OAuth2客户端，按创建时间倒序
+func (r *OAuthClientRepository) ListByUser(ctx context.Context, userID int) ([]*model.OAuthClient, error) {
+	query := `
+		SELECT ` + oauthClientColumns + `
+		FROM oauth_clients WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list OAuth clients",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭OAuth2客户端查询时出错", "error", closeErr)
+		}
+	}()
+
+	clients := make([]*model.OAuthClient, 0)
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan OAuth client",
+				Err:     err,
+			}
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate OAuth clients",
+			Err:     err,
+		}
+	}
+
+	return clients, nil
+}
+
+// Update 更新OAuth2客户端的名称、密钥哈希、权限范围和状态
+func (r *OAuthClientRepository) Update(ctx context.Context, client *model.OAuthClient) error {
+	query := `
+		UPDATE oauth_clients
+		SET name = ?, secret_hash = ?, scopes = ?, status = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		client.Name, client.SecretHash, encodeStringList(client.Scopes), client.Status, client.ID,
+	)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update OAuth client",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "OAuth client not found",
+		}
+	}
+
+	return nil
+}
+
+// Delete 删除OAuth2客户端
+func (r *OAuthClientRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM oauth_clients WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete OAuth client",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "OAuth client not found",
+		}
+	}
+
+	return nil
+}
+
+// TouchLastToken 记录最后一次获取访问令牌的时间
+func (r *OAuthClientRepository) TouchLastToken(ctx context.Context, id int, issuedAt time.Time) error {
+	query := `UPDATE oauth_clients SET last_token_at = ? WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, issuedAt.In(time.Local), id); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update OAuth client last token time",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// get 查询单个OAuth2客户端
+func (r *OAuthClientRepository) get(ctx context.Context, query string, args ...any) (*model.OAuthClient, error) {
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "OAuth client not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get OAuth client",
+			Err:     err,
+		}
+	}
+
+	return client, nil
+}
+
+// scanOAuthClient 扫描一行OAuth2客户端数据
+func scanOAuthClient(row rowScanner) (*model.OAuthClient, error) {
+	client := &model.OAuthClient{}
+	var scopes string
+	var lastTokenAt sql.NullTime
+
+	err := row.Scan(
+		&client.ID, &client.UserID, &client.Name, &client.ClientID, &client.SecretHash,
+		&scopes, &client.Status, &client.CreatedAt, &lastTokenAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Scopes = decodeStringList(scopes)
+	if lastTokenAt.Valid {
+		client.LastTokenAt = &lastTokenAt.Time
+	}
+
+	return client, nil
+}
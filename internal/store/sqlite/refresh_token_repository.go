@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// RefreshTokenRepository 刷新令牌仓库SQLite实现
+type RefreshTokenRepository struct {
+	db DBExecutor
+}
+
+// Create 创建刷新令牌
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, parent_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	token.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.FamilyID, token.TokenHash, token.ParentID, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "refresh token already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create refresh token",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get refresh token ID",
+			Err:     err,
+		}
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+// GetByHash 根据令牌哈希获取刷新令牌
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, parent_id, expires_at, used_at, revoked_at, revoke_reason, created_at
+		FROM refresh_tokens WHERE token_hash = ?
+	`
+
+	token := &model.RefreshToken{}
+	var usedAt, revokedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ParentID,
+		&token.ExpiresAt, &usedAt, &revokedAt, &token.RevokeReason, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "refresh token not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get refresh token",
+			Err:     err,
+		}
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed 将未使用且未撤销的令牌标记为已使用
+// 令牌已被使用或已撤销时返回false，用于检测并发刷新时的重复使用
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, usedAt, id)
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to mark refresh token as used",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RevokeFamily 撤销家族中所有未撤销的令牌
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID, reason string, revokedAt time.Time) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = ?, revoke_reason = ? WHERE family_id = ? AND revoked_at IS NULL`
+
+	return r.revoke(ctx, query, revokedAt, reason, familyID)
+}
+
+// RevokeByUser 撤销用户所有未撤销的令牌
+func (r *RefreshTokenRepository) RevokeByUser(ctx context.Context, userID int, reason string, revokedAt time.Time) (int64, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = ?, revoke_reason = ? WHERE user_id = ? AND revoked_at IS NULL`
+
+	return r.revoke(ctx, query, revokedAt, reason, userID)
+}
+
+// revoke 执行撤销语句，返回撤销的令牌数
+func (r *RefreshTokenRepository) revoke(ctx context.Context, query string, args ...any) (int64, error) {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to revoke refresh tokens",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteExpired 删除已过期的刷新令牌，最多删除limit条
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	query := `DELETE FROM refresh_tokens WHERE id IN (
+		SELECT id FROM refresh_tokens WHERE expires_at <= ? ORDER BY id LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, now.In(time.Local), limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete expired refresh tokens",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
@@ -3,7 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"time"
 
 	"apihub/internal/model"
@@ -214,7 +214,7 @@ func (r *ServiceRepository) List(ctx context.Context, offset, limit int) ([]*mod
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭服务列表查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭服务列表查询时出错", "error", closeErr)
 		}
 	}()
 
@@ -266,7 +266,7 @@ func (r *ServiceRepository) GetEnabled(ctx context.Context) ([]*model.ServiceDef
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭启用服务查询时出错: %v", closeErr)
+			slog.WarnContext(ctx, "关闭启用服务查询时出错", "error", closeErr)
 		}
 	}()
 
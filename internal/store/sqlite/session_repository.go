@@ -0,0 +1,281 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// sessionColumns 会话查询列
+const sessionColumns = `id, user_id, device, client_ip, user_agent, created_at, last_seen_at, expires_at, revoked_at, revoke_reason`
+
+// SessionRepository 登录会话仓库SQLite实现
+type SessionRepository struct {
+	db DBExecutor
+}
+
+// Create 创建会话
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device, client_ip, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.Device, session.ClientIP, session.UserAgent,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "session already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create session",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// GetByID 根据ID获取会话，包括已撤销和已过期的会话
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ?`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &store.DBError{
+				Code:    store.ErrNotFound,
+				Message: "session not found",
+			}
+		}
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get session",
+			Err:     err,
+		}
+	}
+
+	return session, nil
+}
+
+// ListActiveByUser 获取用户未撤销且未过期的会话，按最后活动时间倒序
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]*model.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, now.In(time.Local))
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list sessions",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭会话查询时出错", "error", closeErr)
+		}
+	}()
+
+	sessions := make([]*model.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan session",
+				Err:     err,
+			}
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate sessions",
+			Err:     err,
+		}
+	}
+
+	return sessions, nil
+}
+
+// Touch 更新会话的最后活动时间和客户端信息
+func (r *SessionRepository) Touch(ctx context.Context, id string, lastSeenAt time.Time, clientIP, userAgent string) error {
+	query := `UPDATE sessions SET last_seen_at = ?, client_ip = ?, user_agent = ? WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, lastSeenAt, clientIP, userAgent, id); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update session",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// Extend 延长未撤销会话的过期时间
+func (r *SessionRepository) Extend(ctx context.Context, id string, expiresAt time.Time) error {
+	query := `UPDATE sessions SET expires_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, expiresAt, id); err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to extend session",
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// Revoke 撤销会话
+func (r *SessionRepository) Revoke(ctx context.Context, id, reason string, revokedAt time.Time) error {
+	query := `UPDATE sessions SET revoked_at = ?, revoke_reason = ? WHERE id = ? AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, revokedAt, reason, id)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to revoke session",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "session not found",
+		}
+	}
+
+	return nil
+}
+
+// RevokeByUser 撤销用户所有未撤销的会话，exceptID不为空时保留该会话，返回被撤销的会话ID
+func (r *SessionRepository) RevokeByUser(ctx context.Context, userID int, exceptID, reason string, revokedAt time.Time) ([]string, error) {
+	query := `
+		UPDATE sessions SET revoked_at = ?, revoke_reason = ?
+		WHERE user_id = ? AND id != ? AND revoked_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, revokedAt, reason, userID, exceptID)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to revoke sessions",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭会话查询时出错", "error", closeErr)
+		}
+	}()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan session ID",
+				Err:     err,
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to revoke sessions",
+			Err:     err,
+		}
+	}
+
+	return ids, nil
+}
+
+// DeleteExpired 删除已过期的会话，最多删除limit条
+func (r *SessionRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	query := `DELETE FROM sessions WHERE id IN (
+		SELECT id FROM sessions WHERE expires_at <= ? ORDER BY expires_at LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, now.In(time.Local), limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete expired sessions",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
+
+// rowScanner sql.Row和sql.Rows共有的Scan方法
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSession 扫描一行会话数据
+func scanSession(row rowScanner) (*model.Session, error) {
+	session := &model.Session{}
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.Device, &session.ClientIP, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt, &session.RevokeReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
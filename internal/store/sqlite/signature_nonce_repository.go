@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"apihub/internal/store"
+)
+
+// SignatureNonceRepository 签名请求随机数仓库SQLite实现
+type SignatureNonceRepository struct {
+	db DBExecutor
+}
+
+// Use 记录随机数，同一访问密钥的随机数已记录且未过期时返回false
+// 已过期的记录尚未被清理时直接覆盖
+func (r *SignatureNonceRepository) Use(ctx context.Context, accessKey, nonce string, now, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO signature_nonces (access_key, nonce, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (access_key, nonce) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE signature_nonces.expires_at <= ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, accessKey, nonce, expiresAt.In(time.Local), now.In(time.Local))
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to record signature nonce",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteExpired 删除过期时间早于now的随机数，最多删除limit条
+func (r *SignatureNonceRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	query := `DELETE FROM signature_nonces WHERE rowid IN (
+		SELECT rowid FROM signature_nonces WHERE expires_at <= ? ORDER BY expires_at LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, now.In(time.Local), limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete expired signature nonces",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
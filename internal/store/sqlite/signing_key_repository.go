@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// SigningKeyRepository JWT签名密钥仓库SQLite实现
+type SigningKeyRepository struct {
+	db DBExecutor
+}
+
+// Create 创建签名密钥
+func (r *SigningKeyRepository) Create(ctx context.Context, key *model.SigningKey) error {
+	query := `
+		INSERT INTO jwt_signing_keys (kid, algorithm, private_key, public_key, activates_at, sign_until, verify_until, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	key.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		key.KID, key.Algorithm, key.PrivateKey, key.PublicKey,
+		key.ActivatesAt, key.SignUntil, key.VerifyUntil, key.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return &store.DBError{
+				Code:    store.ErrDuplicateKey,
+				Message: "signing key already exists",
+				Err:     err,
+			}
+		}
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to create signing key",
+			Err:     err,
+		}
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get signing key ID",
+			Err:     err,
+		}
+	}
+
+	key.ID = int(id)
+	return nil
+}
+
+// ListValid 获取验证截止时间晚于now的密钥，按开始签名时间升序
+func (r *SigningKeyRepository) ListValid(ctx context.Context, now time.Time) ([]*model.SigningKey, error) {
+	query := `
+		SELECT id, kid, algorithm, private_key, public_key, activates_at, sign_until, verify_until, created_at
+		FROM jwt_signing_keys
+		WHERE verify_until > ?
+		ORDER BY activates_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now.In(time.Local))
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list signing keys",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭签名密钥查询时出错", "error", closeErr)
+		}
+	}()
+
+	keys := make([]*model.SigningKey, 0)
+	for rows.Next() {
+		key := &model.SigningKey{}
+		err := rows.Scan(
+			&key.ID, &key.KID, &key.Algorithm, &key.PrivateKey, &key.PublicKey,
+			&key.ActivatesAt, &key.SignUntil, &key.VerifyUntil, &key.CreatedAt,
+		)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan signing key",
+				Err:     err,
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate signing keys",
+			Err:     err,
+		}
+	}
+
+	return keys, nil
+}
+
+// UpdateSignUntil 修改密钥停止签发新令牌的时间
+func (r *SigningKeyRepository) UpdateSignUntil(ctx context.Context, id int, signUntil time.Time) error {
+	query := `UPDATE jwt_signing_keys SET sign_until = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, signUntil, id)
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to update signing key",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return &store.DBError{
+			Code:    store.ErrNotFound,
+			Message: "signing key not found",
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpired 删除验证截止时间早于now的密钥
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM jwt_signing_keys WHERE verify_until <= ?`
+
+	result, err := r.db.ExecContext(ctx, query, now.In(time.Local))
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete expired signing keys",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
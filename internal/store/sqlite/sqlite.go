@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
 
@@ -77,6 +77,11 @@ func (s *SQLiteStore) Close() error {
 	return nil
 }
 
+// DB 返回底层数据库连接池，未连接时返回nil
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
 // Migrate 执行数据库迁移
 func (s *SQLiteStore) Migrate() error {
 	if s.db == nil {
@@ -123,7 +128,7 @@ func (s *SQLiteStore) Migrate() error {
 	}
 	defer func() {
 		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("关闭迁移查询时出错: %v", closeErr)
+			slog.Warn("关闭迁移查询时出错", "error", closeErr)
 		}
 	}()
 
@@ -162,11 +167,11 @@ func (s *SQLiteStore) Migrate() error {
 	for _, name := range migrationNames {
 		// 如果已应用，跳过
 		if appliedMigrations[name] {
-			log.Printf("迁移 %s 已应用，跳过", name)
+			slog.Debug("迁移已应用，跳过", "migration", name)
 			continue
 		}
 
-		log.Printf("应用迁移 %s", name)
+		slog.Info("应用迁移", "migration", name)
 
 		// 注意：embed.FS 总是使用正斜杠，即使在 Windows 上也是如此
 		migrationPath := "migrations/" + name
@@ -192,7 +197,7 @@ func (s *SQLiteStore) Migrate() error {
 		// 执行迁移SQL
 		if _, err := tx.Exec(string(content)); err != nil {
 			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				log.Printf("回滚事务时出错: %v", rollbackErr)
+				slog.Error("回滚迁移事务时出错", "migration", name, "error", rollbackErr)
 			}
 			return &store.DBError{
 				Code:    store.ErrMigrationFailed,
@@ -204,7 +209,7 @@ func (s *SQLiteStore) Migrate() error {
 		// 记录迁移
 		if _, err := tx.Exec("INSERT INTO migrations (name) VALUES (?)", name); err != nil {
 			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				log.Printf("回滚事务时出错: %v", rollbackErr)
+				slog.Error("回滚迁移事务时出错", "migration", name, "error", rollbackErr)
 			}
 			return &store.DBError{
 				Code:    store.ErrMigrationFailed,
@@ -222,7 +227,7 @@ func (s *SQLiteStore) Migrate() error {
 			}
 		}
 
-		log.Printf("迁移 %s 应用成功", name)
+		slog.Info("迁移应用成功", "migration", name)
 	}
 
 	return nil
@@ -247,32 +252,87 @@ func (s *SQLiteStore) BeginTx(ctx context.Context) (store.Transaction, error) {
 
 // Users 返回用户仓库
 func (s *SQLiteStore) Users() store.UserRepository {
-	return &UserRepository{db: s.db}
+	return &UserRepository{db: traced(s.db)}
 }
 
 // APIKeys 返回API密钥仓库
 func (s *SQLiteStore) APIKeys() store.APIKeyRepository {
-	return &APIKeyRepository{db: s.db}
+	return &APIKeyRepository{db: traced(s.db)}
 }
 
 // Configs 返回系统配置仓库
 func (s *SQLiteStore) Configs() store.ConfigRepository {
-	return &ConfigRepository{db: s.db}
+	return &ConfigRepository{db: traced(s.db)}
 }
 
 // Quotas 返回服务配额仓库
 func (s *SQLiteStore) Quotas() store.QuotaRepository {
-	return &QuotaRepository{db: s.db}
+	return &QuotaRepository{db: traced(s.db)}
 }
 
 // Services 返回服务定义仓库
 func (s *SQLiteStore) Services() store.ServiceRepository {
-	return &ServiceRepository{db: s.db}
+	return &ServiceRepository{db: traced(s.db)}
 }
 
 // AccessLogs 返回访问日志仓库
 func (s *SQLiteStore) AccessLogs() store.AccessLogRepository {
-	return &AccessLogRepository{db: s.db}
+	return &AccessLogRepository{db: traced(s.db)}
+}
+
+// IPRules 返回IP访问规则仓库
+func (s *SQLiteStore) IPRules() store.IPRuleRepository {
+	return &IPRuleRepository{db: traced(s.db)}
+}
+
+// UsageRollups 返回使用量汇总仓库
+func (s *SQLiteStore) UsageRollups() store.UsageRollupRepository {
+	return &UsageRollupRepository{db: traced(s.db)}
+}
+
+// AuditLogs 返回审计日志仓库
+func (s *SQLiteStore) AuditLogs() store.AuditLogRepository {
+	return &AuditLogRepository{db: traced(s.db)}
+}
+
+// CaptureRules 返回抓取规则仓库
+func (s *SQLiteStore) CaptureRules() store.CaptureRuleRepository {
+	return &CaptureRuleRepository{db: traced(s.db)}
+}
+
+// Captures 返回抓取记录仓库
+func (s *SQLiteStore) Captures() store.CaptureRepository {
+	return &CaptureRepository{db: traced(s.db)}
+}
+
+// SigningKeys 返回JWT签名密钥仓库
+func (s *SQLiteStore) SigningKeys() store.SigningKeyRepository {
+	return &SigningKeyRepository{db: traced(s.db)}
+}
+
+// RefreshTokens 返回刷新令牌仓库
+func (s *SQLiteStore) RefreshTokens() store.RefreshTokenRepository {
+	return &RefreshTokenRepository{db: traced(s.db)}
+}
+
+// Sessions 返回登录会话仓库
+func (s *SQLiteStore) Sessions() store.SessionRepository {
+	return &SessionRepository{db: traced(s.db)}
+}
+
+// Notifications 返回站内通知仓库
+func (s *SQLiteStore) Notifications() store.NotificationRepository {
+	return &NotificationRepository{db: traced(s.db)}
+}
+
+// OAuthClients 返回OAuth2客户端仓库
+func (s *SQLiteStore) OAuthClients() store.OAuthClientRepository {
+	return &OAuthClientRepository{db: traced(s.db)}
+}
+
+// SignatureNonces 返回签名请求随机数仓库
+func (s *SQLiteStore) SignatureNonces() store.SignatureNonceRepository {
+	return &SignatureNonceRepository{db: traced(s.db)}
 }
 
 // 事务方法实现
@@ -303,32 +363,87 @@ func (tx *SQLiteTransaction) Rollback() error {
 
 // Users 返回事务中的用户仓库
 func (tx *SQLiteTransaction) Users() store.UserRepository {
-	return &UserRepository{db: tx.tx}
+	return &UserRepository{db: traced(tx.tx)}
 }
 
 // APIKeys 返回事务中的API密钥仓库
 func (tx *SQLiteTransaction) APIKeys() store.APIKeyRepository {
-	return &APIKeyRepository{db: tx.tx}
+	return &APIKeyRepository{db: traced(tx.tx)}
 }
 
 // Configs 返回事务中的系统配置仓库
 func (tx *SQLiteTransaction) Configs() store.ConfigRepository {
-	return &ConfigRepository{db: tx.tx}
+	return &ConfigRepository{db: traced(tx.tx)}
 }
 
 // Quotas 返回事务中的服务配额仓库
 func (tx *SQLiteTransaction) Quotas() store.QuotaRepository {
-	return &QuotaRepository{db: tx.tx}
+	return &QuotaRepository{db: traced(tx.tx)}
 }
 
 // Services 返回事务中的服务定义仓库
 func (tx *SQLiteTransaction) Services() store.ServiceRepository {
-	return &ServiceRepository{db: tx.tx}
+	return &ServiceRepository{db: traced(tx.tx)}
 }
 
 // AccessLogs 返回事务中的访问日志仓库
 func (tx *SQLiteTransaction) AccessLogs() store.AccessLogRepository {
-	return &AccessLogRepository{db: tx.tx}
+	return &AccessLogRepository{db: traced(tx.tx)}
+}
+
+// IPRules 返回事务中的IP访问规则仓库
+func (tx *SQLiteTransaction) IPRules() store.IPRuleRepository {
+	return &IPRuleRepository{db: traced(tx.tx)}
+}
+
+// UsageRollups 返回事务中的使用量汇总仓库
+func (tx *SQLiteTransaction) UsageRollups() store.UsageRollupRepository {
+	return &UsageRollupRepository{db: traced(tx.tx)}
+}
+
+// AuditLogs 返回事务中的审计日志仓库
+func (tx *SQLiteTransaction) AuditLogs() store.AuditLogRepository {
+	return &AuditLogRepository{db: traced(tx.tx)}
+}
+
+// CaptureRules 返回事务中的抓取规则仓库
+func (tx *SQLiteTransaction) CaptureRules() store.CaptureRuleRepository {
+	return &CaptureRuleRepository{db: traced(tx.tx)}
+}
+
+// Captures 返回事务中的抓取记录仓库
+func (tx *SQLiteTransaction) Captures() store.CaptureRepository {
+	return &CaptureRepository{db: traced(tx.tx)}
+}
+
+// SigningKeys 返回事务中的JWT签名密钥仓库
+func (tx *SQLiteTransaction) SigningKeys() store.SigningKeyRepository {
+	return &SigningKeyRepository{db: traced(tx.tx)}
+}
+
+// RefreshTokens 返回事务中的刷新令牌仓库
+func (tx *SQLiteTransaction) RefreshTokens() store.RefreshTokenRepository {
+	return &RefreshTokenRepository{db: traced(tx.tx)}
+}
+
+// Sessions 返回事务中的登录会话仓库
+func (tx *SQLiteTransaction) Sessions() store.SessionRepository {
+	return &SessionRepository{db: traced(tx.tx)}
+}
+
+// Notifications 返回事务中的站内通知仓库
+func (tx *SQLiteTransaction) Notifications() store.NotificationRepository {
+	return &NotificationRepository{db: traced(tx.tx)}
+}
+
+// OAuthClients 返回事务中的OAuth2客户端仓库
+func (tx *SQLiteTransaction) OAuthClients() store.OAuthClientRepository {
+	return &OAuthClientRepository{db: traced(tx.tx)}
+}
+
+// SignatureNonces 返回事务中的签名请求随机数仓库
+func (tx *SQLiteTransaction) SignatureNonces() store.SignatureNonceRepository {
+	return &SignatureNonceRepository{db: traced(tx.tx)}
 }
 
 // DBExecutor 数据库执行器接口，用于统一处理 *sql.DB 和 *sql.Tx
@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+
+	"apihub/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingExecutor 为每次数据库调用创建span的执行器
+// 仅在上下文中已有span时才创建，后台任务和迁移不产生孤立的追踪
+type tracingExecutor struct {
+	db DBExecutor
+}
+
+// traced 包装执行器以记录数据库调用span
+func traced(db DBExecutor) DBExecutor {
+	return tracingExecutor{db: db}
+}
+
+// unwrapExecutor 获取被包装的原始执行器，用于判断是否处于事务中
+func unwrapExecutor(db DBExecutor) DBExecutor {
+	if e, ok := db.(tracingExecutor); ok {
+		return e.db
+	}
+	return db
+}
+
+// ExecContext 执行语句
+func (e tracingExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(ctx, query)
+	result, err := e.db.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+// QueryContext 执行查询
+// span只覆盖查询本身，不包括调用方遍历结果集的时间
+func (e tracingExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startSpan(ctx, query)
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext 执行单行查询
+func (e tracingExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(ctx, query)
+	row := e.db.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	endSpan(span, err)
+	return row
+}
+
+// startSpan 以调用方仓库方法名创建span，如 sqlite.APIKeyRepository.GetByPrefix
+func startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, nil
+	}
+
+	return tracing.Start(ctx, callerName(3),
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.statement", query),
+	)
+}
+
+// endSpan 结束span
+func endSpan(span trace.Span, err error) {
+	if span != nil {
+		tracing.End(span, err)
+	}
+}
+
+// callerName 获取调用栈中指定层级的函数名
+func callerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "sqlite.query"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "sqlite.query"
+	}
+
+	// apihub/internal/store/sqlite.(*APIKeyRepository).GetByPrefix -> sqlite.APIKeyRepository.GetByPrefix
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
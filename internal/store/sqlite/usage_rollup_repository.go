@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"apihub/internal/model"
+	"apihub/internal/store"
+)
+
+// usageGroupColumns 分组维度对应的列
+var usageGroupColumns = map[string]string{
+	model.UsageGroupByService: "service_name",
+	model.UsageGroupByAPIKey:  "api_key_id",
+	model.UsageGroupByStatus:  "status_class",
+	model.UsageGroupByUser:    "user_id",
+}
+
+// UsageRollupRepository 使用量汇总仓库SQLite实现
+type UsageRollupRepository struct {
+	db DBExecutor
+}
+
+// Increment 累加使用量汇总记录
+func (r *UsageRollupRepository) Increment(ctx context.Context, rollups []*model.UsageRollup) error {
+	query := `
+		INSERT INTO usage_rollups (granularity, bucket, user_id, api_key_id, service_name, status_class,
+			total_calls, total_cost, total_duration_ms, max_duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (granularity, bucket, user_id, api_key_id, service_name, status_class) DO UPDATE SET
+			total_calls = total_calls + excluded.total_calls,
+			total_cost = total_cost + excluded.total_cost,
+			total_duration_ms = total_duration_ms + excluded.total_duration_ms,
+			max_duration_ms = MAX(max_duration_ms, excluded.max_duration_ms)
+	`
+
+	for _, rollup := range rollups {
+		_, err := r.db.ExecContext(ctx, query,
+			rollup.Granularity, rollup.Bucket, rollup.UserID, rollup.APIKeyID, rollup.ServiceName, rollup.StatusClass,
+			rollup.TotalCalls, rollup.TotalCost, rollup.TotalDurationMs, rollup.MaxDurationMs,
+		)
+		if err != nil {
+			return &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to increment usage rollup",
+				Err:     err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Query 查询使用量统计
+func (r *UsageRollupRepository) Query(ctx context.Context, filter model.UsageStatsFilter) ([]model.UsageStatsPoint, error) {
+	groupColumns := []string{"bucket"}
+	for _, dimension := range filter.GroupBy {
+		column, ok := usageGroupColumns[dimension]
+		if !ok {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "unsupported usage group dimension: " + dimension,
+			}
+		}
+		groupColumns = append(groupColumns, column)
+	}
+
+	conditions := []string{"granularity = ?", "bucket >= ?", "bucket <= ?"}
+	args := []interface{}{filter.Granularity, filter.StartBucket, filter.EndBucket}
+	if filter.UserID > 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.APIKeyID > 0 {
+		conditions = append(conditions, "api_key_id = ?")
+		args = append(args, filter.APIKeyID)
+	}
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = ?")
+		args = append(args, filter.ServiceName)
+	}
+
+	groupBy := strings.Join(groupColumns, ", ")
+	query := `
+		SELECT ` + groupBy + `,
+			SUM(total_calls),
+			SUM(CASE WHEN status_class IN ('4xx', '5xx') THEN total_calls ELSE 0 END),
+			SUM(total_cost),
+			SUM(total_duration_ms),
+			MAX(max_duration_ms)
+		FROM usage_rollups
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY ` + groupBy + `
+		ORDER BY ` + groupBy
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to query usage rollups",
+			Err:     err,
+		}
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "关闭使用量统计查询时出错", "error", closeErr)
+		}
+	}()
+
+	points := make([]model.UsageStatsPoint, 0)
+	for rows.Next() {
+		var point model.UsageStatsPoint
+		var totalDurationMs int64
+
+		dest := []interface{}{&point.Bucket}
+		for _, dimension := range filter.GroupBy {
+			switch dimension {
+			case model.UsageGroupByService:
+				dest = append(dest, &point.ServiceName)
+			case model.UsageGroupByAPIKey:
+				dest = append(dest, &point.APIKeyID)
+			case model.UsageGroupByStatus:
+				dest = append(dest, &point.StatusClass)
+			case model.UsageGroupByUser:
+				dest = append(dest, &point.UserID)
+			}
+		}
+		dest = append(dest, &point.TotalCalls, &point.ErrorCalls, &point.TotalCost, &totalDurationMs, &point.MaxDurationMs)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan usage rollup",
+				Err:     err,
+			}
+		}
+
+		if point.TotalCalls > 0 {
+			point.AvgDurationMs = float64(totalDurationMs) / float64(point.TotalCalls)
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate usage rollups",
+			Err:     err,
+		}
+	}
+
+	return points, nil
+}
+
+// DeleteBefore 分批删除指定粒度下早于bucket的汇总记录
+// 时间桶为UTC格式化字符串，可直接按字典序比较
+func (r *UsageRollupRepository) DeleteBefore(ctx context.Context, granularity, bucket string, limit int) (int64, error) {
+	query := `DELETE FROM usage_rollups WHERE rowid IN (
+		SELECT rowid FROM usage_rollups WHERE granularity = ? AND bucket < ? LIMIT ?
+	)`
+
+	result, err := r.db.ExecContext(ctx, query, granularity, bucket, limit)
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to delete old usage rollups",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to get affected rows",
+			Err:     err,
+		}
+	}
+
+	return rowsAffected, nil
+}
@@ -274,6 +274,53 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*model.
 	return users, nil
 }
 
+// ListByRole 获取指定角色的所有用户，包括已禁用的用户
+func (r *UserRepository) ListByRole(ctx context.Context, role string) ([]*model.User, error) {
+	query := `
+		SELECT id, username, password, email, role, status, created_at, updated_at
+		FROM users
+		WHERE role = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to list users by role",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	users := make([]*model.User, 0)
+	for rows.Next() {
+		user := &model.User{}
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Password, &user.Email,
+			&user.Role, &user.Status, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, &store.DBError{
+				Code:    store.ErrDataConstraint,
+				Message: "failed to scan user",
+				Err:     err,
+			}
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &store.DBError{
+			Code:    store.ErrDataConstraint,
+			Message: "failed to iterate users",
+			Err:     err,
+		}
+	}
+
+	return users, nil
+}
+
 // Count 获取用户总数
 func (r *UserRepository) Count(ctx context.Context) (int, error) {
 	query := `SELECT COUNT(*) FROM users`
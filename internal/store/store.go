@@ -3,6 +3,8 @@ package store
 import (
 	"apihub/internal/model"
 	"context"
+	"log/slog"
+	"time"
 )
 
 // Store 存储层主接口
@@ -24,6 +26,17 @@ type Store interface {
 	Quotas() QuotaRepository
 	Services() ServiceRepository
 	AccessLogs() AccessLogRepository
+	IPRules() IPRuleRepository
+	UsageRollups() UsageRollupRepository
+	AuditLogs() AuditLogRepository
+	CaptureRules() CaptureRuleRepository
+	Captures() CaptureRepository
+	SigningKeys() SigningKeyRepository
+	RefreshTokens() RefreshTokenRepository
+	Sessions() SessionRepository
+	Notifications() NotificationRepository
+	OAuthClients() OAuthClientRepository
+	SignatureNonces() SignatureNonceRepository
 }
 
 // Transaction 事务接口
@@ -38,6 +51,17 @@ type Transaction interface {
 	Quotas() QuotaRepository
 	Services() ServiceRepository
 	AccessLogs() AccessLogRepository
+	IPRules() IPRuleRepository
+	UsageRollups() UsageRollupRepository
+	AuditLogs() AuditLogRepository
+	CaptureRules() CaptureRuleRepository
+	Captures() CaptureRepository
+	SigningKeys() SigningKeyRepository
+	RefreshTokens() RefreshTokenRepository
+	Sessions() SessionRepository
+	Notifications() NotificationRepository
+	OAuthClients() OAuthClientRepository
+	SignatureNonces() SignatureNonceRepository
 }
 
 // UserRepository 用户仓库接口
@@ -49,6 +73,8 @@ type UserRepository interface {
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, offset, limit int) ([]*model.User, error)
+	// ListByRole 获取指定角色的所有用户，包括已禁用的用户
+	ListByRole(ctx context.Context, role string) ([]*model.User, error)
 	Count(ctx context.Context) (int, error)
 }
 
@@ -56,11 +82,25 @@ type UserRepository interface {
 type APIKeyRepository interface {
 	Create(ctx context.Context, apiKey *model.APIKey) error
 	GetByID(ctx context.Context, id int) (*model.APIKey, error)
-	GetByKey(ctx context.Context, key string) (*model.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) ([]*model.APIKey, error)
 	GetByUserID(ctx context.Context, userID int) ([]*model.APIKey, error)
 	Update(ctx context.Context, apiKey *model.APIKey) error
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, offset, limit int) ([]*model.APIKey, error)
+	ListLegacy(ctx context.Context) ([]*model.APIKey, error)
+	ConsumeUse(ctx context.Context, id int, maxUses int64) (bool, error)
+	// MarkRotated 将尚未轮换的有效密钥标记为已轮换，已被轮换或已禁用时返回false
+	MarkRotated(ctx context.Context, id, successorID int, rotatedAt, expiresAt time.Time) (bool, error)
+	// ListRotationsEnding 获取已轮换、尚未发送通知且宽限期在(now, deadline]内结束的有效密钥
+	ListRotationsEnding(ctx context.Context, now, deadline time.Time) ([]*model.APIKey, error)
+	// MarkRotationNotified 记录宽限期即将结束的通知已发送，已记录过时返回false
+	MarkRotationNotified(ctx context.Context, id int, notifiedAt time.Time) (bool, error)
+	// TouchLastUsed 更新最后使用时间和IP，已记录的时间更晚时不更新
+	TouchLastUsed(ctx context.Context, id int, usedAt time.Time, clientIP string) error
+	// ListUnused 获取最后使用时间（从未使用时为创建时间）早于before且未过期的有效密钥
+	ListUnused(ctx context.Context, before, now time.Time) ([]*model.APIKey, error)
+	// DisableUnused 禁用最后使用时间（从未使用时为创建时间）仍早于before的有效密钥，期间被使用或已禁用时返回false
+	DisableUnused(ctx context.Context, id int, before time.Time) (bool, error)
 }
 
 // ConfigRepository 系统配置仓库接口
@@ -97,12 +137,172 @@ type ServiceRepository interface {
 // AccessLogRepository 访问日志仓库接口
 type AccessLogRepository interface {
 	Create(ctx context.Context, log *model.AccessLog) error
+	// CreateBatch 批量写入访问日志，建议在事务中调用
+	CreateBatch(ctx context.Context, logs []*model.AccessLog) error
 	GetByID(ctx context.Context, id int) (*model.AccessLog, error)
 	GetByUserID(ctx context.Context, userID int, offset, limit int) ([]*model.AccessLog, error)
 	GetByAPIKeyID(ctx context.Context, apiKeyID int, offset, limit int) ([]*model.AccessLog, error)
 	GetUsageStats(ctx context.Context, userID int, serviceName, startDate, endDate string) (*model.UsageStatsResponse, error)
 	List(ctx context.Context, offset, limit int) ([]*model.AccessLog, error)
 	DeleteOldLogs(ctx context.Context, beforeDate string) error
+	// Query 按条件查询访问日志，结果按ID倒序
+	Query(ctx context.Context, filter model.AccessLogFilter) ([]*model.AccessLog, error)
+	// Iterate 按条件逐条遍历访问日志，用于大范围导出，fn返回错误时停止遍历
+	Iterate(ctx context.Context, filter model.AccessLogFilter, fn func(*model.AccessLog) error) error
+	// ListBefore 按ID升序获取早于指定时间的访问日志，用于清理前归档
+	ListBefore(ctx context.Context, before time.Time, limit int) ([]*model.AccessLog, error)
+	// DeleteBefore 删除早于指定时间且ID不大于maxID的访问日志，最多删除limit条，maxID<=0时不限制ID
+	DeleteBefore(ctx context.Context, before time.Time, maxID, limit int) (int64, error)
+	// CallStatsByAPIKey 统计API密钥自since起的调用次数、最后调用时间和最多maxClientIPs个客户端IP
+	CallStatsByAPIKey(ctx context.Context, apiKeyID int, since time.Time, maxClientIPs int) (*model.APIKeyCallStats, error)
+}
+
+// IPRuleRepository IP访问规则仓库接口
+type IPRuleRepository interface {
+	Create(ctx context.Context, rule *model.IPRule) error
+	GetByID(ctx context.Context, id int) (*model.IPRule, error)
+	// List 按作用范围和目标过滤规则，参数为空时不过滤
+	List(ctx context.Context, scope, target string) ([]*model.IPRule, error)
+	Delete(ctx context.Context, id int) error
+	DeleteByTarget(ctx context.Context, scope, target string) error
+}
+
+// UsageRollupRepository 使用量汇总仓库接口
+type UsageRollupRepository interface {
+	// Increment 累加汇总记录，不存在时创建
+	Increment(ctx context.Context, rollups []*model.UsageRollup) error
+	// Query 按条件查询统计数据，结果按时间桶排序
+	Query(ctx context.Context, filter model.UsageStatsFilter) ([]model.UsageStatsPoint, error)
+	// DeleteBefore 删除指定粒度下时间桶早于bucket的汇总记录，最多删除limit条
+	DeleteBefore(ctx context.Context, granularity, bucket string, limit int) (int64, error)
+}
+
+// AuditLogRepository 审计日志仓库接口
+// 审计日志只允许追加，不提供修改和删除方法
+type AuditLogRepository interface {
+	// Append 追加审计日志并计算哈希链，应与被审计的变更在同一事务中调用
+	Append(ctx context.Context, log *model.AuditLog) error
+	// Query 按条件查询审计日志，结果按ID倒序
+	Query(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, error)
+	// Iterate 按条件逐条遍历审计日志，结果按ID倒序，fn返回错误时停止遍历
+	Iterate(ctx context.Context, filter model.AuditLogFilter, fn func(*model.AuditLog) error) error
+	// Walk 按ID升序遍历全部审计日志，用于校验哈希链
+	Walk(ctx context.Context, fn func(*model.AuditLog) error) error
+}
+
+// CaptureRuleRepository 请求/响应抓取规则仓库接口
+type CaptureRuleRepository interface {
+	Create(ctx context.Context, rule *model.CaptureRule) error
+	GetByID(ctx context.Context, id int) (*model.CaptureRule, error)
+	// List 按作用范围和目标过滤规则，参数为空时不过滤
+	List(ctx context.Context, scope, target string) ([]*model.CaptureRule, error)
+	Update(ctx context.Context, rule *model.CaptureRule) error
+	Delete(ctx context.Context, id int) error
+}
+
+// CaptureRepository 请求/响应抓取记录仓库接口
+type CaptureRepository interface {
+	Create(ctx context.Context, exchange *model.CapturedExchange) error
+	// GetByID 获取未过期的抓取记录，包含加密内容和重放请求
+	GetByID(ctx context.Context, id int) (*model.CapturedExchange, error)
+	// Query 按条件查询未过期的抓取记录，结果按ID倒序，不包含加密内容
+	Query(ctx context.Context, filter model.CaptureFilter) ([]*model.CapturedExchange, error)
+	// DeleteExpired 删除过期时间早于now的抓取记录，最多删除limit条
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+// SigningKeyRepository JWT签名密钥仓库接口
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *model.SigningKey) error
+	// ListValid 获取验证截止时间晚于now的密钥，按开始签名时间升序
+	ListValid(ctx context.Context, now time.Time) ([]*model.SigningKey, error)
+	// UpdateSignUntil 修改密钥停止签发新令牌的时间
+	UpdateSignUntil(ctx context.Context, id int, signUntil time.Time) error
+	// DeleteExpired 删除验证截止时间早于now的密钥
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RefreshTokenRepository 刷新令牌仓库接口
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	// MarkUsed 将未使用且未撤销的令牌标记为已使用，令牌已被使用或已撤销时返回false
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error)
+	// RevokeFamily 撤销家族中所有未撤销的令牌，返回撤销的令牌数
+	RevokeFamily(ctx context.Context, familyID, reason string, revokedAt time.Time) (int64, error)
+	// RevokeByUser 撤销用户所有未撤销的令牌，返回撤销的令牌数
+	RevokeByUser(ctx context.Context, userID int, reason string, revokedAt time.Time) (int64, error)
+	// DeleteExpired 删除过期时间早于now的令牌，最多删除limit条
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+// SessionRepository 登录会话仓库接口
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	// GetByID 根据ID获取会话，包括已撤销和已过期的会话
+	GetByID(ctx context.Context, id string) (*model.Session, error)
+	// ListActiveByUser 获取用户未撤销且未过期的会话，按最后活动时间倒序
+	ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]*model.Session, error)
+	// Touch 更新会话的最后活动时间和客户端信息
+	Touch(ctx context.Context, id string, lastSeenAt time.Time, clientIP, userAgent string) error
+	// Extend 延长未撤销会话的过期时间
+	Extend(ctx context.Context, id string, expiresAt time.Time) error
+	// Revoke 撤销会话，会话不存在或已撤销时返回ErrNotFound
+	Revoke(ctx context.Context, id, reason string, revokedAt time.Time) error
+	// RevokeByUser 撤销用户所有未撤销的会话，exceptID不为空时保留该会话，返回被撤销的会话ID
+	RevokeByUser(ctx context.Context, userID int, exceptID, reason string, revokedAt time.Time) ([]string, error)
+	// DeleteExpired 删除过期时间早于now的会话，最多删除limit条
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+// SignatureNonceRepository 签名请求随机数仓库接口
+type SignatureNonceRepository interface {
+	// Use 记录随机数，同一访问密钥的随机数已记录且未过期时返回false
+	Use(ctx context.Context, accessKey, nonce string, now, expiresAt time.Time) (bool, error)
+	// DeleteExpired 删除过期时间早于now的随机数，最多删除limit条
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+// NotificationRepository 站内通知仓库接口
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	// ListByUser 获取用户的通知，按ID倒序，unreadOnly为true时只返回未读通知
+	ListByUser(ctx context.Context, userID int, unreadOnly bool, offset, limit int) ([]*model.Notification, error)
+	// CountUnread 获取用户的未读通知数
+	CountUnread(ctx context.Context, userID int) (int, error)
+	// MarkRead 将用户的通知标记为已读，ids为空时标记全部，返回标记的通知数
+	MarkRead(ctx context.Context, userID int, ids []int, readAt time.Time) (int64, error)
+}
+
+// OAuthClientRepository OAuth2客户端仓库接口
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	GetByID(ctx context.Context, id int) (*model.OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	// ListByUser 获取用户的OAuth2客户端，按创建时间倒序
+	ListByUser(ctx context.Context, userID int) ([]*model.OAuthClient, error)
+	// Update 更新名称、密钥哈希、权限范围和状态
+	Update(ctx context.Context, client *model.OAuthClient) error
+	Delete(ctx context.Context, id int) error
+	// TouchLastToken 记录最后一次获取访问令牌的时间
+	TouchLastToken(ctx context.Context, id int, issuedAt time.Time) error
+}
+
+// WithTx 在事务中执行fn，fn返回错误时回滚，否则提交
+func WithTx(ctx context.Context, s Store, fn func(tx Transaction) error) error {
+	tx, err := s.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			slog.ErrorContext(ctx, "回滚事务失败", "error", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // DBError 数据库错误类型
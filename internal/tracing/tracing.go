@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 追踪器名称
+const instrumentationName = "apihub"
+
+// 导出方式
+const (
+	ExporterOTLP   = "otlp"   // 通过OTLP/HTTP导出到Collector
+	ExporterStdout = "stdout" // 输出到标准输出，用于本地调试
+	ExporterFile   = "file"   // 输出到文件，用于本地调试
+)
+
+// Config 链路追踪配置
+type Config struct {
+	Enabled     bool
+	ServiceName string  // 上报的服务名称
+	Exporter    string  // 导出方式: otlp, stdout, file
+	Endpoint    string  // OTLP/HTTP地址（如localhost:4318），为空时使用OTEL_EXPORTER_OTLP_ENDPOINT或默认地址
+	Insecure    bool    // OTLP是否使用HTTP而非HTTPS
+	FilePath    string  // file导出方式的输出文件路径
+	SampleRatio float64 // 采样比例，取值0~1
+}
+
+// Init 初始化全局TracerProvider和W3C Trace Context传播器
+// 返回的关闭函数会导出剩余的span，应在退出前调用
+func Init(ctx context.Context, config Config) (func(context.Context) error, error) {
+	// 无论是否启用都设置传播器，使上游传入的追踪上下文可以继续向下游传递
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if config.ServiceName == "" {
+		config.ServiceName = "apihub"
+	}
+
+	exporter, closer, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", config.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter 根据配置创建span导出器
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case ExporterOTLP, "":
+		var options []otlptracehttp.Option
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建OTLP导出器失败: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建标准输出导出器失败: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		path := config.FilePath
+		if path == "" {
+			path = filepath.Join("logs", "traces.jsonl")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, nil, fmt.Errorf("创建追踪文件目录失败: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开追踪文件失败: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("创建文件导出器失败: %w", err)
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("不支持的追踪导出方式: %s", config.Exporter)
+	}
+}
+
+// Tracer 获取追踪器
+// 未启用追踪时返回的追踪器不产生任何开销
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 创建子span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err非空时记录错误状态
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GinMiddleware 创建请求根span的中间件，并从请求头中提取上游传入的追踪上下文
+func GinMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// Middleware 为中间件创建span
+// 返回的处理器链须整体展开使用，如 group.Use(tracing.Middleware("auth", handler)...)；
+// 中间件内部通常直接调用c.Next()，链中第二个处理器在后续处理器执行前结束span，使span只包含该中间件自身的耗时，
+// 中间件拒绝请求时span在中间件返回后结束
+func Middleware(name string, handler gin.HandlerFunc) gin.HandlersChain {
+	key := middlewareSpanKey(name)
+
+	start := func(c *gin.Context) {
+		parent := c.Request.Context()
+		ctx, span := Tracer().Start(parent, "middleware."+name)
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(key, &middlewareSpan{span: span, parent: trace.SpanFromContext(parent)})
+
+		handler(c)
+
+		endMiddlewareSpan(c, key)
+	}
+	end := func(c *gin.Context) {
+		endMiddlewareSpan(c, key)
+	}
+
+	return gin.HandlersChain{start, end}
+}
+
+// middlewareSpan 中间件的span及其父span
+type middlewareSpan struct {
+	span   trace.Span
+	parent trace.Span
+	ended  bool
+}
+
+// middlewareSpanKey 中间件span在gin上下文中的键
+func middlewareSpanKey(name string) string {
+	return "tracing.middleware." + name
+}
+
+// endMiddlewareSpan 结束中间件span，并将请求上下文中的当前span恢复为父span
+// 只替换span而不恢复整个父上下文，保留中间件写入请求上下文的其他值
+func endMiddlewareSpan(c *gin.Context, key string) {
+	value, ok := c.Get(key)
+	if !ok {
+		return
+	}
+	s := value.(*middlewareSpan)
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	if c.IsAborted() {
+		s.span.SetAttributes(
+			attribute.Bool("gin.aborted", true),
+			attribute.Int("http.response.status_code", c.Writer.Status()),
+		)
+	}
+	s.span.End()
+
+	c.Request = c.Request.WithContext(trace.ContextWithSpan(c.Request.Context(), s.parent))
+}